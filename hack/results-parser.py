@@ -63,6 +63,9 @@ def process_one_file(file_path, writer, alg_name, detail_writer=None):
             max_deviation = metric()
             csv_reader = csv.DictReader(csv_file)
             for row in csv_reader:
+                # skip the aggregate summary row written by the simulator
+                if row['input name'] == 'AGGREGATE':
+                    continue
                 if row['score'] == 'invalid':
                     invalid_records += 1
                     continue
@@ -18,8 +18,12 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"log/slog"
 	"os"
+	"strings"
 
+	"github.com/googleinterns/k8s-topology-simulator/modeling/algorithm"
 	"github.com/googleinterns/k8s-topology-simulator/process"
 	"k8s.io/klog/v2"
 )
@@ -29,13 +33,173 @@ func main() {
 	algPtr := flag.String("alg", "SharedGlobalAlgorithm", "routing algorithm")
 	// input file
 	inputPtr := flag.String("input", "example/input.csv", "inputs to use for this algorithm")
+	// input files processed into one merged output, default none
+	inputsPtr := flag.String("inputs", "", "comma-separated input files to process into one merged csv output: -inputs a.csv,b.csv")
 	// output file, default alg_result.csv
 	outputPtr := flag.String("output", "example/output.csv", "output of this algorithm")
+	// zone to take offline during simulation, default none
+	failureZonePtr := flag.String("simulate-failure-zone", "", "simulate traffic with this zone offline")
+	// zone traffic matrix output file, default none
+	matrixPtr := flag.String("output-matrix", "", "output of zone-to-zone traffic matrices")
+	// output format, default detected from the output file extension
+	formatPtr := flag.String("output-format", "", "format of the output, csv, json or k8s-yaml")
+	// suppress the aggregate summary row of the output, default false
+	noSummaryPtr := flag.Bool("no-summary", false, "don't write the aggregate summary row to the output")
+	// only output the N worst or best rows by score, default 0 (all rows)
+	topNPtr := flag.Int("top-n", 0, "only output the N worst-scoring rows, 0 means all")
+	topNBestPtr := flag.Int("top-n-best", 0, "only output the N best-scoring rows, 0 means all")
+	// only validate the input file without running any simulation
+	validatePtr := flag.Bool("validate", false, "validate the input file without simulation")
+	// Prometheus push gateway to push metrics to, default none
+	metricsPushURLPtr := flag.String("metrics-push-url", "", "Prometheus push gateway url to push metrics to")
+	metricsJobPtr := flag.String("metrics-job", "k8s-topology-simulator", "job name of metrics pushed")
+	// log format of process and algorithm packages, default text
+	logFormatPtr := flag.String("log-format", "text", "log format, text or json")
+	// skip input rows with duplicated input names, default false
+	dedupPtr := flag.Bool("deduplicate", false, "skip input rows with an input name that has been seen")
+	dedupKeepLastPtr := flag.Bool("deduplicate-keep-last", false, "deduplicate input rows keeping the last row of an input name")
+	// aggregate statistics of all rows, default none
+	statsPtr := flag.String("output-stats", "", "output of aggregate statistics of all rows")
+	// html report of all rows, default none
+	htmlReportPtr := flag.String("html-report", "", "output of an html report of all rows")
+	// deviation threshold of the algorithm, default 0 (algorithm default)
+	thresholdPtr := flag.Float64("threshold", 0, "deviation threshold of Local, LocalShared and LatencyAware algorithms, 0 means algorithm default")
+	// tune the deviation threshold on the input rows, default false
+	autoTunePtr := flag.Bool("auto-tune-threshold", false, "replace the deviation threshold with the one scoring best on the input rows")
+	// warn about rows whose max deviation exceeds the threshold, default 0 (off)
+	maxDeviationPtr := flag.Float64("max-deviation-threshold", 0, "log a warning for rows whose max deviation exceeds this ratio, 0 means off")
+	// warn about rows taking longer to simulate than the threshold, default 0 (off)
+	slowThresholdPtr := flag.Int64("slow-threshold-ms", 0, "log a warning for rows taking more milliseconds to simulate, 0 means off")
+	// weights of the total score, default process.DefaultScoreWeights
+	defaultWeights := process.DefaultScoreWeights()
+	scoreInZonePtr := flag.Float64("score-in-zone", defaultWeights.InZoneTraffic, "weight of the in-zone traffic score in the total score")
+	scoreDeviationPtr := flag.Float64("score-deviation", defaultWeights.Deviation, "weight of the deviation score in the total score")
+	scoreSlicesPtr := flag.Float64("score-slices", defaultWeights.SliceCount, "weight of the slice score in the total score")
+	// fail on the first invalid input row instead of skipping it
+	strictPtr := flag.Bool("strict", false, "fail on the first invalid input row instead of skipping invalid rows")
+	// leave out the algorithm column of the csv output, default false
+	noAlgorithmColumnPtr := flag.Bool("no-algorithm-column", false, "don't write the algorithm column after the input name of the csv output")
+	// EndpointSliceGroups of every row for debugging, default none
+	dumpSlicesPtr := flag.String("dump-slices", "", "output of the EndpointSliceGroups of every row, one JSON object per line")
+	// simulate dumped EndpointSliceGroups instead of running the algorithm
+	replaySlicesPtr := flag.String("replay-slices", "", "simulate the EndpointSliceGroups of a -dump-slices file instead of running the algorithm")
+	// number of max endpoints per EndpointSlice, default 100
+	var endpointsPerSlice int
+	flag.IntVar(&endpointsPerSlice, "eps", 100, "number of max endpoints per EndpointSlice")
+	flag.IntVar(&endpointsPerSlice, "endpoints-per-slice", 100, "alias of -eps")
+	// re-run the simulation whenever the input file is written
+	watchPtr := flag.Bool("watch", false, "re-run the simulation whenever the input file is written, until ctrl-C")
+	// serve simulations over HTTP on the address, default none
+	servePtr := flag.String("serve", "", "serve POST /simulate requests on the address, e.g. :8080")
+	// compare scores of two output files given as arguments
+	diffPtr := flag.Bool("diff", false, "compare scores of two output files: -diff fileA fileB")
+	// compare all algorithms instead of running a single one
+	compareAllPtr := flag.Bool("compare-all", false, "rank all algorithms on every input")
+	// run several algorithms and write one score column per algorithm
+	algorithmsPtr := flag.String("algorithms", "", "comma-separated algorithms to score side by side: -algorithms alg1,alg2")
+	// markdown comparison of the algorithms of -algorithms, default none
+	comparisonReportPtr := flag.String("comparison-report", "", "markdown table comparing the algorithms of -algorithms on every input")
 	flag.Parse()
 	klog.InitFlags(nil)
+	exitWithError(setLogFormat(*logFormatPtr))
 
-	err := process.StartProcessing(*inputPtr, *outputPtr, *algPtr)
+	if *diffPtr {
+		diff(flag.Args())
+		return
+	}
+	if *servePtr != "" {
+		exitWithError(process.ServeHTTP(*servePtr, *algPtr))
+		return
+	}
+	if *validatePtr {
+		validate(*inputPtr)
+		return
+	}
+
+	config := process.Config{
+		InputFile:             *inputPtr,
+		OutputFile:            *outputPtr,
+		Algorithm:             *algPtr,
+		FailedZone:            *failureZonePtr,
+		MatrixFile:            *matrixPtr,
+		OutputFormat:          *formatPtr,
+		NoSummary:             *noSummaryPtr,
+		TopN:                  *topNPtr,
+		TopNBest:              *topNBestPtr,
+		MetricsPushURL:        *metricsPushURLPtr,
+		MetricsJob:            *metricsJobPtr,
+		Deduplicate:           *dedupPtr,
+		DeduplicateKeepLast:   *dedupKeepLastPtr,
+		StatsFile:             *statsPtr,
+		HTMLReportFile:        *htmlReportPtr,
+		Threshold:             *thresholdPtr,
+		AutoTuneThreshold:     *autoTunePtr,
+		MaxDeviationThreshold: *maxDeviationPtr,
+		SlowThresholdMs:       *slowThresholdPtr,
+		ScoreWeights:          process.ScoreWeights{InZoneTraffic: *scoreInZonePtr, Deviation: *scoreDeviationPtr, SliceCount: *scoreSlicesPtr},
+		EndpointsPerSlice:     endpointsPerSlice,
+		Strict:                *strictPtr,
+		NoAlgorithmColumn:     *noAlgorithmColumnPtr,
+		DumpSlicesFile:        *dumpSlicesPtr,
+		ReplaySlicesFile:      *replaySlicesPtr,
+		ComparisonReportFile:  *comparisonReportPtr,
+	}
+	if *inputsPtr != "" {
+		exitWithError(process.BatchProcessWithConfig(config, strings.Split(*inputsPtr, ",")))
+		return
+	}
+	if *algorithmsPtr != "" {
+		exitWithError(process.MultiAlgorithmRunWithConfig(config, strings.Split(*algorithmsPtr, ",")))
+		return
+	}
+	if *compareAllPtr {
+		exitWithError(process.StartComparison(config))
+		return
+	}
+	if *watchPtr {
+		exitWithError(process.WatchModeWithConfig(config))
+		return
+	}
+	err := process.StartProcessingWithConfig(config)
+	exitWithError(err)
+}
+
+// setLogFormat sets loggers of process and algorithm packages to the format
+func setLogFormat(format string) error {
+	switch format {
+	case "text":
+		return nil
+	case "json":
+		logger := slog.New(slog.NewJSONHandler(os.Stderr, nil))
+		process.SetLogger(logger)
+		algorithm.SetLogger(logger)
+		return nil
+	}
+	return fmt.Errorf("unknown log format %q, should be text or json", format)
+}
+
+// diff writes the score differences of two output files to stdout
+func diff(files []string) {
+	if len(files) != 2 {
+		exitWithError(fmt.Errorf("diff expects 2 output files, got %d", len(files)))
+	}
+	diffs, err := process.DiffOutputs(files[0], files[1])
 	exitWithError(err)
+	exitWithError(process.WriteDiff(os.Stdout, diffs))
+}
+
+// validate prints a validation report of the input file, and exits with a
+// non-zero code if any row is invalid
+func validate(file string) {
+	rows, problems, err := process.ValidateInput(file)
+	exitWithError(err)
+	for _, problem := range problems {
+		fmt.Println(problem.Error())
+	}
+	fmt.Printf("%d rows validated, %d problems found\n", rows, len(problems))
+	if len(problems) > 0 {
+		os.Exit(1)
+	}
 }
 
 func exitWithError(err error) {
@@ -16,33 +16,135 @@ limitations under the License.
 
 package algorithm
 
-import "k8s.io/klog/v2"
+import (
+	"fmt"
+)
+
+// ListAlgorithms returns the canonical names of all algorithms NewAlgorithm can
+// create
+func ListAlgorithms() []string {
+	return []string{"SharedGlobal", "SharedMultiZone", "Local", "LocalSliceNoRebalance", "LocalWeighted", "LocalOpt", "LocalSliceOpt", "LocalShared", "LatencyAware", "Original", "WeightedOriginal", "TwoPhase", "Proportional"}
+}
+
+// IsKnownAlgorithm returns true if name is the canonical name of an algorithm
+// listed by ListAlgorithms or its alias with the "Algorithm" suffix
+func IsKnownAlgorithm(name string) bool {
+	for _, known := range ListAlgorithms() {
+		if name == known || name == known+"Algorithm" {
+			return true
+		}
+	}
+	return false
+}
+
+// default parameters of the algorithms created by NewAlgorithm
+const (
+	// defaultThreshold is the deviation threshold of Local, LocalShared and
+	// LatencyAware
+	defaultThreshold = 0.5
+	// defaultStartingThreshold is the startingThreshold of Local and
+	// LatencyAware
+	defaultStartingThreshold = 3
+	// defaultSharedGlobalWeight is the globalWeight of SharedGlobal
+	defaultSharedGlobalWeight = 0.4
+	// defaultSharedMultiZoneWeight is the globalWeight of SharedMultiZone
+	defaultSharedMultiZoneWeight = 1
+	// defaultGlobalThreshold is the globalThreshold of SharedGlobal and
+	// SharedMultiZone
+	defaultGlobalThreshold = 100
+)
 
 // NewAlgorithm serves as an algorithm constructor based on the algroithm name
 func NewAlgorithm(name string) RoutingAlgorithm {
 	switch name {
 	case "SharedGlobal", "SharedGlobalAlgorithm":
-		klog.Info("SharedGlobalAlgorithm created")
-		return SharedGlobalAlgorithm{sharedCoreAlgorithm: sharedGlobalAlgorithmCore{globalWeight: 0.4, globalThreshold: 100}}
+		logger.Info("algorithm created", "algorithm", "SharedGlobalAlgorithm")
+		return SharedGlobalAlgorithm{sharedCoreAlgorithm: sharedGlobalAlgorithmCore{globalWeight: defaultSharedGlobalWeight, globalThreshold: defaultGlobalThreshold}}
 	case "SharedMultiZone", "SharedMultiZoneAlgorithm":
-		klog.Info("SharedMultiZoneAlgorithm created")
-		return SharedMultiZoneAlgorithm{sharedCoreAlgorithm: sharedGlobalAlgorithmCore{globalWeight: 1, globalThreshold: 100}}
+		logger.Info("algorithm created", "algorithm", "SharedMultiZoneAlgorithm")
+		return SharedMultiZoneAlgorithm{sharedCoreAlgorithm: sharedGlobalAlgorithmCore{globalWeight: defaultSharedMultiZoneWeight, globalThreshold: defaultGlobalThreshold}}
 	case "Local", "LocalAlgorithm":
-		klog.Info("LocalSliceAlgorithm created")
-		return LocalSliceAlgorithm{threshold: 0.5, startingThreshold: 3}
+		logger.Info("algorithm created", "algorithm", "LocalSliceAlgorithm")
+		return &LocalSliceAlgorithm{threshold: defaultThreshold, startingThreshold: defaultStartingThreshold}
+	case "LocalSliceNoRebalance", "LocalSliceNoRebalanceAlgorithm":
+		logger.Info("algorithm created", "algorithm", "LocalSliceAlgorithm", "rebalance_pass", false)
+		return &LocalSliceAlgorithm{threshold: defaultThreshold, startingThreshold: defaultStartingThreshold, DisableRebalancePass: true}
 	case "LocalWeighted", "LocalWeightedAlgorithm":
-		klog.Info("LocalWeightedSliceAlgorithm created")
+		logger.Info("algorithm created", "algorithm", "LocalWeightedSliceAlgorithm")
 		return LocalWeightedSliceAlgorithm{}
 	case "LocalOpt", "LocalOptAlgorithm":
-		klog.Info("LocalSliceAlgorithmOpt created")
+		logger.Info("algorithm created", "algorithm", "LocalSliceAlgorithmOpt")
 		return LocalSliceAlgorithmOpt{}
+	case "LocalSliceOpt", "LocalSliceOptAlgorithm":
+		logger.Info("algorithm created", "algorithm", "LocalSliceAlgorithmOpt")
+		return LocalSliceAlgorithmOpt{GlobalSGWeightByNodes: true}
 	case "LocalShared", "LocalSharedAlgorithm":
-		klog.Info("LocalSharedSliceAlgorithm created")
-		return LocalSharedSliceAlgorithm{threshold: 0.5}
+		logger.Info("algorithm created", "algorithm", "LocalSharedSliceAlgorithm")
+		return &LocalSharedSliceAlgorithm{threshold: defaultThreshold}
+	case "LatencyAware", "LatencyAwareAlgorithm":
+		logger.Info("algorithm created", "algorithm", "LatencyAwareAlgorithm")
+		return LatencyAwareAlgorithm{localAlgorithm: &LocalSliceAlgorithm{threshold: defaultThreshold, startingThreshold: defaultStartingThreshold}}
 	case "Original", "OriginalAlgorithm":
-		klog.Info("OriginalAlgorithm created")
+		logger.Info("algorithm created", "algorithm", "OriginalAlgorithm")
 		return OriginalAlgorithm{}
+	case "WeightedOriginal", "WeightedOriginalAlgorithm":
+		logger.Info("algorithm created", "algorithm", "WeightedOriginalAlgorithm")
+		return WeightedOriginalAlgorithm{UseNodeWeights: true}
+	case "TwoPhase", "TwoPhaseAlgorithm":
+		logger.Info("algorithm created", "algorithm", "TwoPhaseAlgorithm")
+		return TwoPhaseAlgorithm{}
+	case "Proportional", "ProportionalAlgorithm":
+		logger.Info("algorithm created", "algorithm", "ProportionalAlgorithm")
+		return ProportionalAlgorithm{}
+	}
+	logger.Warn("unknown algorithm, return LocalSliceAlgorithm as default", "algorithm", name)
+	return &LocalSliceAlgorithm{}
+}
+
+// NewAlgorithmWithThreshold creates the algorithm of name with the deviation
+// threshold replaced, it returns an error if the algorithm has no threshold
+func NewAlgorithmWithThreshold(name string, threshold float64) (RoutingAlgorithm, error) {
+	alg, err := thresholdAlgorithm(name, threshold)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("algorithm created", "algorithm", name, "threshold", threshold)
+	return alg, nil
+}
+
+// NewAlgorithmWithParams creates the algorithm of name with the parameters of
+// params, parameters not in params keep their default values. The parameters
+// of every algorithm and their valid ranges are listed in AlgorithmParamSpec,
+// algorithms without parameters like LocalWeighted and LocalSliceOpt return
+// an error for any parameter.
+func NewAlgorithmWithParams(name string, params map[string]string) (RoutingAlgorithm, error) {
+	if !IsKnownAlgorithm(name) {
+		return nil, fmt.Errorf("unknown algorithm %s", name)
+	}
+	values, err := parseParams(name, params)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 {
+		return NewAlgorithm(name), nil
+	}
+	logger.Info("algorithm created", "algorithm", name, "params", values)
+	return paramAlgorithm(name, values), nil
+}
+
+// thresholdAlgorithm creates the algorithm of name with the deviation threshold
+// without logging
+func thresholdAlgorithm(name string, threshold float64) (RoutingAlgorithm, error) {
+	if threshold <= 0 {
+		return nil, fmt.Errorf("threshold %v should be positive", threshold)
+	}
+	switch name {
+	case "Local", "LocalAlgorithm":
+		return &LocalSliceAlgorithm{threshold: threshold, startingThreshold: defaultStartingThreshold}, nil
+	case "LocalShared", "LocalSharedAlgorithm":
+		return &LocalSharedSliceAlgorithm{threshold: threshold}, nil
+	case "LatencyAware", "LatencyAwareAlgorithm":
+		return LatencyAwareAlgorithm{localAlgorithm: &LocalSliceAlgorithm{threshold: threshold, startingThreshold: defaultStartingThreshold}}, nil
 	}
-	klog.Warningf("[WARNINIG] unknown algorithm %v, return LocalSliceAlgorithm as default\n", name)
-	return LocalSliceAlgorithm{}
+	return nil, fmt.Errorf("algorithm %s has no deviation threshold", name)
 }
@@ -53,6 +53,9 @@ func (algTest routingAlgorithmTest) doTest(t *testing.T) {
 				t.Errorf("[test %s] got slices: %+v, expected slices: %+v", algTest.algName, sliceGroups, testcase.expectedOutput)
 				return
 			}
+			if err == nil {
+				checkSliceGroupInvariants(t, region, sliceGroups)
+			}
 		})
 	}
 }
@@ -126,3 +129,281 @@ func compareSliceGroups(t *testing.T, sliceGroupsA map[string]types.EndpointSlic
 	}
 	return true
 }
+
+func TestZeroEndpointZones(t *testing.T) {
+	region, err := types.CreateRegionInfo([]types.Zone{
+		{Name: "ZoneA", Nodes: 4, Endpoints: 80},
+		{Name: "ZoneB", Nodes: 3, Endpoints: 0},
+		{Name: "ZoneC", Nodes: 2, Endpoints: 50},
+		{Name: "ZoneD", Nodes: 2, Endpoints: 0},
+		{Name: "ZoneE", Nodes: 1, Endpoints: 30},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	if !reflect.DeepEqual(region.ZeroEndpointZones, []string{"ZoneB", "ZoneD"}) {
+		t.Fatalf("expected zero endpoint zones ZoneB and ZoneD, got %v", region.ZeroEndpointZones)
+	}
+	algs := map[string]RoutingAlgorithm{
+		"LocalSlice":       &LocalSliceAlgorithm{threshold: 0.5, startingThreshold: 3},
+		"LocalSharedSlice": &LocalSharedSliceAlgorithm{threshold: 0.5},
+		"SharedGlobal":     SharedGlobalAlgorithm{sharedCoreAlgorithm: sharedGlobalAlgorithmCore{globalWeight: 0.4, globalThreshold: 100}},
+	}
+	for name, alg := range algs {
+		t.Run(name, func(t *testing.T) {
+			sliceGroups, err := alg.CreateSliceGroups(region)
+			if err != nil {
+				t.Fatalf("unexpected error creating sliceGroups: %v", err)
+			}
+			if _, ok := sliceGroups["global"]; ok && len(sliceGroups) == 1 {
+				t.Errorf("expected %s not to fall back to OriginalAlgorithm", name)
+			}
+			checkSliceGroupInvariants(t, region, sliceGroups)
+			// zones without endpoints never contribute endpoints
+			for label, sliceGroup := range sliceGroups {
+				for _, zone := range region.ZeroEndpointZones {
+					if sliceGroup.Composition[zone].Number != 0 {
+						t.Errorf("expected no endpoints of %s in %s, got %+v", zone, label, sliceGroup.Composition)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSingleZoneRegions(t *testing.T) {
+	algs := map[string]RoutingAlgorithm{
+		"LocalSharedSlice":   &LocalSharedSliceAlgorithm{threshold: 0.5},
+		"LocalSlice":         &LocalSliceAlgorithm{threshold: 0.5, startingThreshold: 3},
+		"LocalWeightedSlice": LocalWeightedSliceAlgorithm{},
+		"Original":           OriginalAlgorithm{},
+	}
+	testCases := []struct {
+		name  string
+		zones []types.Zone
+		// singleGroup is true if the only EndpointSliceGroup should have all
+		// endpoints of the first zone
+		singleGroup bool
+	}{
+		{
+			name:        "one zone",
+			zones:       []types.Zone{{Name: "ZoneA", Nodes: 10, Endpoints: 100}},
+			singleGroup: true,
+		},
+		{
+			// algorithms don't reject a region without endpoints, the
+			// simulator reports its result as invalid instead
+			name:  "one zone without endpoints",
+			zones: []types.Zone{{Name: "ZoneA", Nodes: 10, Endpoints: 0}},
+		},
+		{
+			name:  "two zones with all endpoints in one zone",
+			zones: []types.Zone{{Name: "ZoneA", Nodes: 10, Endpoints: 100}, {Name: "ZoneB", Nodes: 10, Endpoints: 0}},
+		},
+	}
+	for _, tc := range testCases {
+		region, err := types.CreateRegionInfo(tc.zones)
+		if err != nil {
+			t.Fatalf("unexpected error creating region of %s: %v", tc.name, err)
+		}
+		for name, alg := range algs {
+			t.Run(tc.name+"/"+name, func(t *testing.T) {
+				sliceGroups, err := alg.CreateSliceGroups(region)
+				if err != nil {
+					t.Fatalf("unexpected error creating sliceGroups: %v", err)
+				}
+				checkSliceGroupInvariants(t, region, sliceGroups)
+				if !tc.singleGroup {
+					return
+				}
+				if len(sliceGroups) != 1 {
+					t.Fatalf("expected a single sliceGroup, got %+v", sliceGroups)
+				}
+				zone := tc.zones[0]
+				for label, sliceGroup := range sliceGroups {
+					if sliceGroup.Composition[zone.Name].Number != zone.Endpoints || sliceGroup.ZoneTrafficWeights[zone.Name] <= 0 {
+						t.Errorf("expected %s to have all %d endpoints of %s and receive its traffic, got %+v", label, zone.Endpoints, zone.Name, sliceGroup)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestZeroEndpointRegions(t *testing.T) {
+	region, err := types.CreateRegionInfo([]types.Zone{
+		{Name: "ZoneA", Nodes: 4, Endpoints: 0},
+		{Name: "ZoneB", Nodes: 3, Endpoints: 0},
+		{Name: "ZoneC", Nodes: 2, Endpoints: 0},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	// thresholds of 0 and below make sure algorithms don't get past the guard
+	// by falling back to OriginalAlgorithm
+	algs := map[string]RoutingAlgorithm{
+		"LocalSliceWithoutFallback":   &LocalSliceAlgorithm{threshold: 0.5, startingThreshold: 0},
+		"SharedGlobalWithoutFallback": SharedGlobalAlgorithm{sharedCoreAlgorithm: sharedGlobalAlgorithmCore{globalWeight: 0.4, globalThreshold: -1}},
+	}
+	for _, name := range ListAlgorithms() {
+		algs[name] = NewAlgorithm(name)
+	}
+	for name, alg := range algs {
+		t.Run(name, func(t *testing.T) {
+			sliceGroups, err := alg.CreateSliceGroups(region)
+			if err != nil {
+				t.Fatalf("unexpected error creating sliceGroups: %v", err)
+			}
+			if sliceGroups == nil || len(sliceGroups) != 0 {
+				t.Errorf("expected an empty map of sliceGroups, got %+v", sliceGroups)
+			}
+		})
+	}
+	core := sharedGlobalAlgorithmCore{globalWeight: 0.4, globalThreshold: -1}
+	for _, excludeContributor := range []bool{false, true} {
+		sliceGroups, err := core.CreateSliceGroups(region, excludeContributor)
+		if err != nil {
+			t.Fatalf("unexpected error creating sliceGroups with excludeContributor %v: %v", excludeContributor, err)
+		}
+		if sliceGroups == nil || len(sliceGroups) != 0 {
+			t.Errorf("expected an empty map of sliceGroups with excludeContributor %v, got %+v", excludeContributor, sliceGroups)
+		}
+	}
+}
+
+func TestNewAlgorithm(t *testing.T) {
+	testCases := []struct {
+		names    []string
+		expected string
+		unknown  bool
+	}{
+		{names: []string{"SharedGlobal", "SharedGlobalAlgorithm"}, expected: "SharedGlobalAlgorithm"},
+		{names: []string{"SharedMultiZone", "SharedMultiZoneAlgorithm"}, expected: "SharedMultiZoneAlgorithm"},
+		{names: []string{"Local", "LocalAlgorithm", "LocalSliceNoRebalance", "LocalSliceNoRebalanceAlgorithm"}, expected: "LocalSliceAlgorithm"},
+		{names: []string{"LocalWeighted", "LocalWeightedAlgorithm"}, expected: "LocalWeightedSliceAlgorithm"},
+		{names: []string{"LocalOpt", "LocalOptAlgorithm", "LocalSliceOpt", "LocalSliceOptAlgorithm"}, expected: "LocalSliceAlgorithmOpt"},
+		{names: []string{"LocalShared", "LocalSharedAlgorithm"}, expected: "LocalSharedSliceAlgorithm"},
+		{names: []string{"LatencyAware", "LatencyAwareAlgorithm"}, expected: "LatencyAwareAlgorithm"},
+		{names: []string{"Original", "OriginalAlgorithm"}, expected: "OriginalAlgorithm"},
+		{names: []string{"WeightedOriginal", "WeightedOriginalAlgorithm"}, expected: "WeightedOriginalAlgorithm"},
+		{names: []string{"TwoPhase", "TwoPhaseAlgorithm"}, expected: "TwoPhaseAlgorithm"},
+		{names: []string{"Proportional", "ProportionalAlgorithm"}, expected: "ProportionalAlgorithm"},
+		// unknown names fall back to LocalSliceAlgorithm
+		{names: []string{"", "Unknown", "local"}, expected: "LocalSliceAlgorithm", unknown: true},
+	}
+	for _, tc := range testCases {
+		for _, name := range tc.names {
+			var actual string
+			switch NewAlgorithm(name).(type) {
+			case SharedGlobalAlgorithm:
+				actual = "SharedGlobalAlgorithm"
+			case SharedMultiZoneAlgorithm:
+				actual = "SharedMultiZoneAlgorithm"
+			case *LocalSliceAlgorithm:
+				actual = "LocalSliceAlgorithm"
+			case LocalWeightedSliceAlgorithm:
+				actual = "LocalWeightedSliceAlgorithm"
+			case LocalSliceAlgorithmOpt:
+				actual = "LocalSliceAlgorithmOpt"
+			case *LocalSharedSliceAlgorithm:
+				actual = "LocalSharedSliceAlgorithm"
+			case LatencyAwareAlgorithm:
+				actual = "LatencyAwareAlgorithm"
+			case OriginalAlgorithm:
+				actual = "OriginalAlgorithm"
+			case WeightedOriginalAlgorithm:
+				actual = "WeightedOriginalAlgorithm"
+			case TwoPhaseAlgorithm:
+				actual = "TwoPhaseAlgorithm"
+			case ProportionalAlgorithm:
+				actual = "ProportionalAlgorithm"
+			}
+			if actual != tc.expected {
+				t.Errorf("expected %s created from name %q, got %q", tc.expected, name, actual)
+			}
+			if IsKnownAlgorithm(name) == tc.unknown {
+				t.Errorf("expected algorithm name %q to be known: %v", name, !tc.unknown)
+			}
+		}
+	}
+
+	names := ListAlgorithms()
+	if len(names) < 8 {
+		t.Errorf("expected at least 8 algorithms, got %v", names)
+	}
+	region, err := types.CreateRegionInfo([]types.Zone{
+		{Name: "ZoneA", Nodes: 3, Endpoints: 150},
+		{Name: "ZoneB", Nodes: 2, Endpoints: 20},
+		{Name: "ZoneC", Nodes: 1, Endpoints: 30},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	for _, name := range names {
+		sliceGroups, err := NewAlgorithm(name).CreateSliceGroups(region)
+		if err != nil {
+			t.Errorf("unexpected error creating sliceGroups with %s: %v", name, err)
+			continue
+		}
+		checkSliceGroupInvariants(t, region, sliceGroups)
+	}
+}
+
+func TestNewAlgorithmWithParams(t *testing.T) {
+	testCases := []struct {
+		name        string
+		algName     string
+		params      map[string]string
+		expected    RoutingAlgorithm
+		expectedErr bool
+	}{
+		{name: "default LocalShared", algName: "LocalShared", expected: &LocalSharedSliceAlgorithm{threshold: 0.5}},
+		{name: "LocalShared with threshold", algName: "LocalShared", params: map[string]string{"threshold": "0.3"}, expected: &LocalSharedSliceAlgorithm{threshold: 0.3}},
+		{name: "Local with threshold", algName: "LocalAlgorithm", params: map[string]string{"threshold": "0.2"}, expected: &LocalSliceAlgorithm{threshold: 0.2, startingThreshold: 3}},
+		{name: "LocalWeighted without params", algName: "LocalWeighted", params: map[string]string{}, expected: LocalWeightedSliceAlgorithm{}},
+		{name: "LocalSliceOpt without params", algName: "LocalSliceOpt", expected: LocalSliceAlgorithmOpt{GlobalSGWeightByNodes: true}},
+		{name: "LocalWeighted with threshold", algName: "LocalWeighted", params: map[string]string{"threshold": "0.3"}, expectedErr: true},
+		{name: "invalid threshold", algName: "LocalShared", params: map[string]string{"threshold": "low"}, expectedErr: true},
+		{name: "non-positive threshold", algName: "LocalShared", params: map[string]string{"threshold": "0"}, expectedErr: true},
+		{name: "unknown parameter", algName: "LocalShared", params: map[string]string{"weight": "1"}, expectedErr: true},
+		{name: "unknown algorithm", algName: "Unknown", expectedErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			alg, err := NewAlgorithmWithParams(tc.algName, tc.params)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %v, got %v", tc.expectedErr, err)
+			}
+			if !tc.expectedErr && !reflect.DeepEqual(alg, tc.expected) {
+				t.Errorf("expected algorithm %+v, got %+v", tc.expected, alg)
+			}
+		})
+	}
+
+	// ZoneA has far fewer endpoints than its share of nodes, the two
+	// thresholds share endpoints of ZoneB with it differently
+	region, err := types.CreateRegionInfo([]types.Zone{
+		{Name: "ZoneA", Nodes: 1, Endpoints: 1},
+		{Name: "ZoneB", Nodes: 2, Endpoints: 10},
+		{Name: "ZoneC", Nodes: 5, Endpoints: 10},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	var results []map[string]types.EndpointSliceGroup
+	for _, threshold := range []string{"0.3", "0.5"} {
+		alg, err := NewAlgorithmWithParams("LocalShared", map[string]string{"threshold": threshold})
+		if err != nil {
+			t.Fatalf("unexpected error creating algorithm with threshold %s: %v", threshold, err)
+		}
+		sliceGroups, err := alg.CreateSliceGroups(region)
+		if err != nil {
+			t.Fatalf("unexpected error creating sliceGroups with threshold %s: %v", threshold, err)
+		}
+		checkSliceGroupInvariants(t, region, sliceGroups)
+		results = append(results, sliceGroups)
+	}
+	if reflect.DeepEqual(results[0], results[1]) {
+		t.Errorf("expected different sliceGroups with thresholds 0.3 and 0.5, got %+v", results[0])
+	}
+}
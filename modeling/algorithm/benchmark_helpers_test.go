@@ -0,0 +1,76 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// endpointsPerZoneSteps are the progressively larger inputs of benchmarks
+var endpointsPerZoneSteps = []int{10, 50, 100, 500}
+
+// GenerateRegion generates a region of zones with endpointsPerZone endpoints
+// each. Nodes are skewed so that zones have to share endpoints: zone i has
+// nodesPerZone * (i%3 + 1) nodes.
+func GenerateRegion(zones int, nodesPerZone int, endpointsPerZone int) types.RegionInfo {
+	var zoneList []types.Zone
+	for i := 0; i < zones; i++ {
+		zoneList = append(zoneList, types.Zone{
+			Name:      fmt.Sprintf("zone-%03d", i),
+			Nodes:     nodesPerZone * (i%3 + 1),
+			Endpoints: endpointsPerZone,
+		})
+	}
+	region, err := types.CreateRegionInfo(zoneList)
+	if err != nil {
+		panic(err)
+	}
+	return region
+}
+
+// benchmarkAlgorithm benchmarks CreateSliceGroups of alg on region
+func benchmarkAlgorithm(b *testing.B, alg RoutingAlgorithm, region types.RegionInfo) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := alg.CreateSliceGroups(region); err != nil {
+			b.Fatalf("unexpected error creating slice groups: %v", err)
+		}
+	}
+}
+
+// benchmarkAlgorithmSteps runs benchmarkAlgorithm for every step of
+// endpointsPerZoneSteps
+func benchmarkAlgorithmSteps(b *testing.B, alg RoutingAlgorithm, zones int) {
+	for _, endpoints := range endpointsPerZoneSteps {
+		region := GenerateRegion(zones, 10, endpoints)
+		b.Run(fmt.Sprintf("%dEndpointsPerZone", endpoints), func(b *testing.B) {
+			benchmarkAlgorithm(b, alg, region)
+		})
+	}
+}
+
+func BenchmarkLocalSlice_10Zones(b *testing.B) {
+	benchmarkAlgorithmSteps(b, NewAlgorithm("Local"), 10)
+}
+
+func BenchmarkLocalShared_10Zones(b *testing.B) {
+	benchmarkAlgorithmSteps(b, NewAlgorithm("LocalShared"), 10)
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// AlgorithmResult is the evaluation of one algorithm in a scenario
+type AlgorithmResult struct {
+	// Name of the algorithm
+	Name string
+	// Result of simulating the EndpointSliceGroups created by the algorithm
+	Result types.SimulationResult
+	// Score of Result, higher is better
+	Score float64
+}
+
+// ScenarioResult is the evaluation of several algorithms on one scenario, e.g.
+// one row of an input file
+type ScenarioResult struct {
+	ScenarioName     string
+	AlgorithmResults []AlgorithmResult
+}
+
+// GenerateComparisonMarkdown formats scenarios as a markdown table with one row
+// per algorithm of a scenario. Rows are sorted by scenario name, then by score
+// from the highest, invalid results come last in a scenario.
+func GenerateComparisonMarkdown(scenarios []ScenarioResult) string {
+	type comparisonRow struct {
+		scenario string
+		AlgorithmResult
+	}
+	var rows []comparisonRow
+	for _, scenario := range scenarios {
+		for _, result := range scenario.AlgorithmResults {
+			rows = append(rows, comparisonRow{scenario: scenario.ScenarioName, AlgorithmResult: result})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].scenario != rows[j].scenario {
+			return rows[i].scenario < rows[j].scenario
+		}
+		if rows[i].Result.Invalid != rows[j].Result.Invalid {
+			return !rows[i].Result.Invalid
+		}
+		return rows[i].Score > rows[j].Score
+	})
+
+	var builder strings.Builder
+	builder.WriteString("| Scenario | Algorithm | Score | InZoneTraffic% | MeanDeviation% | MaxDeviation% |\n")
+	builder.WriteString("|---|---|---|---|---|---|\n")
+	for _, row := range rows {
+		if row.Result.Invalid {
+			fmt.Fprintf(&builder, "| %s | %s | invalid | invalid | invalid | invalid |\n", markdownCell(row.scenario), markdownCell(row.Name))
+			continue
+		}
+		fmt.Fprintf(&builder, "| %s | %s | %.4f | %.2f | %.2f | %.2f |\n", markdownCell(row.scenario), markdownCell(row.Name), row.Score,
+			row.Result.InZoneTraffic*100, row.Result.MeanDeviation*100, row.Result.MaxDeviation*100)
+	}
+	return builder.String()
+}
+
+// markdownCell escapes pipes of value so it stays in one cell of a table
+func markdownCell(value string) string {
+	return strings.ReplaceAll(value, "|", `\|`)
+}
@@ -0,0 +1,69 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+func TestGenerateComparisonMarkdown(t *testing.T) {
+	scenarios := []ScenarioResult{
+		{
+			ScenarioName: "unbalanced",
+			AlgorithmResults: []AlgorithmResult{
+				{Name: "Original", Result: types.SimulationResult{InZoneTraffic: 0.33, MeanDeviation: 0.5, MaxDeviation: 1.2}, Score: 0.3},
+				{Name: "Broken", Result: types.SimulationResult{Invalid: true}, Score: 0.9},
+				{Name: "Local", Result: types.SimulationResult{InZoneTraffic: 0.8, MeanDeviation: 0.1, MaxDeviation: 0.2}, Score: 0.7},
+			},
+		},
+		{
+			ScenarioName: "a|balanced",
+			AlgorithmResults: []AlgorithmResult{
+				{Name: "Original", Result: types.SimulationResult{InZoneTraffic: 0.33}, Score: 0.6},
+				{Name: "Local", Result: types.SimulationResult{InZoneTraffic: 1}, Score: 0.95},
+			},
+		},
+	}
+	markdown := GenerateComparisonMarkdown(scenarios)
+	if !strings.HasPrefix(markdown, "| Scenario |") {
+		t.Errorf("expected markdown to start with the header, got %q", markdown)
+	}
+	lines := strings.Split(strings.TrimSuffix(markdown, "\n"), "\n")
+	// header and separator lines, one line per algorithm result
+	if len(lines) != 7 {
+		t.Fatalf("expected 7 lines, got %d: %q", len(lines), markdown)
+	}
+	expected := []string{
+		`| a\|balanced | Local | 0.9500 | 100.00 | 0.00 | 0.00 |`,
+		`| a\|balanced | Original | 0.6000 | 33.00 | 0.00 | 0.00 |`,
+		`| unbalanced | Local | 0.7000 | 80.00 | 10.00 | 20.00 |`,
+		`| unbalanced | Original | 0.3000 | 33.00 | 50.00 | 120.00 |`,
+		`| unbalanced | Broken | invalid | invalid | invalid | invalid |`,
+	}
+	for i, line := range expected {
+		if lines[i+2] != line {
+			t.Errorf("expected line %d to be %q, got %q", i+2, line, lines[i+2])
+		}
+	}
+
+	if lines := strings.Split(strings.TrimSuffix(GenerateComparisonMarkdown(nil), "\n"), "\n"); len(lines) != 2 {
+		t.Errorf("expected only the header and separator without scenarios, got %q", lines)
+	}
+}
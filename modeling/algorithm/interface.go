@@ -20,6 +20,7 @@ import "github.com/googleinterns/k8s-topology-simulator/modeling/types"
 
 // RoutingAlgorithm interface for different routing algorithms
 type RoutingAlgorithm interface {
-	// CreateSliceGroups translates RegionInfo into EndpointSliceGroups
+	// CreateSliceGroups translates RegionInfo into EndpointSliceGroups. A region
+	// without endpoints results in no EndpointSliceGroups and no error.
 	CreateSliceGroups(types.RegionInfo) (map[string]types.EndpointSliceGroup, error)
 }
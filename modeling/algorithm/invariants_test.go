@@ -0,0 +1,109 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// CheckInvariants verifies properties every RoutingAlgorithm should preserve on
+// a region: all endpoints of the region are assigned to slice groups exactly
+// once, every zone with nodes can reach at least one endpoint, and traffic
+// weights are non-negative. Algorithms should not fail on valid regions,
+// expected errors are covered by the tests of every algorithm.
+func CheckInvariants(t *testing.T, alg RoutingAlgorithm, region types.RegionInfo) {
+	t.Helper()
+	sliceGroups, err := alg.CreateSliceGroups(region)
+	if err != nil {
+		t.Errorf("unexpected error creating slice groups of region %+v: %v", region.ZoneDetails, err)
+		return
+	}
+	checkSliceGroupInvariants(t, region, sliceGroups)
+}
+
+// checkSliceGroupInvariants verifies the invariants of sliceGroups created
+// from region
+func checkSliceGroupInvariants(t *testing.T, region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup) {
+	t.Helper()
+	totalEndpoints := 0
+	for label, sliceGroup := range sliceGroups {
+		totalEndpoints += sliceGroup.NumberOfEndpoints()
+		for zone, weight := range sliceGroup.ZoneTrafficWeights {
+			if weight < 0 {
+				t.Errorf("expected non-negative traffic weights, got %v from %s in %s", weight, zone, label)
+			}
+		}
+	}
+	if totalEndpoints != region.TotalEndpoints {
+		t.Errorf("expected %d endpoints in slice groups, got %d: %+v", region.TotalEndpoints, totalEndpoints, sliceGroups)
+	}
+	if region.TotalEndpoints == 0 {
+		return
+	}
+	for zoneName, zone := range region.ZoneDetails {
+		if zone.Nodes == 0 {
+			continue
+		}
+		reachable := false
+		for _, sliceGroup := range sliceGroups {
+			if sliceGroup.ZoneTrafficWeights[zoneName] > 0 && sliceGroup.NumberOfEndpoints() > 0 {
+				reachable = true
+				break
+			}
+		}
+		if !reachable {
+			t.Errorf("expected zone %s to reach at least one slice group: %+v", zoneName, sliceGroups)
+		}
+	}
+}
+
+// randomRegion generates a region of 2 to 6 zones with random nodes and
+// endpoints
+func randomRegion(random *rand.Rand) types.RegionInfo {
+	var zones []types.Zone
+	n := 2 + random.Intn(5)
+	for i := 0; i < n; i++ {
+		zones = append(zones, types.Zone{
+			Name:      fmt.Sprintf("zone%d", i),
+			Nodes:     random.Intn(20),
+			Endpoints: random.Intn(50),
+		})
+	}
+	region, err := types.CreateRegionInfo(zones)
+	if err != nil {
+		panic(err)
+	}
+	return region
+}
+
+func TestInvariants(t *testing.T) {
+	for _, name := range ListAlgorithms() {
+		alg := NewAlgorithm(name)
+		// every algorithm is checked against the same regions
+		random := rand.New(rand.NewSource(1))
+		for i := 0; i < 50; i++ {
+			region := randomRegion(random)
+			t.Run(fmt.Sprintf("%s/%d", name, i), func(t *testing.T) {
+				CheckInvariants(t, alg, region)
+			})
+		}
+	}
+}
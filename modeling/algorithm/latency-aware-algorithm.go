@@ -0,0 +1,66 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"fmt"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// LatencyAwareAlgorithm is a variation of LocalSliceAlgorithm that expects
+// endpoints of zones based on their cross-zone latency instead of number of
+// nodes. Zones with a lower latency are expected to have proportionally more
+// endpoints: expectedEndpoints = totalEndpoints * (1/latency) /
+// sum(1/latency of every zone).
+type LatencyAwareAlgorithm struct {
+	localAlgorithm *LocalSliceAlgorithm
+}
+
+// CreateSliceGroups creates sliceGroups with 'one local EndpointSliceGroup per
+// zone' policy weighted by inverse cross-zone latency. If any zone has no
+// latency, it works the same as LocalSliceAlgorithm.
+func (alg LatencyAwareAlgorithm) CreateSliceGroups(region types.RegionInfo) (map[string]types.EndpointSliceGroup, error) {
+	if region.ZoneDetails == nil {
+		return nil, fmt.Errorf("zoneDetail should not be nil")
+	}
+	if region.TotalEndpoints == 0 {
+		return map[string]types.EndpointSliceGroup{}, nil
+	}
+	inverseLatencySum := 0.0
+	for _, zoneName := range region.ZoneNames() {
+		zone := region.ZoneDetails[zoneName]
+		if zone.AvgCrossZoneLatencyMs == 0 {
+			logger.Info("zone has no cross-zone latency, switching to local algorithm", "algorithm", "LatencyAwareAlgorithm", "zone", zoneName)
+			return alg.localAlgorithm.CreateSliceGroups(region)
+		}
+		inverseLatencySum += 1 / zone.AvgCrossZoneLatencyMs
+	}
+	// LocalSliceAlgorithm expects endpoints of a zone by its NodesRatio,
+	// replace it with the proportion of the inverse latency
+	weightedRegion := types.RegionInfo{
+		TotalNodes:        region.TotalNodes,
+		TotalEndpoints:    region.TotalEndpoints,
+		ZoneDetails:       map[string]types.Zone{},
+		ZeroEndpointZones: region.ZeroEndpointZones,
+	}
+	for zoneName, zone := range region.ZoneDetails {
+		zone.NodesRatio = (1 / zone.AvgCrossZoneLatencyMs) / inverseLatencySum
+		weightedRegion.ZoneDetails[zoneName] = zone
+	}
+	return alg.localAlgorithm.CreateSliceGroups(weightedRegion)
+}
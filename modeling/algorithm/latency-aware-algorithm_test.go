@@ -0,0 +1,87 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+func TestLatencyAwareAlgorithm(t *testing.T) {
+	alg := LatencyAwareAlgorithm{localAlgorithm: &LocalSliceAlgorithm{threshold: 0.1, startingThreshold: 3}}
+	testCases := []struct {
+		name     string
+		input    []types.Zone
+		expected map[string]int
+	}{
+		{
+			name: "lower latency receives more endpoints",
+			input: []types.Zone{
+				types.Zone{Nodes: 10, Endpoints: 20, Name: "ZoneA", AvgCrossZoneLatencyMs: 1},
+				types.Zone{Nodes: 10, Endpoints: 20, Name: "ZoneB", AvgCrossZoneLatencyMs: 3},
+			},
+			// 40 * 1 / (1 + 1/3) = 30, 40 * (1/3) / (1 + 1/3) = 10
+			expected: map[string]int{"ZoneA": 30, "ZoneB": 10},
+		},
+		{
+			name: "equal latency",
+			input: []types.Zone{
+				types.Zone{Nodes: 10, Endpoints: 30, Name: "ZoneA", AvgCrossZoneLatencyMs: 2},
+				types.Zone{Nodes: 30, Endpoints: 10, Name: "ZoneB", AvgCrossZoneLatencyMs: 2},
+			},
+			expected: map[string]int{"ZoneA": 20, "ZoneB": 20},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			region, err := types.CreateRegionInfo(tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error creating region: %v", err)
+			}
+			sliceGroups, err := alg.CreateSliceGroups(region)
+			if err != nil {
+				t.Fatalf("unexpected error creating slice groups: %v", err)
+			}
+			checkSliceGroupInvariants(t, region, sliceGroups)
+			for zone, expected := range tc.expected {
+				if number := sliceGroups[zone].NumberOfEndpoints(); number != expected {
+					t.Errorf("expected %d endpoints for %s, got %d", expected, zone, number)
+				}
+			}
+		})
+	}
+
+	t.Run("zone without latency", func(t *testing.T) {
+		region, err := types.CreateRegionInfo([]types.Zone{
+			types.Zone{Nodes: 10, Endpoints: 30, Name: "ZoneA", AvgCrossZoneLatencyMs: 1},
+			types.Zone{Nodes: 30, Endpoints: 10, Name: "ZoneB"},
+		})
+		if err != nil {
+			t.Fatalf("unexpected error creating region: %v", err)
+		}
+		sliceGroups, err := alg.CreateSliceGroups(region)
+		if err != nil {
+			t.Fatalf("unexpected error creating slice groups: %v", err)
+		}
+		expected, _ := alg.localAlgorithm.CreateSliceGroups(region)
+		if !reflect.DeepEqual(sliceGroups, expected) {
+			t.Errorf("expected the same slice groups as LocalSliceAlgorithm %v, got %v", expected, sliceGroups)
+		}
+	})
+}
@@ -21,9 +21,9 @@ import (
 	"fmt"
 	"math"
 	"sort"
+	"sync"
 
 	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
-	"k8s.io/klog/v2"
 )
 
 // LocalSharedSliceAlgorithm is one variation of LocalSliceAlgorithm which
@@ -32,17 +32,52 @@ import (
 // distribution). This variation deals with failed corner cases by sharing
 // endpoints to zones that have no endpoints.
 type LocalSharedSliceAlgorithm struct {
+	// mu guards threshold against updates while slice groups are created
+	mu sync.RWMutex
 	// threshold for max deviation allowed for endpoints
 	threshold float64
+	// bandwidthAware scales threshold of zones by their bandwidth
+	bandwidthAware bool
+}
+
+// WithBandwidthAwareThreshold returns a copy of the algorithm which scales the
+// deviation threshold of zones with a known bandwidth by BandwidthMbps /
+// average bandwidth
+func (alg *LocalSharedSliceAlgorithm) WithBandwidthAwareThreshold() *LocalSharedSliceAlgorithm {
+	return &LocalSharedSliceAlgorithm{threshold: alg.Threshold(), bandwidthAware: true}
+}
+
+// Threshold returns the max deviation allowed for endpoints
+func (alg *LocalSharedSliceAlgorithm) Threshold() float64 {
+	alg.mu.RLock()
+	defer alg.mu.RUnlock()
+	return alg.threshold
+}
+
+// SetThreshold updates the max deviation allowed for endpoints, it waits for
+// running CreateSliceGroups calls to finish
+func (alg *LocalSharedSliceAlgorithm) SetThreshold(t float64) error {
+	if t <= 0 {
+		return fmt.Errorf("threshold %v should be positive", t)
+	}
+	alg.mu.Lock()
+	defer alg.mu.Unlock()
+	alg.threshold = t
+	return nil
 }
 
 // CreateSliceGroups creates sliceGroups with 'one local EndpointSliceGroup per
 // zone' policy. Zones with no endpoints allocated will be treated as a whole
 // that shares a shared-SG.
-func (alg LocalSharedSliceAlgorithm) CreateSliceGroups(region types.RegionInfo) (map[string]types.EndpointSliceGroup, error) {
+func (alg *LocalSharedSliceAlgorithm) CreateSliceGroups(region types.RegionInfo) (map[string]types.EndpointSliceGroup, error) {
+	alg.mu.RLock()
+	defer alg.mu.RUnlock()
 	if region.ZoneDetails == nil {
 		return nil, fmt.Errorf("zoneDetail should not be nil")
 	}
+	if region.TotalEndpoints == 0 {
+		return map[string]types.EndpointSliceGroup{}, nil
+	}
 	// if number of total endpoints < number of zones, use original algorithm
 	// instead. This algorithm itself can handle some of these special corner
 	// cases but performs poorly at small scale corner cases, so using the
@@ -69,7 +104,7 @@ func (alg LocalSharedSliceAlgorithm) CreateSliceGroups(region types.RegionInfo)
 		ReceiveEndpoint: true,
 	}
 	// traverse the map by name order
-	zoneNames := sortZoneByNames(region.ZoneDetails)
+	zoneNames := region.ZoneNames()
 	for _, zoneName := range zoneNames {
 		zone := region.ZoneDetails[zoneName]
 		var localGroup types.EndpointSliceGroup
@@ -84,13 +119,13 @@ func (alg LocalSharedSliceAlgorithm) CreateSliceGroups(region types.RegionInfo)
 
 		// calculate expected number of endpoints based on the proportion of
 		// nodes in this zone
-		expectedEndpoints := zone.NodesRatio * float64(region.TotalEndpoints)
+		expectedEndpoints := zone.ExpectedEndpoints(region.TotalEndpoints)
 		// deviation: a negative value means need more endpoints from other
 		// sliceGroups, a positive value means need give out endpoints to other
 		// sliceGroups
 		deviation := float64(zone.Endpoints) - expectedEndpoints
 		// merge all the zones with no endpoints into a shared slice group
-		if zone.Endpoints == 0 {
+		if region.HasZeroEndpoints(zoneName) {
 			// this is a form used to accurately represent the deviation in
 			// float, floatDeviation = 1 * abs(deviation). If we do the
 			// approximate here, it could lead to a large accuracy lose with
@@ -123,15 +158,30 @@ func (alg LocalSharedSliceAlgorithm) CreateSliceGroups(region types.RegionInfo)
 		return nil, err
 	}
 	if !succ {
-		klog.Infof("failed to use local shared algorithm, switching to original algorithm %+v \n", region)
+		logger.Info("failed to use local shared algorithm, switching to original algorithm", "algorithm", "LocalSharedSliceAlgorithm", "region", region.Summarize())
 		return OriginalAlgorithm{}.CreateSliceGroups(region)
 	}
+	if err := AssertEndpointConservation(region, sliceGroups); err != nil {
+		return nil, err
+	}
 	return sliceGroups, nil
 }
 
+// BalanceSliceGroupsShared runs the rebalancing of LocalSharedSliceAlgorithm
+// with the deviation threshold on pre-built lists and pools, so it can be
+// tested without CreateSliceGroups. It distributes endpoints from zones of
+// availablePool to the zones of endpointsNeeded, merges the zones of
+// endpointsNeededUrgent into one shared EndpointSliceGroup, and updates
+// sliceGroups in place. It returns false if the region can't be balanced.
+func BalanceSliceGroupsShared(threshold float64, endpointsNeeded, endpointsNeededUrgent *endpointsList, region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup, availablePool, receiverPool *ZonePriorityQueue) (bool, error) {
+	alg := &LocalSharedSliceAlgorithm{threshold: threshold}
+	return alg.balanceSliceGroups(endpointsNeeded, endpointsNeededUrgent, region, sliceGroups, availablePool, receiverPool)
+}
+
 // balanceSliceGroups distributes endpoints from zones with extra endpoints to
-// EndpointSliceGroups for zones with insufficient endpoints.
-func (alg LocalSharedSliceAlgorithm) balanceSliceGroups(endpointsNeeded *endpointsList, endpointsNeededUrgent *endpointsList, region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup, availablePool *ZonePriorityQueue, receiverPool *ZonePriorityQueue) (bool, error) {
+// EndpointSliceGroups for zones with insufficient endpoints. It's kept as a
+// method since thresholds of zones also depend on bandwidthAware.
+func (alg *LocalSharedSliceAlgorithm) balanceSliceGroups(endpointsNeeded *endpointsList, endpointsNeededUrgent *endpointsList, region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup, availablePool *ZonePriorityQueue, receiverPool *ZonePriorityQueue) (bool, error) {
 	heap.Init(availablePool)
 	// merge one sharedSG that zones in the urgent list will consume
 	mergedSG := types.EndpointSliceGroup{Composition: map[string]types.WeightedEndpoints{}, ZoneTrafficWeights: map[string]float64{}}
@@ -141,6 +191,11 @@ func (alg LocalSharedSliceAlgorithm) balanceSliceGroups(endpointsNeeded *endpoin
 	// accumulate the float deviation of every urgent zone, this is an actual
 	// value of sum(expectedEndpoints)
 	expectedEndpointsMerged := 0.0
+	// sort urgent zones by name to make the label of the merged SG
+	// deterministic
+	sort.Slice(endpointsNeededUrgent.byZone, func(i, j int) bool {
+		return endpointsNeededUrgent.byZone[i].name < endpointsNeededUrgent.byZone[j].name
+	})
 	for _, urgentZone := range endpointsNeededUrgent.byZone {
 		mergedED.name += "-" + urgentZone.name
 		expectedEndpointsMerged += (float64(urgentZone.deviation) * urgentZone.weight)
@@ -169,9 +224,22 @@ func (alg LocalSharedSliceAlgorithm) balanceSliceGroups(endpointsNeeded *endpoin
 		sliceGroups[mergedSG.Label] = mergedSG
 		endpointsNeeded.pushFront(mergedED)
 	}
+	// every iteration either moves one endpoint or finishes one needed zone,
+	// more iterations mean a broken pool
+	iterations, maxIterations := 0, maxBalanceIterations(region)
 	// assign extra endpoints to zones/SG needed
 	for index := 0; index < len(endpointsNeeded.byZone); {
+		iterations++
+		if iterations > maxIterations {
+			return false, errMaxIterations
+		}
 		receiveZone := endpointsNeeded.byZone[index]
+		// the zone reached its MaxEndpoints, stop assigning endpoints to it.
+		// Merged SGs of urgent zones are not in the region and never full.
+		if !region.ZoneDetails[receiveZone.name].CanAcceptEndpoints(sliceGroups[receiveZone.name].NumberOfEndpoints(), 1) {
+			endpointsNeeded.pop()
+			continue
+		}
 		if availablePool.Len() == 0 {
 			// if no zones can give endpoints to needed zones, this
 			// variation can't work with this input, we handle the input with
@@ -209,11 +277,16 @@ func (alg LocalSharedSliceAlgorithm) balanceSliceGroups(endpointsNeeded *endpoin
 	// the second and third zone will not require endpoints based on floor
 	// approximation (2.8 -> 2). But the 1st zone has too many endpoints, it
 	// should give one out.
+	iterations = 0
 	for availablePool.Len() > 0 {
+		iterations++
+		if iterations > maxIterations {
+			return false, errMaxIterations
+		}
 		candidate := heap.Pop(availablePool).(string)
 		// candidate is guaranteed to have a local owned SG, omit the second
 		// returned value
-		deviation, ok := getEndpointsDeviation(region, sliceGroups, candidate)
+		deviation, ok := CalculateDeviation(region, sliceGroups, candidate)
 		if !ok {
 			// this should never happen, since every candidate in the
 			// availablePool should have a local SG.
@@ -225,7 +298,30 @@ func (alg LocalSharedSliceAlgorithm) balanceSliceGroups(endpointsNeeded *endpoin
 		// if candidate zone has at least one extra endpoints than it
 		// expects, it should give that endpoint out to a zone that needs
 		// endpoints from other zones.
+		if receiverPool.Len() == 0 {
+			break
+		}
 		receiveZone := heap.Pop(receiverPool).(string)
+		if !region.ZoneDetails[receiveZone].CanAcceptEndpoints(sliceGroups[receiveZone].NumberOfEndpoints(), 1) {
+			// full zones leave the receiverPool, the candidate tries the next
+			// receiver
+			heap.Push(availablePool, candidate)
+			continue
+		}
+		if receiveZone == candidate {
+			// other zones are full, there is nowhere to give the endpoint
+			heap.Push(receiverPool, receiveZone)
+			break
+		}
+		// the receiver has the highest traffic load, if it already has its
+		// expected endpoints so do the others. This happens when a shared
+		// sliceGroup replaced the sliceGroup of an urgent zone with less
+		// endpoints than the zone expects, leaving extra endpoints in every
+		// local sliceGroup.
+		if receiveDeviation, _ := CalculateDeviation(region, sliceGroups, receiveZone); receiveDeviation >= 0 {
+			heap.Push(receiverPool, receiveZone)
+			break
+		}
 		updateSGComposition(sliceGroups[receiveZone], candidate, 1, 1)
 		heap.Push(receiverPool, receiveZone)
 
@@ -237,17 +333,6 @@ func (alg LocalSharedSliceAlgorithm) balanceSliceGroups(endpointsNeeded *endpoin
 	return true, nil
 }
 
-// helper function help calculate the deviation between locally owned endpoints
-// and expected endpoints.
-func getEndpointsDeviation(region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup, zone string) (float64, bool) {
-	expectedEndpoints := float64(region.TotalEndpoints) * region.ZoneDetails[zone].NodesRatio
-	sliceGroup, ok := sliceGroups[zone]
-	if !ok {
-		return 0.0, false
-	}
-	return float64(sliceGroup.NumberOfEndpoints()) - expectedEndpoints, true
-}
-
 // helper function to update composition in ESG
 func updateSGComposition(sliceGroup types.EndpointSliceGroup, zone string, delta int, weight float64) {
 	weightedComp := sliceGroup.Composition[zone]
@@ -258,7 +343,7 @@ func updateSGComposition(sliceGroup types.EndpointSliceGroup, zone string, delta
 
 // helper function helps to keep all the endpoints with a traffic load deviation
 // less than threshold, return false if it can't.
-func (alg LocalSharedSliceAlgorithm) keepDeviationBelowThreshold(availablePool *ZonePriorityQueue, receiverPool *ZonePriorityQueue) bool {
+func (alg *LocalSharedSliceAlgorithm) keepDeviationBelowThreshold(availablePool *ZonePriorityQueue, receiverPool *ZonePriorityQueue) bool {
 	region := availablePool.Region
 	sliceGroups := availablePool.SliceGroups
 	// get zones with deviation >= threshold
@@ -286,11 +371,11 @@ func (alg LocalSharedSliceAlgorithm) keepDeviationBelowThreshold(availablePool *
 		for extraEndpointsNumber < len(urgentZones) {
 			if availablePool.Len() > 0 {
 				candidate := availablePool.ZoneNames[0]
-				deviation, ok := getEndpointsDeviation(region, sliceGroups, candidate)
+				deviation, ok := CalculateDeviation(region, sliceGroups, candidate)
 				if !ok {
 					// this should never happen, since every candidate in the
 					// availablePool should have a local SG.
-					klog.Errorf("unexpcted nil error in sliceGroups while getting deviation for %s candidate", candidate)
+					logger.Error("unexpcted nil error in sliceGroups while getting deviation for candidate", "algorithm", "LocalSharedSliceAlgorithm", "zone", candidate)
 					return false
 				}
 				// zones have absolute extra endpoints directly give them out
@@ -324,6 +409,15 @@ func (alg LocalSharedSliceAlgorithm) keepDeviationBelowThreshold(availablePool *
 			}
 			return false
 		}
+		// a zone reached its MaxEndpoints can't receive an endpoint into its
+		// own sliceGroup, the extra endpoints make a shared sliceGroup instead
+		if !urgentZonesCanAcceptEndpoints(urgentZones, region, sliceGroups) {
+			if alg.getExtraEndpointsForSharedSlice(availablePool, extraEndpoints, urgentZones) {
+				alg.createSharedSlice(urgentZones, extraEndpoints, sliceGroups)
+				return true
+			}
+			return false
+		}
 		// sort zone names to deterministically traverse the map
 		var zoneNames []string
 		for zone := range extraEndpoints {
@@ -345,8 +439,19 @@ func (alg LocalSharedSliceAlgorithm) keepDeviationBelowThreshold(availablePool *
 	return true
 }
 
+// urgentZonesCanAcceptEndpoints returns true if every urgent zone can receive
+// one more endpoint into its sliceGroup without exceeding its MaxEndpoints
+func urgentZonesCanAcceptEndpoints(urgentZones []string, region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup) bool {
+	for _, urgentZone := range urgentZones {
+		if !region.ZoneDetails[urgentZone].CanAcceptEndpoints(sliceGroups[urgentZone].NumberOfEndpoints(), 1) {
+			return false
+		}
+	}
+	return true
+}
+
 // detect whether a zone is valid to contribute endpoints to other zones
-func (alg LocalSharedSliceAlgorithm) validContributor(zoneName string, region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup) bool {
+func (alg *LocalSharedSliceAlgorithm) validContributor(zoneName string, region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup) bool {
 	// if the sliceGroup has no local composition, it is not a valid contributor
 	if sliceGroups[zoneName].Composition == nil || sliceGroups[zoneName].NumberOfEndpoints() == 1 {
 		return false
@@ -355,15 +460,23 @@ func (alg LocalSharedSliceAlgorithm) validContributor(zoneName string, region ty
 }
 
 // check if endpoints in receiveZone have invalid deviation
-func (alg LocalSharedSliceAlgorithm) deviationAboveThreshold(receiveZone string, region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup, delta int) bool {
-	expectedEndpoints := float64(region.TotalEndpoints) * region.ZoneDetails[receiveZone].NodesRatio
+func (alg *LocalSharedSliceAlgorithm) deviationAboveThreshold(receiveZone string, region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup, delta int) bool {
+	expectedEndpoints := region.ZoneDetails[receiveZone].ExpectedEndpoints(region.TotalEndpoints)
 	trafficDeviation := expectedEndpoints/float64(sliceGroups[receiveZone].NumberOfEndpoints()+delta) - 1
-	return trafficDeviation >= alg.threshold
+	return trafficDeviation >= alg.zoneThreshold(region, receiveZone)
+}
+
+// zoneThreshold returns the deviation threshold of a zone
+func (alg *LocalSharedSliceAlgorithm) zoneThreshold(region types.RegionInfo, zone string) float64 {
+	if !alg.bandwidthAware {
+		return alg.threshold
+	}
+	return effectiveThreshold(alg.threshold, region, zone)
 }
 
 // check if endpoints in a shared sliceGroup could be able to achieve deviation
 // less than threshold
-func (alg LocalSharedSliceAlgorithm) sufficientExtraEndpointsForSharedSlice(urgentZones []string, region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup, extraEndpoints int) bool {
+func (alg *LocalSharedSliceAlgorithm) sufficientExtraEndpointsForSharedSlice(urgentZones []string, region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup, extraEndpoints int) bool {
 	trafficLoad := 0.0
 	totalEndpoints := extraEndpoints
 	for _, urgentZone := range urgentZones {
@@ -372,7 +485,7 @@ func (alg LocalSharedSliceAlgorithm) sufficientExtraEndpointsForSharedSlice(urge
 	// traffic load = sum(exptected endpoints) / total endpoints in the shared
 	// sliceGroup
 	for _, urgentZone := range urgentZones {
-		expectedEP := float64(region.TotalEndpoints) * region.ZoneDetails[urgentZone].NodesRatio
+		expectedEP := region.ZoneDetails[urgentZone].ExpectedEndpoints(region.TotalEndpoints)
 		trafficLoad += expectedEP / float64(totalEndpoints)
 	}
 	return trafficLoad-1 < alg.threshold
@@ -380,24 +493,22 @@ func (alg LocalSharedSliceAlgorithm) sufficientExtraEndpointsForSharedSlice(urge
 
 // create a shared sliceGroup for urgent zones that have a deviation
 // greater/equal to threshold
-func (alg LocalSharedSliceAlgorithm) createSharedSlice(urgentZones []string, extraEndpoints map[string]int, sliceGroups map[string]types.EndpointSliceGroup) {
-	sharedLabel := "shared"
-	sharedSG := types.EndpointSliceGroup{Composition: map[string]types.WeightedEndpoints{}, ZoneTrafficWeights: map[string]float64{}}
-	for _, urgentZone := range urgentZones {
-		sharedLabel += fmt.Sprintf("-%s", urgentZone)
-		for zone, contribution := range sliceGroups[urgentZone].Composition {
-			// urgent zones are contributing all of their endpoints to the
-			// shared SG.
-			updateSGComposition(sharedSG, zone, contribution.Number, contribution.Weight)
-		}
-		sharedSG.ZoneTrafficWeights[urgentZone] = 1
+func (alg *LocalSharedSliceAlgorithm) createSharedSlice(urgentZones []string, extraEndpoints map[string]int, sliceGroups map[string]types.EndpointSliceGroup) {
+	sharedSG := types.EndpointSliceGroup{Label: "shared", Composition: map[string]types.WeightedEndpoints{}, ZoneTrafficWeights: map[string]float64{}}
+	// urgentZones are in the order of their deviation, merge them by name to
+	// make the label of the shared SG deterministic
+	sortedZones := append([]string{}, urgentZones...)
+	sort.Strings(sortedZones)
+	for _, urgentZone := range sortedZones {
+		// urgent zones are contributing all of their endpoints to the shared
+		// SG, and their traffic is entirely routed to it.
+		sharedSG.Merge(sliceGroups[urgentZone])
 		delete(sliceGroups, urgentZone)
 	}
 	for zone, number := range extraEndpoints {
 		updateSGComposition(sharedSG, zone, number, 1)
 	}
-	sharedSG.Label = sharedLabel
-	sliceGroups[sharedLabel] = sharedSG
+	sliceGroups[sharedSG.Label] = sharedSG
 }
 
 // getExtraEndpointsForSharedSlice attempts to get extra endpoints that could be
@@ -406,7 +517,7 @@ func (alg LocalSharedSliceAlgorithm) createSharedSlice(urgentZones []string, ext
 // Previously we only ask zones to give out endpoints before they reach the
 // ceiling of their expected endpoints. In this function, we ask zones to give
 // out endpoints as long as their deviations are less than threshold.
-func (alg LocalSharedSliceAlgorithm) getExtraEndpointsForSharedSlice(availablePool *ZonePriorityQueue, extraEndpoints map[string]int, urgentZones []string) bool {
+func (alg *LocalSharedSliceAlgorithm) getExtraEndpointsForSharedSlice(availablePool *ZonePriorityQueue, extraEndpoints map[string]int, urgentZones []string) bool {
 	sliceGroups := availablePool.SliceGroups
 	region := availablePool.Region
 	// total number of extra endpoints, this value is used to check if it's
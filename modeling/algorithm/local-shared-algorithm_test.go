@@ -17,6 +17,10 @@ limitations under the License.
 package algorithm
 
 import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
@@ -585,11 +589,561 @@ func TestLocalSharedAlgorithm(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		{
+			name: "receiving zone limited by max endpoints",
+			input: []types.Zone{
+				types.Zone{
+					Nodes:     10,
+					Endpoints: 18,
+					Name:      "ZoneA",
+				},
+				types.Zone{
+					Nodes:        10,
+					Endpoints:    2,
+					Name:         "ZoneB",
+					MaxEndpoints: 9,
+				},
+			},
+			expectedOutput: map[string]types.EndpointSliceGroup{
+				"ZoneA": types.EndpointSliceGroup{
+					Label: "ZoneA",
+					Composition: map[string]types.WeightedEndpoints{
+						"ZoneA": types.WeightedEndpoints{Number: 11, Weight: 1},
+					},
+					ZoneTrafficWeights: map[string]float64{
+						"ZoneA": 1,
+					},
+				},
+				"ZoneB": types.EndpointSliceGroup{
+					Label: "ZoneB",
+					Composition: map[string]types.WeightedEndpoints{
+						"ZoneA": types.WeightedEndpoints{Number: 7, Weight: 1},
+						"ZoneB": types.WeightedEndpoints{Number: 2, Weight: 1},
+					},
+					ZoneTrafficWeights: map[string]float64{
+						"ZoneB": 1,
+					},
+				},
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "urgent zone at max endpoints with borrowed endpoints gets a shared slice",
+			input: []types.Zone{
+				types.Zone{
+					Nodes:     10,
+					Endpoints: 18,
+					Name:      "ZoneA",
+				},
+				types.Zone{
+					Nodes:        10,
+					Endpoints:    2,
+					Name:         "ZoneB",
+					MaxEndpoints: 5,
+				},
+			},
+			expectedOutput: map[string]types.EndpointSliceGroup{
+				"ZoneA": types.EndpointSliceGroup{
+					Label: "ZoneA",
+					Composition: map[string]types.WeightedEndpoints{
+						"ZoneA": types.WeightedEndpoints{Number: 13, Weight: 1},
+					},
+					ZoneTrafficWeights: map[string]float64{
+						"ZoneA": 1,
+					},
+				},
+				"shared-ZoneB": types.EndpointSliceGroup{
+					Label: "shared-ZoneB",
+					Composition: map[string]types.WeightedEndpoints{
+						"ZoneA": types.WeightedEndpoints{Number: 5, Weight: 1},
+						"ZoneB": types.WeightedEndpoints{Number: 2, Weight: 1},
+					},
+					ZoneTrafficWeights: map[string]float64{
+						"ZoneB": 1,
+					},
+				},
+			},
+			expectedErr: nil,
+		},
+		{
+			name: "urgent zone at max endpoints with its own endpoints gets a shared slice",
+			input: []types.Zone{
+				types.Zone{
+					Nodes:     10,
+					Endpoints: 18,
+					Name:      "ZoneA",
+				},
+				types.Zone{
+					Nodes:        10,
+					Endpoints:    2,
+					Name:         "ZoneB",
+					MaxEndpoints: 2,
+				},
+			},
+			expectedOutput: map[string]types.EndpointSliceGroup{
+				"ZoneA": types.EndpointSliceGroup{
+					Label: "ZoneA",
+					Composition: map[string]types.WeightedEndpoints{
+						"ZoneA": types.WeightedEndpoints{Number: 13, Weight: 1},
+					},
+					ZoneTrafficWeights: map[string]float64{
+						"ZoneA": 1,
+					},
+				},
+				"shared-ZoneB": types.EndpointSliceGroup{
+					Label: "shared-ZoneB",
+					Composition: map[string]types.WeightedEndpoints{
+						"ZoneA": types.WeightedEndpoints{Number: 5, Weight: 1},
+						"ZoneB": types.WeightedEndpoints{Number: 2, Weight: 1},
+					},
+					ZoneTrafficWeights: map[string]float64{
+						"ZoneB": 1,
+					},
+				},
+			},
+			expectedErr: nil,
+		},
+		{
+			// the shared sliceGroup of z0 has less endpoints than z0 expects,
+			// every local sliceGroup left has extra endpoints
+			name: "shared slice of a zone at max endpoints leaving extra endpoints",
+			input: []types.Zone{
+				types.Zone{
+					Nodes:        57,
+					Endpoints:    5,
+					Name:         "z0",
+					MaxEndpoints: 6,
+				},
+				types.Zone{
+					Nodes:     72,
+					Endpoints: 58,
+					Name:      "z1",
+				},
+				types.Zone{
+					Nodes:     26,
+					Endpoints: 68,
+					Name:      "z2",
+				},
+				types.Zone{
+					Nodes:     20,
+					Endpoints: 0,
+					Name:      "z3",
+				},
+				types.Zone{
+					Nodes:     11,
+					Endpoints: 13,
+					Name:      "z4",
+				},
+			},
+			expectedOutput: map[string]types.EndpointSliceGroup{
+				"merged-z3": types.EndpointSliceGroup{
+					Label: "merged-z3",
+					Composition: map[string]types.WeightedEndpoints{
+						"z2": types.WeightedEndpoints{Number: 15, Weight: 1},
+					},
+					ZoneTrafficWeights: map[string]float64{
+						"z3": 1,
+					},
+				},
+				"shared-z0": types.EndpointSliceGroup{
+					Label: "shared-z0",
+					Composition: map[string]types.WeightedEndpoints{
+						"z0": types.WeightedEndpoints{Number: 5, Weight: 1},
+						"z2": types.WeightedEndpoints{Number: 24, Weight: 1},
+						"z4": types.WeightedEndpoints{Number: 1, Weight: 1},
+					},
+					ZoneTrafficWeights: map[string]float64{
+						"z0": 1,
+					},
+				},
+				"z1": types.EndpointSliceGroup{
+					Label: "z1",
+					Composition: map[string]types.WeightedEndpoints{
+						"z1": types.WeightedEndpoints{Number: 58, Weight: 1},
+					},
+					ZoneTrafficWeights: map[string]float64{
+						"z1": 1,
+					},
+				},
+				"z2": types.EndpointSliceGroup{
+					Label: "z2",
+					Composition: map[string]types.WeightedEndpoints{
+						"z2": types.WeightedEndpoints{Number: 29, Weight: 1},
+					},
+					ZoneTrafficWeights: map[string]float64{
+						"z2": 1,
+					},
+				},
+				"z4": types.EndpointSliceGroup{
+					Label: "z4",
+					Composition: map[string]types.WeightedEndpoints{
+						"z4": types.WeightedEndpoints{Number: 12, Weight: 1},
+					},
+					ZoneTrafficWeights: map[string]float64{
+						"z4": 1,
+					},
+				},
+			},
+			expectedErr: nil,
+		},
 	}
 	localTest := routingAlgorithmTest{
 		algName:   "LocalSharedSlice",
-		alg:       LocalSharedSliceAlgorithm{threshold: 0.5},
+		alg:       &LocalSharedSliceAlgorithm{threshold: 0.5},
 		testCases: testCases,
 	}
 	localTest.doTest(t)
 }
+
+func TestBalanceSliceGroupsShared(t *testing.T) {
+	local := func(zone string, composition map[string]int) types.EndpointSliceGroup {
+		sliceGroup := types.EndpointSliceGroup{Label: zone, Composition: map[string]types.WeightedEndpoints{}, ZoneTrafficWeights: map[string]float64{zone: 1}}
+		for name, number := range composition {
+			sliceGroup.Composition[name] = types.WeightedEndpoints{Number: number, Weight: 1}
+		}
+		return sliceGroup
+	}
+	testCases := []struct {
+		name      string
+		zones     []types.Zone
+		threshold float64
+		needed    []endpointDeviation
+		urgent    []endpointDeviation
+		available []string
+		receivers []string
+		succ      bool
+		expected  map[string]types.EndpointSliceGroup
+	}{
+		{
+			// ZoneA expects 2.5 endpoints and gives one to ZoneB
+			name:      "needed zone receives from contributor",
+			zones:     []types.Zone{{Name: "ZoneA", Nodes: 1, Endpoints: 4}, {Name: "ZoneB", Nodes: 1, Endpoints: 1}},
+			threshold: 0.5,
+			needed:    []endpointDeviation{{name: "ZoneB", deviation: 1}},
+			available: []string{"ZoneA"},
+			receivers: []string{"ZoneA", "ZoneB"},
+			succ:      true,
+			expected: map[string]types.EndpointSliceGroup{
+				"ZoneA": local("ZoneA", map[string]int{"ZoneA": 3}),
+				"ZoneB": local("ZoneB", map[string]int{"ZoneA": 1, "ZoneB": 1}),
+			},
+		},
+		{
+			// ZoneB has no endpoints and expects 2 endpoints in its merged SG
+			name:      "urgent zone consumes merged slice group",
+			zones:     []types.Zone{{Name: "ZoneA", Nodes: 1, Endpoints: 4}, {Name: "ZoneB", Nodes: 1, Endpoints: 0}},
+			threshold: 0.5,
+			urgent:    []endpointDeviation{{name: "ZoneB", deviation: 1, weight: 2}},
+			available: []string{"ZoneA"},
+			receivers: []string{"ZoneA"},
+			succ:      true,
+			expected: map[string]types.EndpointSliceGroup{
+				"ZoneA": local("ZoneA", map[string]int{"ZoneA": 2}),
+				"merged-ZoneB": {
+					Label:              "merged-ZoneB",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneA": {Number: 2, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneB": 1},
+				},
+			},
+		},
+		{
+			// ZoneB and ZoneC stay above the threshold with one more endpoint
+			// each, they share their endpoints and one extra endpoint of ZoneA
+			name:      "zones above threshold share a slice group",
+			zones:     []types.Zone{{Name: "ZoneA", Nodes: 2, Endpoints: 5}, {Name: "ZoneB", Nodes: 2, Endpoints: 3}, {Name: "ZoneC", Nodes: 1, Endpoints: 1}},
+			threshold: 0.1,
+			available: []string{"ZoneA"},
+			receivers: []string{"ZoneA", "ZoneB", "ZoneC"},
+			succ:      true,
+			expected: map[string]types.EndpointSliceGroup{
+				"ZoneA": local("ZoneA", map[string]int{"ZoneA": 4}),
+				"shared-ZoneB-ZoneC": {
+					Label:              "shared-ZoneB-ZoneC",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneA": {Number: 1, Weight: 1}, "ZoneB": {Number: 3, Weight: 1}, "ZoneC": {Number: 1, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneB": 1, "ZoneC": 1},
+				},
+			},
+		},
+		{
+			name:      "no contributor",
+			zones:     []types.Zone{{Name: "ZoneA", Nodes: 1, Endpoints: 3}, {Name: "ZoneB", Nodes: 3, Endpoints: 1}},
+			threshold: 0.5,
+			needed:    []endpointDeviation{{name: "ZoneB", deviation: 2}},
+			receivers: []string{"ZoneA", "ZoneB"},
+			expected: map[string]types.EndpointSliceGroup{
+				"ZoneA": local("ZoneA", map[string]int{"ZoneA": 3}),
+				"ZoneB": local("ZoneB", map[string]int{"ZoneB": 1}),
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			region, err := types.CreateRegionInfo(tc.zones)
+			if err != nil {
+				t.Fatalf("unexpected error creating region: %v", err)
+			}
+			sliceGroups := map[string]types.EndpointSliceGroup{}
+			for _, zone := range tc.zones {
+				if zone.Endpoints > 0 {
+					sliceGroups[zone.Name] = local(zone.Name, map[string]int{zone.Name: zone.Endpoints})
+				}
+			}
+			needed, urgent := endpointsList{byZone: tc.needed}, endpointsList{byZone: tc.urgent}
+			availablePool := ZonePriorityQueue{Region: region, SliceGroups: sliceGroups, ZoneNames: tc.available}
+			receiverPool := ZonePriorityQueue{Region: region, SliceGroups: sliceGroups, ZoneNames: tc.receivers, ReceiveEndpoint: true}
+			succ, err := BalanceSliceGroupsShared(tc.threshold, &needed, &urgent, region, sliceGroups, &availablePool, &receiverPool)
+			if err != nil {
+				t.Fatalf("unexpected error balancing sliceGroups: %v", err)
+			}
+			if succ != tc.succ {
+				t.Fatalf("expected success %v, got %v", tc.succ, succ)
+			}
+			if !reflect.DeepEqual(sliceGroups, tc.expected) {
+				t.Errorf("expected sliceGroups %+v, got %+v", tc.expected, sliceGroups)
+			}
+		})
+	}
+}
+
+func TestLocalSharedAlgorithmBandwidthAware(t *testing.T) {
+	input := []types.Zone{
+		types.Zone{Nodes: 3, Endpoints: 1, Name: "ZoneA", BandwidthMbps: 400},
+		types.Zone{Nodes: 2, Endpoints: 1, Name: "ZoneB", BandwidthMbps: 100},
+		types.Zone{Nodes: 1, Endpoints: 8, Name: "ZoneC", BandwidthMbps: 100},
+	}
+	localGroup := func(zone string, composition map[string]types.WeightedEndpoints) types.EndpointSliceGroup {
+		return types.EndpointSliceGroup{Label: zone, Composition: composition, ZoneTrafficWeights: map[string]float64{zone: 1}}
+	}
+	// ZoneB expects 3.33 endpoints, with bandwidth awareness its threshold is
+	// halved to 0.1 and it needs a shared sliceGroup to get below it
+	tests := []routingAlgorithmTest{
+		{
+			algName: "LocalSharedSlice",
+			alg:     &LocalSharedSliceAlgorithm{threshold: 0.2},
+			testCases: []algTestCase{{
+				name:  "without bandwidth awareness",
+				input: input,
+				expectedOutput: map[string]types.EndpointSliceGroup{
+					"ZoneA": localGroup("ZoneA", map[string]types.WeightedEndpoints{"ZoneA": {Number: 1, Weight: 1}, "ZoneC": {Number: 4, Weight: 1}}),
+					"ZoneB": localGroup("ZoneB", map[string]types.WeightedEndpoints{"ZoneB": {Number: 1, Weight: 1}, "ZoneC": {Number: 2, Weight: 1}}),
+					"ZoneC": localGroup("ZoneC", map[string]types.WeightedEndpoints{"ZoneC": {Number: 2, Weight: 1}}),
+				},
+			}},
+		},
+		{
+			algName: "LocalSharedSliceBandwidthAware",
+			alg:     (&LocalSharedSliceAlgorithm{threshold: 0.2}).WithBandwidthAwareThreshold(),
+			testCases: []algTestCase{{
+				name:  "with bandwidth awareness",
+				input: input,
+				expectedOutput: map[string]types.EndpointSliceGroup{
+					"ZoneA": localGroup("ZoneA", map[string]types.WeightedEndpoints{"ZoneA": {Number: 1, Weight: 1}, "ZoneC": {Number: 4, Weight: 1}}),
+					"ZoneC": localGroup("ZoneC", map[string]types.WeightedEndpoints{"ZoneC": {Number: 2, Weight: 1}}),
+					"shared-ZoneB": types.EndpointSliceGroup{
+						Label:              "shared-ZoneB",
+						Composition:        map[string]types.WeightedEndpoints{"ZoneB": {Number: 1, Weight: 1}, "ZoneC": {Number: 2, Weight: 1}},
+						ZoneTrafficWeights: map[string]float64{"ZoneB": 1},
+					},
+				},
+			}},
+		},
+	}
+	for _, test := range tests {
+		test.doTest(t)
+	}
+}
+
+func TestLocalSharedAlgorithmLabels(t *testing.T) {
+	testCases := []algTestCase{
+		{
+			// ZoneB has a higher deviation than ZoneA and becomes urgent first
+			name: "shared SG of multiple urgent zones",
+			input: []types.Zone{
+				types.Zone{Nodes: 3, Endpoints: 1, Name: "ZoneA"},
+				types.Zone{Nodes: 2, Endpoints: 1, Name: "ZoneB"},
+				types.Zone{Nodes: 1, Endpoints: 3, Name: "ZoneC"},
+			},
+			expectedOutput: map[string]types.EndpointSliceGroup{
+				"ZoneC": types.EndpointSliceGroup{
+					Label:              "ZoneC",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneC": {Number: 1, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneC": 1},
+				},
+				"shared-ZoneA-ZoneB": types.EndpointSliceGroup{
+					Label:              "shared-ZoneA-ZoneB",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneA": {Number: 1, Weight: 1}, "ZoneB": {Number: 1, Weight: 1}, "ZoneC": {Number: 2, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneA": 1, "ZoneB": 1},
+				},
+			},
+		},
+		{
+			name: "merged SG of multiple zones without endpoints",
+			input: []types.Zone{
+				types.Zone{Nodes: 10, Endpoints: 0, Name: "ZoneD"},
+				types.Zone{Nodes: 30, Endpoints: 0, Name: "ZoneB"},
+				types.Zone{Nodes: 30, Endpoints: 100, Name: "ZoneC"},
+				types.Zone{Nodes: 30, Endpoints: 0, Name: "ZoneA"},
+			},
+			expectedOutput: map[string]types.EndpointSliceGroup{
+				"ZoneC": types.EndpointSliceGroup{
+					Label:              "ZoneC",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneC": {Number: 30, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneC": 1},
+				},
+				"merged-ZoneA-ZoneB-ZoneD": types.EndpointSliceGroup{
+					Label:              "merged-ZoneA-ZoneB-ZoneD",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneC": {Number: 70, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneA": 1, "ZoneB": 1, "ZoneD": 1},
+				},
+			},
+		},
+	}
+	localTest := routingAlgorithmTest{
+		algName:   "LocalSharedSlice",
+		alg:       &LocalSharedSliceAlgorithm{threshold: 0.2},
+		testCases: testCases,
+	}
+	// labels should be the same across runs
+	for i := 0; i < 10; i++ {
+		localTest.doTest(t)
+	}
+}
+
+func TestLocalSharedAlgorithmDeterminism(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input []types.Zone
+	}{
+		{
+			name: "shared SG of two urgent zones",
+			input: []types.Zone{
+				{Nodes: 3, Endpoints: 1, Name: "ZoneA"},
+				{Nodes: 2, Endpoints: 1, Name: "ZoneB"},
+				{Nodes: 1, Endpoints: 3, Name: "ZoneC"},
+			},
+		},
+		{
+			// ZoneA, ZoneB and ZoneC tie in deviation
+			name: "shared SG of urgent zones with the same deviation",
+			input: []types.Zone{
+				{Nodes: 16, Endpoints: 1, Name: "ZoneA"},
+				{Nodes: 16, Endpoints: 1, Name: "ZoneB"},
+				{Nodes: 16, Endpoints: 1, Name: "ZoneC"},
+				{Nodes: 42, Endpoints: 6, Name: "ZoneD"},
+			},
+		},
+	}
+	random := rand.New(rand.NewSource(1))
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var first map[string]types.EndpointSliceGroup
+			for i := 0; i < 50; i++ {
+				// the order of input zones shouldn't matter either
+				zones := append([]types.Zone{}, tc.input...)
+				random.Shuffle(len(zones), func(a, b int) {
+					zones[a], zones[b] = zones[b], zones[a]
+				})
+				region, err := types.CreateRegionInfo(zones)
+				if err != nil {
+					t.Fatalf("unexpected error creating region: %v", err)
+				}
+				sliceGroups, err := (&LocalSharedSliceAlgorithm{threshold: 0.2}).CreateSliceGroups(region)
+				if err != nil {
+					t.Fatalf("unexpected error creating sliceGroups: %v", err)
+				}
+				if i == 0 {
+					first = sliceGroups
+					continue
+				}
+				if !reflect.DeepEqual(sliceGroups, first) {
+					t.Fatalf("expected the same sliceGroups in run %d, got %v, want %v", i, sliceGroups, first)
+				}
+			}
+			shared := false
+			for label := range first {
+				shared = shared || strings.HasPrefix(label, "shared-")
+			}
+			if !shared {
+				t.Errorf("expected a shared SG, got %v", first)
+			}
+		})
+	}
+}
+
+func TestLocalSharedAlgorithmMaxIterations(t *testing.T) {
+	region, err := types.CreateRegionInfo([]types.Zone{
+		types.Zone{Nodes: 1, Endpoints: 9, Name: "ZoneA"},
+		types.Zone{Nodes: 1, Endpoints: 1, Name: "ZoneB"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	sliceGroups := map[string]types.EndpointSliceGroup{}
+	for _, zoneName := range region.ZoneNames() {
+		zone := region.ZoneDetails[zoneName]
+		sliceGroups[zoneName] = types.EndpointSliceGroup{Label: zoneName, Composition: map[string]types.WeightedEndpoints{zoneName: {Number: zone.Endpoints, Weight: 1}}, ZoneTrafficWeights: map[string]float64{zoneName: 1}}
+	}
+	// a broken needed list asks ZoneA, the only contributor, for a negative
+	// number of endpoints, so the endpoint it gives out always comes back and
+	// the request is never fulfilled
+	endpointsNeeded := endpointsList{}
+	endpointsNeeded.push(endpointDeviation{name: "ZoneA", deviation: -1})
+	availablePool := ZonePriorityQueue{Region: region, SliceGroups: sliceGroups, ZoneNames: []string{"ZoneA"}}
+	receiverPool := ZonePriorityQueue{Region: region, SliceGroups: sliceGroups, ReceiveEndpoint: true, ZoneNames: region.ZoneNames()}
+	alg := &LocalSharedSliceAlgorithm{threshold: 0.5}
+	succ, err := alg.balanceSliceGroups(&endpointsNeeded, &endpointsList{}, region, sliceGroups, &availablePool, &receiverPool)
+	if succ || err != errMaxIterations {
+		t.Errorf("expected error %v balancing with a broken needed list, got %v, %v", errMaxIterations, succ, err)
+	}
+}
+
+func TestLocalSharedAlgorithmSetThreshold(t *testing.T) {
+	alg := &LocalSharedSliceAlgorithm{threshold: 0.5}
+	for _, threshold := range []float64{0, -0.5} {
+		if err := alg.SetThreshold(threshold); err == nil {
+			t.Errorf("expected error setting threshold %v", threshold)
+		}
+	}
+	if err := alg.SetThreshold(0.2); err != nil {
+		t.Fatalf("unexpected error setting threshold: %v", err)
+	}
+	if alg.Threshold() != 0.2 {
+		t.Errorf("expected threshold 0.2, got %v", alg.Threshold())
+	}
+}
+
+func TestLocalSharedAlgorithmSetThresholdConcurrently(t *testing.T) {
+	region, err := types.CreateRegionInfo([]types.Zone{
+		types.Zone{Nodes: 1, Endpoints: 5, Name: "ZoneA"},
+		types.Zone{Nodes: 2, Endpoints: 20, Name: "ZoneB"},
+		types.Zone{Nodes: 7, Endpoints: 20, Name: "ZoneC"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	alg := &LocalSharedSliceAlgorithm{threshold: 0.5}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := alg.SetThreshold(0.1 * float64(i+j%5+1)); err != nil {
+					t.Errorf("unexpected error setting threshold: %v", err)
+				}
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				sliceGroups, err := alg.CreateSliceGroups(region)
+				if err != nil {
+					t.Errorf("unexpected error creating sliceGroups: %v", err)
+					return
+				}
+				checkSliceGroupInvariants(t, region, sliceGroups)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -28,7 +28,14 @@ import (
 // balanced with the incoming traffic (number of nodes distribution). This
 // variation distributes extra endpoints available after local-slice
 // distribution to a global SG with a lower weight that every zone can reach.
-type LocalSliceAlgorithmOpt struct{}
+type LocalSliceAlgorithmOpt struct {
+	// GlobalSGWeightByNodes weights traffic from each zone to the global SG by
+	// the NodesRatio of the zone instead of 1 / number of zones
+	GlobalSGWeightByNodes bool
+	// GlobalSGWeight, if positive, is the constant weight of traffic from each
+	// zone to the global SG, it takes precedence over GlobalSGWeightByNodes
+	GlobalSGWeight float64
+}
 
 // CreateSliceGroups creates sliceGroups with 'one local EndpointSliceGroup per
 // zone' policy
@@ -36,13 +43,16 @@ func (alg LocalSliceAlgorithmOpt) CreateSliceGroups(region types.RegionInfo) (ma
 	if region.ZoneDetails == nil {
 		return nil, fmt.Errorf("zoneDetail should not be nil")
 	}
+	if region.TotalEndpoints == 0 {
+		return map[string]types.EndpointSliceGroup{}, nil
+	}
 	sliceGroups := map[string]types.EndpointSliceGroup{}
 	// endpointsAvailable stores zones with number of endpoints available
 	endpointsAvailable := endpointsList{}
 	// endpointsNeeded stores zones with number of endpoints needed
 	endpointsNeeded := endpointsList{}
 	// traverse the map by name order
-	zoneNames := sortZoneByNames(region.ZoneDetails)
+	zoneNames := region.ZoneNames()
 	for _, zoneName := range zoneNames {
 		zone := region.ZoneDetails[zoneName]
 		var localGroup types.EndpointSliceGroup
@@ -57,7 +67,7 @@ func (alg LocalSliceAlgorithmOpt) CreateSliceGroups(region types.RegionInfo) (ma
 
 		// calculate expected number of endpoints based on the proportion of
 		// nodes in this zone
-		expectedEndpoints := zone.NodesRatio * float64(region.TotalEndpoints)
+		expectedEndpoints := zone.ExpectedEndpoints(region.TotalEndpoints)
 		// deviation: a negative value means need more endpoints from other
 		// sliceGroups, a positive value means need give out endpoints to other
 		// sliceGroups
@@ -76,10 +86,16 @@ func (alg LocalSliceAlgorithmOpt) CreateSliceGroups(region types.RegionInfo) (ma
 		sliceGroups[zoneName] = localGroup
 	}
 
+	// zones with the largest deviation give and receive endpoints first
+	endpointsAvailable.SortByDeviationDescending()
+	endpointsNeeded.SortByDeviationDescending()
 	err := alg.balanceSliceGroups(region, &endpointsAvailable, &endpointsNeeded, sliceGroups)
 	if err != nil {
 		return nil, err
 	}
+	if err := AssertEndpointConservation(region, sliceGroups); err != nil {
+		return nil, err
+	}
 	return sliceGroups, nil
 }
 
@@ -109,8 +125,8 @@ func (alg LocalSliceAlgorithmOpt) balanceSliceGroups(region types.RegionInfo, en
 			Composition:        map[string]types.WeightedEndpoints{},
 			ZoneTrafficWeights: map[string]float64{},
 		}
-		for zone := range region.ZoneDetails {
-			globalSG.ZoneTrafficWeights[zone] = 1 / float64(len(region.ZoneDetails))
+		for zoneName, zone := range region.ZoneDetails {
+			globalSG.ZoneTrafficWeights[zoneName] = alg.globalSGWeight(zone, region)
 		}
 		for _, extraEndpoints := range endpointsAvailable.byZone {
 			globalSG.Composition[extraEndpoints.name] = types.WeightedEndpoints{Number: extraEndpoints.deviation, Weight: 1.0}
@@ -120,3 +136,14 @@ func (alg LocalSliceAlgorithmOpt) balanceSliceGroups(region types.RegionInfo, en
 	}
 	return nil
 }
+
+// globalSGWeight returns the weight of traffic from the zone to the global SG
+func (alg LocalSliceAlgorithmOpt) globalSGWeight(zone types.Zone, region types.RegionInfo) float64 {
+	if alg.GlobalSGWeight > 0 {
+		return alg.GlobalSGWeight
+	}
+	if alg.GlobalSGWeightByNodes {
+		return zone.NodesRatio
+	}
+	return 1 / float64(len(region.ZoneDetails))
+}
@@ -215,3 +215,144 @@ func TestLocalAlgorithmOpt(t *testing.T) {
 	}
 	localTest.doTest(t)
 }
+
+func TestLocalAlgorithmOptGlobalSGWeight(t *testing.T) {
+	input := []types.Zone{
+		types.Zone{Nodes: 1, Endpoints: 10, Name: "ZoneA"},
+		types.Zone{Nodes: 2, Endpoints: 5, Name: "ZoneB"},
+		types.Zone{Nodes: 3, Endpoints: 5, Name: "ZoneC"},
+	}
+	// ZoneA has one extra endpoint after the approximation, which goes to the
+	// global SG
+	expectedOutput := func(globalWeights map[string]float64) map[string]types.EndpointSliceGroup {
+		return map[string]types.EndpointSliceGroup{
+			"ZoneA": types.EndpointSliceGroup{
+				Label:              "ZoneA",
+				Composition:        map[string]types.WeightedEndpoints{"ZoneA": {Number: 3, Weight: 1}},
+				ZoneTrafficWeights: map[string]float64{"ZoneA": 1},
+			},
+			"ZoneB": types.EndpointSliceGroup{
+				Label:              "ZoneB",
+				Composition:        map[string]types.WeightedEndpoints{"ZoneA": {Number: 1, Weight: 1}, "ZoneB": {Number: 5, Weight: 1}},
+				ZoneTrafficWeights: map[string]float64{"ZoneB": 1},
+			},
+			"ZoneC": types.EndpointSliceGroup{
+				Label:              "ZoneC",
+				Composition:        map[string]types.WeightedEndpoints{"ZoneA": {Number: 5, Weight: 1}, "ZoneC": {Number: 5, Weight: 1}},
+				ZoneTrafficWeights: map[string]float64{"ZoneC": 1},
+			},
+			"global": types.EndpointSliceGroup{
+				Label:              "global",
+				Composition:        map[string]types.WeightedEndpoints{"ZoneA": {Number: 1, Weight: 1}},
+				ZoneTrafficWeights: globalWeights,
+			},
+		}
+	}
+	tests := []routingAlgorithmTest{
+		{
+			algName: "LocalSliceOptUniform",
+			alg:     LocalSliceAlgorithmOpt{},
+			testCases: []algTestCase{{
+				name:           "uniform global SG weight",
+				input:          input,
+				expectedOutput: expectedOutput(map[string]float64{"ZoneA": 1.0 / 3, "ZoneB": 1.0 / 3, "ZoneC": 1.0 / 3}),
+			}},
+		},
+		{
+			algName: "LocalSliceOptByNodes",
+			alg:     LocalSliceAlgorithmOpt{GlobalSGWeightByNodes: true},
+			testCases: []algTestCase{{
+				name:           "global SG weight by nodes",
+				input:          input,
+				expectedOutput: expectedOutput(map[string]float64{"ZoneA": 1.0 / 6, "ZoneB": 2.0 / 6, "ZoneC": 3.0 / 6}),
+			}},
+		},
+		{
+			algName: "LocalSliceOptConstant",
+			alg:     LocalSliceAlgorithmOpt{GlobalSGWeightByNodes: true, GlobalSGWeight: 0.2},
+			testCases: []algTestCase{{
+				name:           "constant global SG weight takes precedence",
+				input:          input,
+				expectedOutput: expectedOutput(map[string]float64{"ZoneA": 0.2, "ZoneB": 0.2, "ZoneC": 0.2}),
+			}},
+		},
+	}
+	for _, test := range tests {
+		test.doTest(t)
+	}
+}
+
+func TestLocalAlgorithmOptGlobalSGComposition(t *testing.T) {
+	testCases := []struct {
+		name         string
+		input        []types.Zone
+		expectGlobal bool
+	}{
+		{
+			name: "balanced region",
+			input: []types.Zone{
+				types.Zone{Nodes: 10, Endpoints: 20, Name: "ZoneA"},
+				types.Zone{Nodes: 20, Endpoints: 40, Name: "ZoneB"},
+				types.Zone{Nodes: 30, Endpoints: 60, Name: "ZoneC"},
+			},
+			expectGlobal: false,
+		},
+		{
+			name: "imbalanced region",
+			input: []types.Zone{
+				types.Zone{Nodes: 10, Endpoints: 15, Name: "ZoneA"},
+				types.Zone{Nodes: 10, Endpoints: 8, Name: "ZoneB"},
+				types.Zone{Nodes: 10, Endpoints: 8, Name: "ZoneC"},
+			},
+			expectGlobal: true,
+		},
+		{
+			// ZoneA expects 10.5 endpoints
+			name: "zone with 10x expected endpoints",
+			input: []types.Zone{
+				types.Zone{Nodes: 1, Endpoints: 100, Name: "ZoneA"},
+				types.Zone{Nodes: 9, Endpoints: 40, Name: "ZoneB"},
+				types.Zone{Nodes: 10, Endpoints: 70, Name: "ZoneC"},
+			},
+			expectGlobal: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			region, err := types.CreateRegionInfo(tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error creating region: %v", err)
+			}
+			sliceGroups, err := LocalSliceAlgorithmOpt{}.CreateSliceGroups(region)
+			if err != nil {
+				t.Fatalf("unexpected error creating sliceGroups: %v", err)
+			}
+			globalSG, ok := sliceGroups["global"]
+			if ok != tc.expectGlobal {
+				t.Fatalf("expected a global SG %v, got %v", tc.expectGlobal, sliceGroups)
+			}
+			// leftover endpoints not assigned to any local SG
+			leftover := region.TotalEndpoints
+			for _, zone := range region.ZoneNames() {
+				leftover -= sliceGroups[zone].NumberOfEndpoints()
+			}
+			if globalSG.NumberOfEndpoints() != leftover {
+				t.Errorf("expected %d leftover endpoints in the global SG, got %d", leftover, globalSG.NumberOfEndpoints())
+			}
+			if !ok {
+				return
+			}
+			weightSum := 0.0
+			for _, zone := range region.ZoneNames() {
+				weight, found := globalSG.ZoneTrafficWeights[zone]
+				if !found || !compareFloat(weight, 1/float64(len(region.ZoneDetails)), 1e-9) {
+					t.Errorf("expected global SG weight 1/%d of %s, got %v", len(region.ZoneDetails), zone, weight)
+				}
+				weightSum += weight
+			}
+			if !compareFloat(weightSum, 1, 1e-9) {
+				t.Errorf("expected global SG weights to sum up to 1, got %v", weightSum)
+			}
+		})
+	}
+}
@@ -19,9 +19,9 @@ package algorithm
 import (
 	"container/heap"
 	"fmt"
+	"sync"
 
 	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
-	"k8s.io/klog/v2"
 )
 
 // LocalSliceAlgorithm 'borrows' and 'rents' endpoints from other zones to make
@@ -35,16 +35,57 @@ import (
 // 3. EndpointSlices in zones with less endpoints than expected will receive
 // endpoints from zones that have more endpoints than expected.
 type LocalSliceAlgorithm struct {
-	threshold         float64
+	// mu guards threshold against updates while slice groups are created
+	mu        sync.RWMutex
+	threshold float64
+	// startingThreshold is the number of endpoints per zone below which the
+	// algorithm falls back to OriginalAlgorithm, 0 disables the fallback
 	startingThreshold int
+	// bandwidthAware scales threshold of zones by their bandwidth
+	bandwidthAware bool
+	// DisableRebalancePass skips the second pass of balancing, which moves
+	// extra endpoints to zones below their expected number of endpoints to
+	// reduce mean deviation at the cost of in-zone traffic
+	DisableRebalancePass bool
+}
+
+// WithBandwidthAwareThreshold returns a copy of the algorithm which scales the
+// deviation threshold of zones with a known bandwidth by BandwidthMbps /
+// average bandwidth
+func (alg *LocalSliceAlgorithm) WithBandwidthAwareThreshold() *LocalSliceAlgorithm {
+	return &LocalSliceAlgorithm{threshold: alg.Threshold(), startingThreshold: alg.startingThreshold, bandwidthAware: true, DisableRebalancePass: alg.DisableRebalancePass}
+}
+
+// Threshold returns the max deviation allowed for endpoints
+func (alg *LocalSliceAlgorithm) Threshold() float64 {
+	alg.mu.RLock()
+	defer alg.mu.RUnlock()
+	return alg.threshold
+}
+
+// SetThreshold updates the max deviation allowed for endpoints, it waits for
+// running CreateSliceGroups calls to finish
+func (alg *LocalSliceAlgorithm) SetThreshold(t float64) error {
+	if t <= 0 {
+		return fmt.Errorf("threshold %v should be positive", t)
+	}
+	alg.mu.Lock()
+	defer alg.mu.Unlock()
+	alg.threshold = t
+	return nil
 }
 
 // CreateSliceGroups creates sliceGroups with 'one local EndpointSliceGroup per
 // zone' policy
-func (alg LocalSliceAlgorithm) CreateSliceGroups(region types.RegionInfo) (map[string]types.EndpointSliceGroup, error) {
+func (alg *LocalSliceAlgorithm) CreateSliceGroups(region types.RegionInfo) (map[string]types.EndpointSliceGroup, error) {
+	alg.mu.RLock()
+	defer alg.mu.RUnlock()
 	if region.ZoneDetails == nil {
 		return nil, fmt.Errorf("zoneDetail should not be nil")
 	}
+	if region.TotalEndpoints == 0 {
+		return map[string]types.EndpointSliceGroup{}, nil
+	}
 	if region.TotalEndpoints < alg.startingThreshold*len(region.ZoneDetails) {
 		return OriginalAlgorithm{}.CreateSliceGroups(region)
 	}
@@ -70,7 +111,7 @@ func (alg LocalSliceAlgorithm) CreateSliceGroups(region types.RegionInfo) (map[s
 	}
 
 	// traverse the map by name order
-	zoneNames := sortZoneByNames(region.ZoneDetails)
+	zoneNames := region.ZoneNames()
 	for _, zoneName := range zoneNames {
 		zone := region.ZoneDetails[zoneName]
 		var localGroup types.EndpointSliceGroup
@@ -83,14 +124,17 @@ func (alg LocalSliceAlgorithm) CreateSliceGroups(region types.RegionInfo) (map[s
 		// endpoints from zoneName
 		localGroup.Composition = map[string]types.WeightedEndpoints{}
 
-		if zone.Endpoints != 0 {
+		// zones without endpoints have an empty local sliceGroup and never
+		// contribute endpoints
+		zeroEndpoints := region.HasZeroEndpoints(zoneName)
+		if !zeroEndpoints {
 			localGroup.Composition[zoneName] = types.WeightedEndpoints{Number: zone.Endpoints, Weight: 1}
 		}
 		sliceGroups[zoneName] = localGroup
 
 		// if this zone would still have a deviation below threshold after
 		// giving one endpoint out, it is a qualified contributor
-		if alg.validContributor(zoneName, region, sliceGroups) {
+		if !zeroEndpoints && alg.validContributor(zoneName, region, sliceGroups) {
 			availablePool.ZoneNames = append(availablePool.ZoneNames, zoneName)
 		}
 		// if this zone has a deviation above threshold, it needs extra
@@ -106,26 +150,41 @@ func (alg LocalSliceAlgorithm) CreateSliceGroups(region types.RegionInfo) (map[s
 		return nil, err
 	}
 	if !succ {
-		klog.Infof("failed to use local algorithm, switching to original algorithm %+v \n", region)
+		logger.Info("failed to use local algorithm, switching to original algorithm", "algorithm", "LocalSliceAlgorithm", "region", region.Summarize())
 		return OriginalAlgorithm{}.CreateSliceGroups(region)
 	}
+	if err := AssertEndpointConservation(region, sliceGroups); err != nil {
+		return nil, err
+	}
 	return sliceGroups, nil
 }
 
 // balanceSliceGroups distributes endpoints from zones with extra endpoints to
 // EndpointSliceGroups for zones with insufficient endpoints.
-func (alg LocalSliceAlgorithm) balanceSliceGroups(availablePool *ZonePriorityQueue, receiverPool *ZonePriorityQueue, zonePool *ZonePriorityQueue, region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup) (bool, error) {
+// Pools are usually ZonePriorityQueues.
+func (alg *LocalSliceAlgorithm) balanceSliceGroups(availablePool heap.Interface, receiverPool heap.Interface, zonePool heap.Interface, region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup) (bool, error) {
 	heap.Init(availablePool)
 	heap.Init(receiverPool)
+	// every iteration either moves one endpoint or finishes one receiver, more
+	// iterations mean a broken pool
+	iterations, maxIterations := 0, maxBalanceIterations(region)
 	// do a first round rebalance, this round aims to get all zones with
 	// deviation below threshold
 	for receiverPool.Len() > 0 {
+		iterations++
+		if iterations > maxIterations {
+			return false, errMaxIterations
+		}
 		// get the zone with most insufficient endpoints
 		receiver := heap.Pop(receiverPool).(string)
 		for availablePool.Len() > 0 {
 			if !alg.deviationAboveThreshold(receiver, region, sliceGroups, 0) {
 				break
 			}
+			iterations++
+			if iterations > maxIterations {
+				return false, errMaxIterations
+			}
 			// get the zone with most extra endpoints
 			candidate := heap.Pop(availablePool).(string)
 			// assign one endpoint from candidate to receiver
@@ -146,13 +205,16 @@ func (alg LocalSliceAlgorithm) balanceSliceGroups(availablePool *ZonePriorityQue
 	// rebalance endpoints to reduce mean deviation at the cost of in-zone
 	// traffic
 	// +optional
+	if alg.DisableRebalancePass {
+		return true, nil
+	}
 	heap.Init(zonePool)
 	for availablePool.Len() > 0 {
 		// get the zone with most extra endpoints
 		candidate := heap.Pop(availablePool).(string)
-		deviation, ok := getEndpointsDeviation(region, sliceGroups, candidate)
+		deviation, ok := CalculateDeviation(region, sliceGroups, candidate)
 		if !ok {
-			klog.Warningf("get deviation of %s failed", candidate)
+			logger.Warn("get deviation failed", "algorithm", "LocalSliceAlgorithm", "zone", candidate)
 			continue
 		}
 		// if this zone has less than 1 endpoint overflowed compared to expected
@@ -166,9 +228,9 @@ func (alg LocalSliceAlgorithm) balanceSliceGroups(availablePool *ZonePriorityQue
 			// zonePool will always be non-empty
 			// get the zone with most insufficient endpoints
 			receiver := heap.Pop(zonePool).(string)
-			receiverDeviation, ok := getEndpointsDeviation(region, sliceGroups, receiver)
+			receiverDeviation, ok := CalculateDeviation(region, sliceGroups, receiver)
 			if !ok {
-				klog.Warningf("get deviation of %s failed", receiver)
+				logger.Warn("get deviation failed", "algorithm", "LocalSliceAlgorithm", "zone", receiver)
 				continue
 			}
 			// if this zone has number of endpoints >= floor of expected number,
@@ -194,7 +256,7 @@ func (alg LocalSliceAlgorithm) balanceSliceGroups(availablePool *ZonePriorityQue
 }
 
 // detect whether a zone is valid to contribute endpoints to other zones
-func (alg LocalSliceAlgorithm) validContributor(zoneName string, region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup) bool {
+func (alg *LocalSliceAlgorithm) validContributor(zoneName string, region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup) bool {
 	// if the sliceGroup has no local composition, it is not a valid contributor
 	if len(sliceGroups[zoneName].Composition) == 0 || sliceGroups[zoneName].NumberOfEndpoints() <= 1 {
 		return false
@@ -208,8 +270,16 @@ func (alg LocalSliceAlgorithm) validContributor(zoneName string, region types.Re
 // threshold
 // positive delta: after receiving delta endpoints, if it is still above
 // threshold
-func (alg LocalSliceAlgorithm) deviationAboveThreshold(zone string, region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup, delta int) bool {
-	expectedEndpoints := float64(region.TotalEndpoints) * region.ZoneDetails[zone].NodesRatio
+func (alg *LocalSliceAlgorithm) deviationAboveThreshold(zone string, region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup, delta int) bool {
+	expectedEndpoints := region.ZoneDetails[zone].ExpectedEndpoints(region.TotalEndpoints)
 	trafficDeviation := expectedEndpoints/float64(sliceGroups[zone].NumberOfEndpoints()+delta) - 1
-	return trafficDeviation >= alg.threshold
+	return trafficDeviation >= alg.zoneThreshold(region, zone)
+}
+
+// zoneThreshold returns the deviation threshold of a zone
+func (alg *LocalSliceAlgorithm) zoneThreshold(region types.RegionInfo, zone string) float64 {
+	if !alg.bandwidthAware {
+		return alg.threshold
+	}
+	return effectiveThreshold(alg.threshold, region, zone)
 }
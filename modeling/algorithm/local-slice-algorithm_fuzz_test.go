@@ -0,0 +1,69 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// maxFuzzNumber bounds nodes and endpoints of fuzzed zones, since the local
+// algorithm moves endpoints one by one
+const maxFuzzNumber = 1000
+
+func FuzzLocalSliceAlgorithm(f *testing.F) {
+	// nodes and endpoints of three zones used by existing test cases
+	seeds := [][6]int{
+		{1, 5, 2, 20, 7, 20},
+		{1, 0, 1, 6, 1, 7},
+		{16, 5, 8, 1, 1, 0},
+		{30, 100, 30, 0, 30, 0},
+		{30, 1, 30, 0, 30, 0},
+		{1, 3, 2, 2, 2, 2},
+		{0, 0, 0, 0, 0, 0},
+		{-1, 5, 2, 20, 7, 20},
+	}
+	for _, seed := range seeds {
+		f.Add(seed[0], seed[1], seed[2], seed[3], seed[4], seed[5])
+	}
+	alg := NewAlgorithm("Local")
+	f.Fuzz(func(t *testing.T, nodesA, endpointsA, nodesB, endpointsB, nodesC, endpointsC int) {
+		for _, number := range []int{nodesA, endpointsA, nodesB, endpointsB, nodesC, endpointsC} {
+			if number > maxFuzzNumber {
+				t.Skip()
+			}
+		}
+		region, err := types.CreateRegionInfo([]types.Zone{
+			{Name: "ZoneA", Nodes: nodesA, Endpoints: endpointsA},
+			{Name: "ZoneB", Nodes: nodesB, Endpoints: endpointsB},
+			{Name: "ZoneC", Nodes: nodesC, Endpoints: endpointsC},
+		})
+		if err != nil {
+			// invalid inputs are rejected with an error rather than a panic
+			return
+		}
+		sliceGroups, err := alg.CreateSliceGroups(region)
+		if err != nil {
+			return
+		}
+		if sliceGroups == nil {
+			t.Fatalf("expected non-nil slice groups without an error for region %+v", region)
+		}
+		checkSliceGroupInvariants(t, region, sliceGroups)
+	})
+}
@@ -17,8 +17,13 @@ limitations under the License.
 package algorithm
 
 import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
 	"testing"
 
+	"github.com/googleinterns/k8s-topology-simulator/modeling/simulator"
 	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
 )
 
@@ -314,8 +319,343 @@ func TestLocalAlgorithm(t *testing.T) {
 	}
 	localTest := routingAlgorithmTest{
 		algName:   "LocalSlice",
-		alg:       LocalSliceAlgorithm{threshold: 0.5},
+		alg:       &LocalSliceAlgorithm{threshold: 0.5},
 		testCases: testCases,
 	}
 	localTest.doTest(t)
 }
+
+func TestLocalAlgorithmBandwidthAware(t *testing.T) {
+	input := []types.Zone{
+		types.Zone{Nodes: 3, Endpoints: 1, Name: "ZoneA", BandwidthMbps: 400},
+		types.Zone{Nodes: 2, Endpoints: 0, Name: "ZoneB", BandwidthMbps: 100},
+		types.Zone{Nodes: 1, Endpoints: 9, Name: "ZoneC", BandwidthMbps: 100},
+	}
+	localGroup := func(zone string, composition map[string]types.WeightedEndpoints) types.EndpointSliceGroup {
+		return types.EndpointSliceGroup{Label: zone, Composition: composition, ZoneTrafficWeights: map[string]float64{zone: 1}}
+	}
+	// ZoneA expects 5 endpoints and ZoneB expects 3.33 endpoints. With
+	// bandwidth awareness, high-bandwidth ZoneA tolerates a higher deviation
+	// and the low-bandwidth ZoneB gets the cross-zone endpoint instead.
+	tests := []routingAlgorithmTest{
+		{
+			algName: "LocalSlice",
+			alg:     &LocalSliceAlgorithm{threshold: 0.2, startingThreshold: 3},
+			testCases: []algTestCase{{
+				name:  "without bandwidth awareness",
+				input: input,
+				expectedOutput: map[string]types.EndpointSliceGroup{
+					"ZoneA": localGroup("ZoneA", map[string]types.WeightedEndpoints{"ZoneA": {Number: 1, Weight: 1}, "ZoneC": {Number: 4, Weight: 1}}),
+					"ZoneB": localGroup("ZoneB", map[string]types.WeightedEndpoints{"ZoneC": {Number: 3, Weight: 1}}),
+					"ZoneC": localGroup("ZoneC", map[string]types.WeightedEndpoints{"ZoneC": {Number: 2, Weight: 1}}),
+				},
+			}},
+		},
+		{
+			algName: "LocalSliceBandwidthAware",
+			alg:     (&LocalSliceAlgorithm{threshold: 0.2, startingThreshold: 3}).WithBandwidthAwareThreshold(),
+			testCases: []algTestCase{{
+				name:  "with bandwidth awareness",
+				input: input,
+				expectedOutput: map[string]types.EndpointSliceGroup{
+					"ZoneA": localGroup("ZoneA", map[string]types.WeightedEndpoints{"ZoneA": {Number: 1, Weight: 1}, "ZoneC": {Number: 3, Weight: 1}}),
+					"ZoneB": localGroup("ZoneB", map[string]types.WeightedEndpoints{"ZoneC": {Number: 4, Weight: 1}}),
+					"ZoneC": localGroup("ZoneC", map[string]types.WeightedEndpoints{"ZoneC": {Number: 2, Weight: 1}}),
+				},
+			}},
+		},
+	}
+	for _, test := range tests {
+		test.doTest(t)
+	}
+}
+
+// stuckQueue is a broken heap which always pops the same zone
+type stuckQueue struct {
+	zone string
+}
+
+func (q stuckQueue) Len() int           { return 1 }
+func (q stuckQueue) Less(i, j int) bool { return false }
+func (q stuckQueue) Swap(i, j int)      {}
+func (q stuckQueue) Push(x interface{}) {}
+func (q stuckQueue) Pop() interface{}   { return q.zone }
+
+func TestLocalAlgorithmMaxIterations(t *testing.T) {
+	region, err := types.CreateRegionInfo([]types.Zone{
+		types.Zone{Nodes: 1, Endpoints: 5, Name: "ZoneA"},
+		types.Zone{Nodes: 1, Endpoints: 5, Name: "ZoneB"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	sliceGroups := map[string]types.EndpointSliceGroup{}
+	for _, zone := range region.ZoneNames() {
+		sliceGroups[zone] = types.EndpointSliceGroup{Label: zone, Composition: map[string]types.WeightedEndpoints{zone: {Number: 5, Weight: 1}}, ZoneTrafficWeights: map[string]float64{zone: 1}}
+	}
+	alg := &LocalSliceAlgorithm{threshold: 0.5, startingThreshold: 3}
+	emptyPool := ZonePriorityQueue{Region: region, SliceGroups: sliceGroups}
+	succ, err := alg.balanceSliceGroups(&emptyPool, stuckQueue{zone: "ZoneA"}, &ZonePriorityQueue{Region: region, SliceGroups: sliceGroups}, region, sliceGroups)
+	if succ || err != errMaxIterations {
+		t.Errorf("expected error %v balancing with a stuck receiver pool, got %v, %v", errMaxIterations, succ, err)
+	}
+}
+
+func TestLocalAlgorithmSetThreshold(t *testing.T) {
+	alg := &LocalSliceAlgorithm{threshold: 0.5, startingThreshold: 3}
+	for _, threshold := range []float64{0, -0.5} {
+		if err := alg.SetThreshold(threshold); err == nil {
+			t.Errorf("expected error setting threshold %v", threshold)
+		}
+	}
+	if err := alg.SetThreshold(0.2); err != nil {
+		t.Fatalf("unexpected error setting threshold: %v", err)
+	}
+	if alg.Threshold() != 0.2 {
+		t.Errorf("expected threshold 0.2, got %v", alg.Threshold())
+	}
+}
+
+func TestLocalAlgorithmSetThresholdConcurrently(t *testing.T) {
+	region, err := types.CreateRegionInfo([]types.Zone{
+		types.Zone{Nodes: 1, Endpoints: 5, Name: "ZoneA"},
+		types.Zone{Nodes: 2, Endpoints: 20, Name: "ZoneB"},
+		types.Zone{Nodes: 7, Endpoints: 20, Name: "ZoneC"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	alg := &LocalSliceAlgorithm{threshold: 0.5, startingThreshold: 3}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := alg.SetThreshold(0.1 * float64(i+j%5+1)); err != nil {
+					t.Errorf("unexpected error setting threshold: %v", err)
+				}
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				sliceGroups, err := alg.CreateSliceGroups(region)
+				if err != nil {
+					t.Errorf("unexpected error creating sliceGroups: %v", err)
+					return
+				}
+				checkSliceGroupInvariants(t, region, sliceGroups)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLocalAlgorithmManyZones(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	var zones []types.Zone
+	for i := 0; i < 50; i++ {
+		zones = append(zones, types.Zone{
+			Name:      fmt.Sprintf("zone%02d", i),
+			Nodes:     1 + random.Intn(50),
+			Endpoints: 1 + random.Intn(100),
+		})
+	}
+	region, err := types.CreateRegionInfo(zones)
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	originalSlices, err := OriginalAlgorithm{}.CreateSliceGroups(region)
+	if err != nil {
+		t.Fatalf("unexpected error creating sliceGroups of OriginalAlgorithm: %v", err)
+	}
+	original, err := simulator.TheoreticalSimulator{}.Simulate(region, originalSlices)
+	if err != nil {
+		t.Fatalf("unexpected error simulating OriginalAlgorithm: %v", err)
+	}
+
+	algs := map[string]RoutingAlgorithm{
+		"LocalSlice":       NewAlgorithm("Local"),
+		"LocalSharedSlice": NewAlgorithm("LocalShared"),
+	}
+	for name, alg := range algs {
+		t.Run(name, func(t *testing.T) {
+			sliceGroups, err := alg.CreateSliceGroups(region)
+			if err != nil {
+				t.Fatalf("unexpected error creating sliceGroups: %v", err)
+			}
+			checkSliceGroupInvariants(t, region, sliceGroups)
+			for label, sliceGroup := range sliceGroups {
+				for zone, weight := range sliceGroup.ZoneTrafficWeights {
+					if weight < 0 || weight > 1 {
+						t.Errorf("expected traffic weight in [0, 1], got %v from %s in %s", weight, zone, label)
+					}
+				}
+			}
+			result, err := simulator.TheoreticalSimulator{}.Simulate(region, sliceGroups)
+			if err != nil {
+				t.Fatalf("unexpected error simulating: %v", err)
+			}
+			if result.Invalid || result.MaxDeviation >= 2 {
+				t.Errorf("expected a valid result with max deviation below 2, got %+v", result)
+			}
+			// OriginalAlgorithm spreads traffic evenly over all endpoints, it
+			// has no deviation but keeps less traffic in zone
+			if original.MaxDeviation > result.MaxDeviation {
+				t.Errorf("expected max deviation %v of OriginalAlgorithm not to exceed %v", original.MaxDeviation, result.MaxDeviation)
+			}
+			if original.InZoneTraffic > result.InZoneTraffic {
+				t.Errorf("expected in-zone traffic %v of OriginalAlgorithm not to exceed %v", original.InZoneTraffic, result.InZoneTraffic)
+			}
+		})
+	}
+}
+
+func TestLocalAlgorithmDisableRebalancePass(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input []types.Zone
+		// rebalanced is true if the rebalance pass moves endpoints of the
+		// input
+		rebalanced bool
+	}{
+		{
+			// ZoneA has 2 endpoints more than expected, ZoneB 2 fewer but
+			// still below threshold
+			name: "extra endpoints below threshold",
+			input: []types.Zone{
+				{Name: "ZoneA", Nodes: 1, Endpoints: 10},
+				{Name: "ZoneB", Nodes: 1, Endpoints: 6},
+			},
+			rebalanced: true,
+		},
+		{
+			// ZoneA still has extra endpoints after giving ZoneB enough to
+			// get below threshold
+			name: "extra endpoints after the first pass",
+			input: []types.Zone{
+				{Name: "ZoneA", Nodes: 1, Endpoints: 30},
+				{Name: "ZoneB", Nodes: 2, Endpoints: 10},
+				{Name: "ZoneC", Nodes: 3, Endpoints: 26},
+			},
+			rebalanced: true,
+		},
+		{
+			name: "balanced zones",
+			input: []types.Zone{
+				{Name: "ZoneA", Nodes: 1, Endpoints: 10},
+				{Name: "ZoneB", Nodes: 1, Endpoints: 10},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			region, err := types.CreateRegionInfo(tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error creating region: %v", err)
+			}
+			var results []types.SimulationResult
+			var outputs []map[string]types.EndpointSliceGroup
+			for _, alg := range []RoutingAlgorithm{NewAlgorithm("Local"), NewAlgorithm("LocalSliceNoRebalance")} {
+				sliceGroups, err := alg.CreateSliceGroups(region)
+				if err != nil {
+					t.Fatalf("unexpected error creating sliceGroups: %v", err)
+				}
+				checkSliceGroupInvariants(t, region, sliceGroups)
+				result, err := simulator.TheoreticalSimulator{}.Simulate(region, sliceGroups)
+				if err != nil {
+					t.Fatalf("unexpected error simulating: %v", err)
+				}
+				results = append(results, result)
+				outputs = append(outputs, sliceGroups)
+			}
+			if results[1].InZoneTraffic < results[0].InZoneTraffic-1e-9 {
+				t.Errorf("expected in-zone traffic %v without rebalance pass to be at least %v", results[1].InZoneTraffic, results[0].InZoneTraffic)
+			}
+			if rebalanced := !reflect.DeepEqual(outputs[0], outputs[1]); rebalanced != tc.rebalanced {
+				t.Errorf("expected different sliceGroups with the rebalance pass %v, got %v and %v", tc.rebalanced, outputs[0], outputs[1])
+			}
+		})
+	}
+}
+
+func TestLocalAlgorithmDeterminism(t *testing.T) {
+	// ZoneA and ZoneB tie in the endpoints they need, ZoneC and ZoneD tie in
+	// the endpoints they have available
+	input := []types.Zone{
+		{Nodes: 4, Endpoints: 1, Name: "ZoneA"},
+		{Nodes: 4, Endpoints: 1, Name: "ZoneB"},
+		{Nodes: 1, Endpoints: 5, Name: "ZoneC"},
+		{Nodes: 1, Endpoints: 5, Name: "ZoneD"},
+		{Nodes: 2, Endpoints: 2, Name: "ZoneE"},
+	}
+	algs := map[string]RoutingAlgorithm{
+		"LocalSlice":    &LocalSliceAlgorithm{threshold: 0.5, startingThreshold: 1},
+		"LocalSliceOpt": LocalSliceAlgorithmOpt{},
+	}
+	random := rand.New(rand.NewSource(1))
+	for name, alg := range algs {
+		t.Run(name, func(t *testing.T) {
+			var first map[string]types.EndpointSliceGroup
+			for i := 0; i < 100; i++ {
+				zones := append([]types.Zone{}, input...)
+				random.Shuffle(len(zones), func(a, b int) {
+					zones[a], zones[b] = zones[b], zones[a]
+				})
+				region, err := types.CreateRegionInfo(zones)
+				if err != nil {
+					t.Fatalf("unexpected error creating region: %v", err)
+				}
+				sliceGroups, err := alg.CreateSliceGroups(region)
+				if err != nil {
+					t.Fatalf("unexpected error creating sliceGroups: %v", err)
+				}
+				if i == 0 {
+					first = sliceGroups
+					continue
+				}
+				if !reflect.DeepEqual(sliceGroups, first) {
+					t.Fatalf("run %d: expected the same sliceGroups %+v, got %+v", i, first, sliceGroups)
+				}
+			}
+		})
+	}
+}
+
+func TestLocalAlgorithmStartingThreshold(t *testing.T) {
+	if alg, ok := NewAlgorithm("Local").(*LocalSliceAlgorithm); !ok || alg.startingThreshold != 3 {
+		t.Fatalf("expected Local to have startingThreshold 3, got %+v", NewAlgorithm("Local"))
+	}
+	testCases := []struct {
+		name              string
+		startingThreshold int
+		endpoints         []int
+		fallback          bool
+	}{
+		{name: "below startingThreshold * zones", startingThreshold: 3, endpoints: []int{3, 3, 2}, fallback: true},
+		{name: "at startingThreshold * zones", startingThreshold: 3, endpoints: []int{3, 3, 3}},
+		{name: "above startingThreshold * zones", startingThreshold: 3, endpoints: []int{4, 3, 3}},
+		{name: "disabled startingThreshold", startingThreshold: 0, endpoints: []int{1, 1, 1}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var zones []types.Zone
+			for i, endpoints := range tc.endpoints {
+				zones = append(zones, types.Zone{Name: fmt.Sprintf("Zone%d", i), Nodes: 10, Endpoints: endpoints})
+			}
+			region, err := types.CreateRegionInfo(zones)
+			if err != nil {
+				t.Fatalf("unexpected error creating region: %v", err)
+			}
+			sliceGroups, err := (&LocalSliceAlgorithm{threshold: 0.5, startingThreshold: tc.startingThreshold}).CreateSliceGroups(region)
+			if err != nil {
+				t.Fatalf("unexpected error creating sliceGroups: %v", err)
+			}
+			_, global := sliceGroups["global"]
+			if fallback := global && len(sliceGroups) == 1; fallback != tc.fallback {
+				t.Errorf("expected fallback to OriginalAlgorithm %v, got sliceGroups %+v", tc.fallback, sliceGroups)
+			}
+		})
+	}
+}
@@ -18,10 +18,14 @@ package algorithm
 
 import (
 	"fmt"
+	"math"
 
 	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
 )
 
+// integerEpsilon is the max float error of a value deemed to be an integer
+const integerEpsilon = 1e-9
+
 // LocalWeightedSliceAlgorithm is a variation of LocalSliceAlgorithm which
 // 'borrows' and 'rents' endpoints from other zones to make the local
 // EndpointSlice balanced with the incoming traffic. This variation uses weights
@@ -36,6 +40,9 @@ func (alg LocalWeightedSliceAlgorithm) CreateSliceGroups(region types.RegionInfo
 	if region.ZoneDetails == nil {
 		return nil, fmt.Errorf("zoneDetail should not be nil")
 	}
+	if region.TotalEndpoints == 0 {
+		return map[string]types.EndpointSliceGroup{}, nil
+	}
 	sliceGroups := map[string]types.EndpointSliceGroup{}
 	// endpointsAvailable stores zones with int number of endpoints available
 	endpointsAvailable := endpointsList{}
@@ -48,7 +55,7 @@ func (alg LocalWeightedSliceAlgorithm) CreateSliceGroups(region types.RegionInfo
 	// needed
 	weightedEndpointsNeeded := endpointsList{}
 	// traverse the map by name order
-	zoneNames := sortZoneByNames(region.ZoneDetails)
+	zoneNames := region.ZoneNames()
 	for _, zoneName := range zoneNames {
 		zone := region.ZoneDetails[zoneName]
 		var localGroup types.EndpointSliceGroup
@@ -62,8 +69,9 @@ func (alg LocalWeightedSliceAlgorithm) CreateSliceGroups(region types.RegionInfo
 		localGroup.Composition = map[string]types.WeightedEndpoints{}
 
 		// calculate expected number of endpoints based on the proportion of
-		// nodes in this zone
-		expectedEndpoints := zone.NodesRatio * float64(region.TotalEndpoints)
+		// nodes in this zone, an expected number like 5.999999999999999 caused
+		// by float errors is taken as 6 so no endpoint is lost in truncation
+		expectedEndpoints := roundNearInteger(zone.ExpectedEndpoints(region.TotalEndpoints))
 		// deviation: a negative value means this zone needs more endpoints from
 		// other zones, a positive value means this zone needs to give out
 		// endpoints to other zones
@@ -104,7 +112,13 @@ func (alg LocalWeightedSliceAlgorithm) CreateSliceGroups(region types.RegionInfo
 	}
 
 	err := alg.balanceSliceGroups(&endpointsAvailable, &endpointsNeeded, &weightedEndpointsAvailable, &weightedEndpointsNeeded, sliceGroups)
-	return sliceGroups, err
+	if err != nil {
+		return sliceGroups, err
+	}
+	if err := AssertEndpointConservation(region, sliceGroups); err != nil {
+		return nil, err
+	}
+	return sliceGroups, nil
 }
 
 // balanceSliceGroups distributes endpoints from zones with extra endpoints to
@@ -115,7 +129,7 @@ func (alg LocalWeightedSliceAlgorithm) balanceSliceGroups(endpointsAvailable *en
 		// There are no more full endpoints available, but this zone still needs
 		// endpoints. Push the needed endpoints into a weighted list and deal
 		// with them as partial endpoints.
-		if len(endpointsAvailable.byZone) == 0 {
+		if endpointsAvailable.Total() == 0 {
 			receiveZone.weight = 1
 			weightedEndpointsNeeded.push(receiveZone)
 			endpointsNeeded.pop()
@@ -192,8 +206,22 @@ func (alg LocalWeightedSliceAlgorithm) balanceSliceGroups(endpointsAvailable *en
 				weightedEndpointsNeeded.pop()
 			}
 		}
+		// shared sliceGroups with the same receiving zones, or none of them,
+		// are told apart by the zone contributing the endpoints
+		if _, ok := sliceGroups[sharedSlice.Label]; ok {
+			sharedSlice.Label += "-from-" + extraEndpoints.name
+		}
 		sliceGroups[sharedSlice.Label] = sharedSlice
 		weightedEndpointsAvailable.pop()
 	}
 	return nil
 }
+
+// roundNearInteger rounds value to the nearest integer if it's within
+// integerEpsilon of it, and returns value otherwise
+func roundNearInteger(value float64) float64 {
+	if rounded := math.Round(value); math.Abs(value-rounded) < integerEpsilon {
+		return rounded
+	}
+	return value
+}
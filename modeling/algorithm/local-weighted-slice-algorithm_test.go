@@ -224,3 +224,168 @@ func TestLocalWeightedAlgorithm(t *testing.T) {
 	}
 	localTest.doTest(t)
 }
+
+func TestLocalWeightedAlgorithmFloatPrecision(t *testing.T) {
+	localGroup := func(zone string, composition map[string]types.WeightedEndpoints) types.EndpointSliceGroup {
+		return types.EndpointSliceGroup{Label: zone, Composition: composition, ZoneTrafficWeights: map[string]float64{zone: 1}}
+	}
+	testCases := []algTestCase{
+		{
+			name: "all zones equal",
+			input: []types.Zone{
+				types.Zone{Nodes: 10, Endpoints: 10, Name: "ZoneA"},
+				types.Zone{Nodes: 10, Endpoints: 10, Name: "ZoneB"},
+				types.Zone{Nodes: 10, Endpoints: 10, Name: "ZoneC"},
+			},
+			expectedOutput: map[string]types.EndpointSliceGroup{
+				"ZoneA": localGroup("ZoneA", map[string]types.WeightedEndpoints{"ZoneA": {Number: 10, Weight: 1}}),
+				"ZoneB": localGroup("ZoneB", map[string]types.WeightedEndpoints{"ZoneB": {Number: 10, Weight: 1}}),
+				"ZoneC": localGroup("ZoneC", map[string]types.WeightedEndpoints{"ZoneC": {Number: 10, Weight: 1}}),
+			},
+		},
+		{
+			// ZoneB expects 1997.006 endpoints and the other zones 1.997, so
+			// the decimal parts are shared through two tiny weights
+			name: "extreme endpoints ratio",
+			input: []types.Zone{
+				types.Zone{Nodes: 1, Endpoints: 1000, Name: "ZoneA"},
+				types.Zone{Nodes: 1000, Endpoints: 1, Name: "ZoneB"},
+				types.Zone{Nodes: 1, Endpoints: 1000, Name: "ZoneC"},
+			},
+			expectedOutput: map[string]types.EndpointSliceGroup{
+				"ZoneA": localGroup("ZoneA", map[string]types.WeightedEndpoints{"ZoneA": {Number: 1, Weight: 1}}),
+				"ZoneB": localGroup("ZoneB", map[string]types.WeightedEndpoints{"ZoneA": {Number: 998, Weight: 1}, "ZoneB": {Number: 1, Weight: 1}, "ZoneC": {Number: 998, Weight: 1}}),
+				"ZoneC": localGroup("ZoneC", map[string]types.WeightedEndpoints{"ZoneC": {Number: 1, Weight: 1}}),
+				"shared-ZoneA-ZoneB": types.EndpointSliceGroup{
+					Label:              "shared-ZoneA-ZoneB",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneA": {Number: 1, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneA": 0.997006, "ZoneB": 0.002994},
+				},
+				"shared-ZoneC-ZoneB": types.EndpointSliceGroup{
+					Label:              "shared-ZoneC-ZoneB",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneC": {Number: 1, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneB": 0.002994, "ZoneC": 0.997006},
+				},
+			},
+		},
+		{
+			// expected endpoints are 2, 2 and 4 with zero decimal deviation
+			name: "integer expected endpoints",
+			input: []types.Zone{
+				types.Zone{Nodes: 1, Endpoints: 4, Name: "ZoneA"},
+				types.Zone{Nodes: 1, Endpoints: 0, Name: "ZoneB"},
+				types.Zone{Nodes: 2, Endpoints: 4, Name: "ZoneC"},
+			},
+			expectedOutput: map[string]types.EndpointSliceGroup{
+				"ZoneA": localGroup("ZoneA", map[string]types.WeightedEndpoints{"ZoneA": {Number: 2, Weight: 1}}),
+				"ZoneB": localGroup("ZoneB", map[string]types.WeightedEndpoints{"ZoneA": {Number: 2, Weight: 1}}),
+				"ZoneC": localGroup("ZoneC", map[string]types.WeightedEndpoints{"ZoneC": {Number: 4, Weight: 1}}),
+			},
+		},
+		{
+			// ZoneC expects 27.000000000000004 endpoints, the decimal deviation
+			// of -3.5e-15 must not create a shared slice group
+			name: "decimal deviation slightly below zero",
+			input: []types.Zone{
+				types.Zone{Nodes: 1, Endpoints: 16, Name: "ZoneA"},
+				types.Zone{Nodes: 4, Endpoints: 19, Name: "ZoneB"},
+				types.Zone{Nodes: 9, Endpoints: 7, Name: "ZoneC"},
+			},
+			expectedOutput: map[string]types.EndpointSliceGroup{
+				"ZoneA": localGroup("ZoneA", map[string]types.WeightedEndpoints{"ZoneA": {Number: 3, Weight: 1}}),
+				"ZoneB": localGroup("ZoneB", map[string]types.WeightedEndpoints{"ZoneB": {Number: 12, Weight: 1}}),
+				"ZoneC": localGroup("ZoneC", map[string]types.WeightedEndpoints{"ZoneA": {Number: 13, Weight: 1}, "ZoneB": {Number: 7, Weight: 1}, "ZoneC": {Number: 7, Weight: 1}}),
+			},
+		},
+	}
+	localTest := routingAlgorithmTest{
+		algName:   "LocalWeightedSlice",
+		alg:       LocalWeightedSliceAlgorithm{},
+		testCases: testCases,
+	}
+	localTest.doTest(t)
+
+	for _, tc := range testCases {
+		region, err := types.CreateRegionInfo(tc.input)
+		if err != nil {
+			t.Fatalf("unexpected error creating region: %v", err)
+		}
+		sliceGroups, err := localTest.alg.CreateSliceGroups(region)
+		if err != nil {
+			t.Fatalf("unexpected error creating sliceGroups: %v", err)
+		}
+		for label, sliceGroup := range sliceGroups {
+			if err := sliceGroup.Validate(); err != nil {
+				t.Errorf("[%s] expected valid slice group %s, got %v", tc.name, label, err)
+			}
+		}
+	}
+}
+
+func TestLocalWeightedSliceAlgorithmInvariant(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input []types.Zone
+	}{
+		{
+			name:  "unbalanced nodes distribution",
+			input: []types.Zone{{Name: "ZoneA", Nodes: 1, Endpoints: 5}, {Name: "ZoneB", Nodes: 2, Endpoints: 20}, {Name: "ZoneC", Nodes: 7, Endpoints: 20}},
+		},
+		{
+			name:  "zero endpoints",
+			input: []types.Zone{{Name: "ZoneA", Nodes: 1, Endpoints: 0}, {Name: "ZoneB", Nodes: 1, Endpoints: 6}, {Name: "ZoneC", Nodes: 1, Endpoints: 7}},
+		},
+		{
+			name:  "give out more endpoints",
+			input: []types.Zone{{Name: "ZoneA", Nodes: 16, Endpoints: 5}, {Name: "ZoneB", Nodes: 8, Endpoints: 1}, {Name: "ZoneC", Nodes: 1, Endpoints: 0}},
+		},
+		{
+			name:  "2 zones with no endpoints",
+			input: []types.Zone{{Name: "ZoneA", Nodes: 30, Endpoints: 100}, {Name: "ZoneB", Nodes: 30, Endpoints: 0}, {Name: "ZoneC", Nodes: 30, Endpoints: 0}},
+		},
+		{
+			name:  "only 1 endpoint",
+			input: []types.Zone{{Name: "ZoneA", Nodes: 30, Endpoints: 1}, {Name: "ZoneB", Nodes: 30, Endpoints: 0}, {Name: "ZoneC", Nodes: 30, Endpoints: 0}},
+		},
+		{
+			name:  "mostly balanced small",
+			input: []types.Zone{{Name: "ZoneA", Nodes: 1, Endpoints: 3}, {Name: "ZoneB", Nodes: 2, Endpoints: 2}, {Name: "ZoneC", Nodes: 2, Endpoints: 2}},
+		},
+		{
+			name:  "balanced",
+			input: []types.Zone{{Name: "ZoneA", Nodes: 10, Endpoints: 10}, {Name: "ZoneB", Nodes: 10, Endpoints: 10}, {Name: "ZoneC", Nodes: 10, Endpoints: 10}},
+		},
+		{
+			// 94 * 3 / 47 is computed as 5.999999999999999
+			name:  "expected endpoints with float errors",
+			input: []types.Zone{{Name: "ZoneA", Nodes: 3, Endpoints: 37}, {Name: "ZoneB", Nodes: 15, Endpoints: 25}, {Name: "ZoneC", Nodes: 17, Endpoints: 22}, {Name: "ZoneD", Nodes: 12, Endpoints: 10}},
+		},
+		{
+			name:  "zones without nodes",
+			input: []types.Zone{{Name: "ZoneA", Nodes: 0, Endpoints: 46}, {Name: "ZoneB", Nodes: 0, Endpoints: 5}},
+		},
+		{
+			name:  "5 zones",
+			input: []types.Zone{{Name: "ZoneA", Nodes: 7, Endpoints: 13}, {Name: "ZoneB", Nodes: 3, Endpoints: 29}, {Name: "ZoneC", Nodes: 11, Endpoints: 4}, {Name: "ZoneD", Nodes: 5, Endpoints: 17}, {Name: "ZoneE", Nodes: 1, Endpoints: 0}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			region, err := types.CreateRegionInfo(tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error creating region: %v", err)
+			}
+			sliceGroups, err := LocalWeightedSliceAlgorithm{}.CreateSliceGroups(region)
+			if err != nil {
+				t.Fatalf("unexpected error creating sliceGroups: %v", err)
+			}
+			total := 0.0
+			for _, sliceGroup := range sliceGroups {
+				total += sliceGroup.NumberOfWeightedEndpoints()
+			}
+			if !compareFloat(total, float64(region.TotalEndpoints), 1e-9) {
+				t.Errorf("expected %d weighted endpoints, got %v: %+v", region.TotalEndpoints, total, sliceGroups)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import "log/slog"
+
+// logger is the structured logger of the package, it writes text logs with the
+// default slog handler unless replaced by SetLogger
+var logger = slog.Default()
+
+// SetLogger replaces the logger of the package, i.e. with a JSON handler for log
+// aggregators
+func SetLogger(l *slog.Logger) {
+	logger = l
+}
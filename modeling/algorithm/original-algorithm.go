@@ -26,18 +26,30 @@ import (
 // servie, serving as a benchmark to evaluate other algorithms
 type OriginalAlgorithm struct{}
 
-// CreateSliceGroups puts all endpoints into a global EndpointSliceGroup
+// CreateSliceGroups puts all endpoints into a global EndpointSliceGroup, zones
+// without endpoints are left out of its composition
 func (alg OriginalAlgorithm) CreateSliceGroups(region types.RegionInfo) (map[string]types.EndpointSliceGroup, error) {
 	if region.ZoneDetails == nil {
 		return nil, fmt.Errorf("zoneDetail should not be nil")
 	}
+	if region.TotalEndpoints == 0 {
+		return map[string]types.EndpointSliceGroup{}, nil
+	}
 	globalSG := types.EndpointSliceGroup{Label: "global",
 		Composition:        map[string]types.WeightedEndpoints{},
 		ZoneTrafficWeights: map[string]float64{},
 	}
 	for zoneName, zone := range region.ZoneDetails {
 		globalSG.ZoneTrafficWeights[zoneName] = 1.0
-		globalSG.Composition[zoneName] = types.WeightedEndpoints{Number: zone.Endpoints, Weight: 1.0}
+		// zones without endpoints still send traffic but aren't part of the
+		// composition
+		if zone.Endpoints > 0 {
+			globalSG.Composition[zoneName] = types.WeightedEndpoints{Number: zone.Endpoints, Weight: 1.0}
+		}
+	}
+	sliceGroups := map[string]types.EndpointSliceGroup{"global": globalSG}
+	if err := AssertEndpointConservation(region, sliceGroups); err != nil {
+		return nil, err
 	}
-	return map[string]types.EndpointSliceGroup{"global": globalSG}, nil
+	return sliceGroups, nil
 }
@@ -0,0 +1,67 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+func TestOriginalAlgorithm(t *testing.T) {
+	testCases := []algTestCase{
+		{
+			name: "all zones with endpoints",
+			input: []types.Zone{
+				types.Zone{Nodes: 1, Endpoints: 5, Name: "ZoneA"},
+				types.Zone{Nodes: 2, Endpoints: 20, Name: "ZoneB"},
+			},
+			expectedOutput: map[string]types.EndpointSliceGroup{
+				"global": types.EndpointSliceGroup{
+					Label: "global",
+					Composition: map[string]types.WeightedEndpoints{
+						"ZoneA": types.WeightedEndpoints{Number: 5, Weight: 1},
+						"ZoneB": types.WeightedEndpoints{Number: 20, Weight: 1},
+					},
+					ZoneTrafficWeights: map[string]float64{"ZoneA": 1, "ZoneB": 1},
+				},
+			},
+		},
+		{
+			name: "zone without endpoints",
+			input: []types.Zone{
+				types.Zone{Nodes: 1, Endpoints: 5, Name: "ZoneA"},
+				types.Zone{Nodes: 2, Endpoints: 0, Name: "ZoneB"},
+			},
+			expectedOutput: map[string]types.EndpointSliceGroup{
+				"global": types.EndpointSliceGroup{
+					Label: "global",
+					Composition: map[string]types.WeightedEndpoints{
+						"ZoneA": types.WeightedEndpoints{Number: 5, Weight: 1},
+					},
+					ZoneTrafficWeights: map[string]float64{"ZoneA": 1, "ZoneB": 1},
+				},
+			},
+		},
+	}
+	originalTest := routingAlgorithmTest{
+		algName:   "Original",
+		alg:       OriginalAlgorithm{},
+		testCases: testCases,
+	}
+	originalTest.doTest(t)
+}
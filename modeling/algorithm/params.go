@@ -0,0 +1,143 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParamSpec describes the valid range of an algorithm parameter
+type ParamSpec struct {
+	// Min and Max are the bounds of the parameter, both are inclusive unless
+	// MinExclusive is set
+	Min float64
+	Max float64
+	// MinExclusive excludes Min from the valid range
+	MinExclusive bool
+	// Integer parameters don't accept fractional values
+	Integer bool
+	// Description of the parameter
+	Description string
+}
+
+var (
+	thresholdSpec = ParamSpec{Min: 0, Max: 10, MinExclusive: true,
+		Description: "deviation threshold of traffic load a zone may exceed before receiving endpoints from other zones"}
+	startingThresholdSpec = ParamSpec{Min: 0, Max: 100, Integer: true,
+		Description: "number of endpoints per zone below which all endpoints stay in one global sliceGroup, 0 disables it"}
+	globalWeightSpec = ParamSpec{Min: 0, Max: 1,
+		Description: "weight of traffic from every zone to the global sliceGroup"}
+	globalThresholdSpec = ParamSpec{Min: 1, Max: 10000, Integer: true,
+		Description: "number of endpoints up to which all endpoints stay in the global sliceGroup"}
+)
+
+// AlgorithmParamSpec lists the parameters NewAlgorithmWithParams accepts, by
+// canonical algorithm name and parameter name. Algorithms not listed have no
+// parameters.
+var AlgorithmParamSpec = map[string]map[string]ParamSpec{
+	"Local":           {"threshold": thresholdSpec, "startingThreshold": startingThresholdSpec},
+	"LocalShared":     {"threshold": thresholdSpec},
+	"LatencyAware":    {"threshold": thresholdSpec, "startingThreshold": startingThresholdSpec},
+	"SharedGlobal":    {"globalWeight": globalWeightSpec, "globalThreshold": globalThresholdSpec},
+	"SharedMultiZone": {"globalWeight": globalWeightSpec, "globalThreshold": globalThresholdSpec},
+}
+
+// Validate returns an error if value of the parameter name is out of the range
+// of the spec
+func (spec ParamSpec) Validate(name string, value float64) error {
+	lower := "["
+	if spec.MinExclusive {
+		lower = "("
+	}
+	if math.IsNaN(value) || value < spec.Min || (spec.MinExclusive && value == spec.Min) || value > spec.Max {
+		return fmt.Errorf("%s %v is out of valid range %s%v, %v]", name, value, lower, spec.Min, spec.Max)
+	}
+	if spec.Integer && value != math.Trunc(value) {
+		return fmt.Errorf("%s %v should be an integer", name, value)
+	}
+	return nil
+}
+
+// parseParams parses and validates params of the algorithm name against its
+// AlgorithmParamSpec
+func parseParams(name string, params map[string]string) (map[string]float64, error) {
+	specs := AlgorithmParamSpec[strings.TrimSuffix(name, "Algorithm")]
+	values := map[string]float64{}
+	for key, raw := range params {
+		spec, ok := specs[key]
+		if !ok {
+			return nil, fmt.Errorf("unknown parameter %s of algorithm %s", key, name)
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q of algorithm %s: %v", key, raw, name, err)
+		}
+		if err := spec.Validate(key, value); err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// paramAlgorithm creates the algorithm of name with its default parameters
+// replaced by values, without logging
+func paramAlgorithm(name string, values map[string]float64) RoutingAlgorithm {
+	switch strings.TrimSuffix(name, "Algorithm") {
+	case "Local":
+		return localAlgorithmWithParams(values)
+	case "LocalShared":
+		return &LocalSharedSliceAlgorithm{threshold: paramOrDefault(values, "threshold", defaultThreshold)}
+	case "LatencyAware":
+		return LatencyAwareAlgorithm{localAlgorithm: localAlgorithmWithParams(values)}
+	case "SharedGlobal":
+		return SharedGlobalAlgorithm{sharedCoreAlgorithm: sharedCoreWithParams(values, defaultSharedGlobalWeight)}
+	case "SharedMultiZone":
+		return SharedMultiZoneAlgorithm{sharedCoreAlgorithm: sharedCoreWithParams(values, defaultSharedMultiZoneWeight)}
+	}
+	return NewAlgorithm(name)
+}
+
+// localAlgorithmWithParams creates a LocalSliceAlgorithm with the threshold and
+// startingThreshold of values
+func localAlgorithmWithParams(values map[string]float64) *LocalSliceAlgorithm {
+	return &LocalSliceAlgorithm{
+		threshold:         paramOrDefault(values, "threshold", defaultThreshold),
+		startingThreshold: int(paramOrDefault(values, "startingThreshold", defaultStartingThreshold)),
+	}
+}
+
+// sharedCoreWithParams creates a sharedGlobalAlgorithmCore with the
+// globalWeight and globalThreshold of values
+func sharedCoreWithParams(values map[string]float64, defaultGlobalWeight float64) sharedGlobalAlgorithmCore {
+	return sharedGlobalAlgorithmCore{
+		globalWeight:    paramOrDefault(values, "globalWeight", defaultGlobalWeight),
+		globalThreshold: int(paramOrDefault(values, "globalThreshold", defaultGlobalThreshold)),
+	}
+}
+
+// paramOrDefault returns the value of the parameter key, defaultValue if it's
+// not set
+func paramOrDefault(values map[string]float64, key string, defaultValue float64) float64 {
+	if value, ok := values[key]; ok {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParamSpecValidate(t *testing.T) {
+	testCases := []struct {
+		name        string
+		param       string
+		value       float64
+		expectedErr string
+	}{
+		{name: "threshold at exclusive min", param: "threshold", value: 0, expectedErr: "threshold 0 is out of valid range (0, 10]"},
+		{name: "threshold above min", param: "threshold", value: 0.001},
+		{name: "threshold at max", param: "threshold", value: 10},
+		{name: "threshold above max", param: "threshold", value: 10.001, expectedErr: "threshold 10.001 is out of valid range (0, 10]"},
+		{name: "globalWeight at min", param: "globalWeight", value: 0},
+		{name: "globalWeight below min", param: "globalWeight", value: -0.1, expectedErr: "globalWeight -0.1 is out of valid range [0, 1]"},
+		{name: "globalWeight at max", param: "globalWeight", value: 1},
+		{name: "globalWeight above max", param: "globalWeight", value: 1.1, expectedErr: "globalWeight 1.1 is out of valid range [0, 1]"},
+		{name: "globalThreshold at min", param: "globalThreshold", value: 1},
+		{name: "globalThreshold below min", param: "globalThreshold", value: 0, expectedErr: "globalThreshold 0 is out of valid range [1, 10000]"},
+		{name: "globalThreshold at max", param: "globalThreshold", value: 10000},
+		{name: "globalThreshold above max", param: "globalThreshold", value: 10001, expectedErr: "globalThreshold 10001 is out of valid range [1, 10000]"},
+		{name: "fractional globalThreshold", param: "globalThreshold", value: 10.5, expectedErr: "globalThreshold 10.5 should be an integer"},
+		{name: "startingThreshold at min", param: "startingThreshold", value: 0},
+		{name: "startingThreshold below min", param: "startingThreshold", value: -1, expectedErr: "startingThreshold -1 is out of valid range [0, 100]"},
+		{name: "startingThreshold at max", param: "startingThreshold", value: 100},
+		{name: "startingThreshold above max", param: "startingThreshold", value: 101, expectedErr: "startingThreshold 101 is out of valid range [0, 100]"},
+	}
+	specs := map[string]ParamSpec{}
+	for _, algSpecs := range AlgorithmParamSpec {
+		for param, spec := range algSpecs {
+			specs[param] = spec
+		}
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			spec, ok := specs[tc.param]
+			if !ok {
+				t.Fatalf("expected a spec of %s", tc.param)
+			}
+			err := spec.Validate(tc.param, tc.value)
+			if tc.expectedErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error validating %s %v: %v", tc.param, tc.value, err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tc.expectedErr {
+				t.Errorf("expected error %q validating %s %v, got %v", tc.expectedErr, tc.param, tc.value, err)
+			}
+		})
+	}
+}
+
+func TestAlgorithmParamSpec(t *testing.T) {
+	for algName, specs := range AlgorithmParamSpec {
+		if !IsKnownAlgorithm(algName) {
+			t.Errorf("expected %s to be a known algorithm", algName)
+		}
+		for param, spec := range specs {
+			if spec.Min > spec.Max || spec.Description == "" {
+				t.Errorf("expected a described range of %s of %s, got %+v", param, algName, spec)
+			}
+		}
+	}
+}
+
+func TestNewAlgorithmWithRangedParams(t *testing.T) {
+	testCases := []struct {
+		name        string
+		algName     string
+		params      map[string]string
+		expected    RoutingAlgorithm
+		expectedErr string
+	}{
+		{
+			name:     "Local with startingThreshold",
+			algName:  "Local",
+			params:   map[string]string{"threshold": "0.3", "startingThreshold": "5"},
+			expected: &LocalSliceAlgorithm{threshold: 0.3, startingThreshold: 5},
+		},
+		{
+			name:     "LatencyAware with startingThreshold",
+			algName:  "LatencyAwareAlgorithm",
+			params:   map[string]string{"startingThreshold": "1"},
+			expected: LatencyAwareAlgorithm{localAlgorithm: &LocalSliceAlgorithm{threshold: 0.5, startingThreshold: 1}},
+		},
+		{
+			name:     "SharedGlobal with global parameters",
+			algName:  "SharedGlobal",
+			params:   map[string]string{"globalWeight": "0.7", "globalThreshold": "50"},
+			expected: SharedGlobalAlgorithm{sharedCoreAlgorithm: sharedGlobalAlgorithmCore{globalWeight: 0.7, globalThreshold: 50}},
+		},
+		{
+			name:     "SharedMultiZone with globalThreshold",
+			algName:  "SharedMultiZone",
+			params:   map[string]string{"globalThreshold": "10000"},
+			expected: SharedMultiZoneAlgorithm{sharedCoreAlgorithm: sharedGlobalAlgorithmCore{globalWeight: 1, globalThreshold: 10000}},
+		},
+		{name: "threshold out of range", algName: "LocalShared", params: map[string]string{"threshold": "11"}, expectedErr: "threshold 11 is out of valid range (0, 10]"},
+		{name: "globalWeight out of range", algName: "SharedGlobal", params: map[string]string{"globalWeight": "2"}, expectedErr: "globalWeight 2 is out of valid range [0, 1]"},
+		{name: "startingThreshold of LocalShared", algName: "LocalShared", params: map[string]string{"startingThreshold": "3"}, expectedErr: "unknown parameter startingThreshold"},
+		{name: "invalid globalThreshold", algName: "SharedGlobal", params: map[string]string{"globalThreshold": "many"}, expectedErr: "invalid globalThreshold"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			alg, err := NewAlgorithmWithParams(tc.algName, tc.params)
+			if tc.expectedErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.expectedErr) {
+					t.Errorf("expected error containing %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error creating algorithm: %v", err)
+			}
+			if !reflect.DeepEqual(alg, tc.expected) {
+				t.Errorf("expected algorithm %+v, got %+v", tc.expected, alg)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"errors"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// ProportionalAlgorithm creates one EndpointSliceGroup per zone with the
+// endpoints of the zone, and every zone consumes all EndpointSliceGroups with
+// its proportion of nodes as traffic weight. Every zone sees all endpoints, so
+// traffic is spread evenly over them: the deviation is zero by construction at
+// the cost of zero locality. It's a lower bound of the deviation other
+// algorithms can reach.
+type ProportionalAlgorithm struct{}
+
+// CreateSliceGroups creates an EndpointSliceGroup for every zone with
+// endpoints, consumed by all zones with their NodesRatio as traffic weight.
+// Zones without nodes send no traffic and keep a weight of 1, so that every
+// zone can reach all EndpointSliceGroups.
+func (alg ProportionalAlgorithm) CreateSliceGroups(region types.RegionInfo) (map[string]types.EndpointSliceGroup, error) {
+	if region.ZoneDetails == nil {
+		return nil, errors.New("can't create EndpointSlices without zones specified")
+	}
+	if region.TotalEndpoints == 0 {
+		return map[string]types.EndpointSliceGroup{}, nil
+	}
+	weights := make(map[string]float64, len(region.ZoneDetails))
+	for name, zone := range region.ZoneDetails {
+		weights[name] = 1
+		if zone.NodesRatio > 0 {
+			weights[name] = zone.NodesRatio
+		}
+	}
+	sliceGroups := make(map[string]types.EndpointSliceGroup)
+	for name, zone := range region.ZoneDetails {
+		if region.HasZeroEndpoints(name) {
+			continue
+		}
+		sliceGroup := types.EndpointSliceGroup{
+			Label:              name,
+			Composition:        map[string]types.WeightedEndpoints{name: {Number: zone.Endpoints, Weight: 1}},
+			ZoneTrafficWeights: make(map[string]float64, len(weights)),
+		}
+		for zoneName, weight := range weights {
+			sliceGroup.ZoneTrafficWeights[zoneName] = weight
+		}
+		sliceGroups[name] = sliceGroup
+	}
+	if err := AssertEndpointConservation(region, sliceGroups); err != nil {
+		return nil, err
+	}
+	return sliceGroups, nil
+}
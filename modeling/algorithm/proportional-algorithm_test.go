@@ -0,0 +1,76 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/simulator"
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+func TestProportionalAlgorithm(t *testing.T) {
+	region, err := types.CreateRegionInfo([]types.Zone{
+		{Nodes: 1, Endpoints: 5, Name: "ZoneA"},
+		{Nodes: 2, Endpoints: 20, Name: "ZoneB"},
+		{Nodes: 7, Endpoints: 20, Name: "ZoneC"},
+		{Nodes: 3, Endpoints: 0, Name: "ZoneD"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	sliceGroups, err := ProportionalAlgorithm{}.CreateSliceGroups(region)
+	if err != nil {
+		t.Fatalf("unexpected error creating sliceGroups: %v", err)
+	}
+	if len(sliceGroups) != 3 {
+		t.Fatalf("expected a sliceGroup per zone with endpoints, got %+v", sliceGroups)
+	}
+	for label, sliceGroup := range sliceGroups {
+		if sliceGroup.Composition[label].Number != region.ZoneDetails[label].Endpoints {
+			t.Errorf("expected all endpoints of %s in its sliceGroup, got %+v", label, sliceGroup.Composition)
+		}
+		for name, zone := range region.ZoneDetails {
+			if !compareFloat(sliceGroup.ZoneTrafficWeights[name], zone.NodesRatio, 1e-9) {
+				t.Errorf("expected weight %v of %s in %s, got %v", zone.NodesRatio, name, label, sliceGroup.ZoneTrafficWeights[name])
+			}
+		}
+	}
+}
+
+func TestProportionalAlgorithmZeroDeviation(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		region := randomRegion(random)
+		if region.TotalEndpoints == 0 || region.TotalNodes == 0 {
+			continue
+		}
+		sliceGroups, err := ProportionalAlgorithm{}.CreateSliceGroups(region)
+		if err != nil {
+			t.Fatalf("unexpected error creating sliceGroups of %+v: %v", region, err)
+		}
+		checkSliceGroupInvariants(t, region, sliceGroups)
+		result, err := simulator.TheoreticalSimulator{}.Simulate(region, sliceGroups)
+		if err != nil {
+			t.Fatalf("unexpected error simulating %+v: %v", region, err)
+		}
+		if result.Invalid || !compareFloat(result.MaxDeviation, 0, 1e-9) || !compareFloat(DeviationScore(result), 100, 1e-6) {
+			t.Errorf("expected a valid result without deviation of %+v, got %+v", region, result)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// ScoreWeights are the weights of the component scores in the total score
+type ScoreWeights = types.ScoreWeights
+
+// DefaultScoreWeights are the weights used to generate output files
+var DefaultScoreWeights = ScoreWeights{InZoneTraffic: 0.45, Deviation: 0.4, SliceCount: 0.15}
+
+// InZoneTrafficScore uses the in-zone traffic percentage as the score
+func InZoneTrafficScore(result types.SimulationResult) float64 {
+	return result.InZoneTrafficScore()
+}
+
+// DeviationScore scores the max and mean traffic load deviation equally
+func DeviationScore(result types.SimulationResult) float64 {
+	return result.DeviationScore()
+}
+
+// SliceScore compares the number of EndpointSlices the original algorithm would
+// create for endpoints with endpointSlices, it's 0 if there is no EndpointSlice
+func SliceScore(endpoints int, endpointSlices int) float64 {
+	return types.SliceScore(endpoints, endpointSlices)
+}
+
+// SliceScoreWithCapacity is SliceScore with EndpointSlices holding capacity
+// endpoints
+func SliceScoreWithCapacity(endpoints int, endpointSlices int, capacity int) float64 {
+	return types.SliceScoreWithCapacity(endpoints, endpointSlices, capacity)
+}
+
+// CalculateScore calculates the total score of a simulation result weighted
+// from the component scores, with the slice score based on EndpointSlices
+// holding capacity endpoints, 100 if capacity isn't positive. Invalid results
+// get a zero score.
+func CalculateScore(result types.SimulationResult, endpoints int, endpointSlices int, capacity int, weights ScoreWeights) float64 {
+	return result.ScoreWithCapacity(weights, endpoints, endpointSlices, capacity)
+}
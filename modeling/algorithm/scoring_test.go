@@ -0,0 +1,221 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+func TestScoring(t *testing.T) {
+	// expected values are taken from example output files generated with the
+	// original hardcoded formula
+	testCases := []struct {
+		name                  string
+		result                types.SimulationResult
+		endpoints             int
+		endpointSlices        int
+		expectedInZoneTraffic float64
+		expectedDeviation     float64
+		expectedSlice         float64
+		expectedTotal         float64
+	}{
+		{
+			name:                  "local shared with one zone without endpoints",
+			result:                types.SimulationResult{InZoneTraffic: 2.0 / 3, MaxDeviation: 1.0 / 99, MeanDeviation: 1.0 / 150},
+			endpoints:             200,
+			endpointSlices:        3,
+			expectedInZoneTraffic: 66.6667,
+			expectedDeviation:     99.1616,
+			expectedSlice:         66.6667,
+			expectedTotal:         79.6646,
+		},
+		{
+			name:                  "original",
+			result:                types.SimulationResult{InZoneTraffic: 1.0 / 3},
+			endpoints:             100,
+			endpointSlices:        1,
+			expectedInZoneTraffic: 33.3333,
+			expectedDeviation:     100,
+			expectedSlice:         100,
+			expectedTotal:         70,
+		},
+		{
+			name:                  "no EndpointSlices",
+			result:                types.SimulationResult{InZoneTraffic: 1},
+			endpoints:             0,
+			endpointSlices:        0,
+			expectedInZoneTraffic: 100,
+			expectedDeviation:     100,
+			expectedSlice:         0,
+			expectedTotal:         85,
+		},
+		{
+			name:                  "invalid result",
+			result:                types.SimulationResult{Invalid: true},
+			endpoints:             100,
+			endpointSlices:        1,
+			expectedInZoneTraffic: 0,
+			expectedDeviation:     100,
+			expectedSlice:         100,
+			expectedTotal:         0,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := InZoneTrafficScore(tc.result); !compareFloat(got, tc.expectedInZoneTraffic, 0.0001) {
+				t.Errorf("expected in-zone traffic score %v, got %v", tc.expectedInZoneTraffic, got)
+			}
+			if got := DeviationScore(tc.result); !compareFloat(got, tc.expectedDeviation, 0.0001) {
+				t.Errorf("expected deviation score %v, got %v", tc.expectedDeviation, got)
+			}
+			if got := SliceScore(tc.endpoints, tc.endpointSlices); !compareFloat(got, tc.expectedSlice, 0.0001) {
+				t.Errorf("expected slice score %v, got %v", tc.expectedSlice, got)
+			}
+			if got := CalculateScore(tc.result, tc.endpoints, tc.endpointSlices, 100, DefaultScoreWeights); !compareFloat(got, tc.expectedTotal, 0.0001) {
+				t.Errorf("expected total score %v, got %v", tc.expectedTotal, got)
+			}
+		})
+	}
+
+	result := types.SimulationResult{InZoneTraffic: 0.5, MaxDeviation: 0.1, MeanDeviation: 0.1}
+	if got := CalculateScore(result, 100, 1, 100, ScoreWeights{InZoneTraffic: 1}); got != 50 {
+		t.Errorf("expected the score of in-zone traffic only to be 50, got %v", got)
+	}
+}
+
+func TestCalculateScore(t *testing.T) {
+	testCases := []struct {
+		name           string
+		result         types.SimulationResult
+		endpoints      int
+		endpointSlices int
+		expected       float64
+	}{
+		{
+			name:           "all traffic in zone without deviation",
+			result:         types.SimulationResult{InZoneTraffic: 1},
+			endpoints:      300,
+			endpointSlices: 3,
+			expected:       100,
+		},
+		{
+			// only the slice score is left
+			name:           "no traffic in zone with max deviation",
+			result:         types.SimulationResult{InZoneTraffic: 0, MaxDeviation: 1, MeanDeviation: 1},
+			endpoints:      300,
+			endpointSlices: 3,
+			expected:       15,
+		},
+		{
+			// unbalanced row of the csv output of LocalShared
+			name:           "intermediate",
+			result:         types.SimulationResult{InZoneTraffic: 2.0 / 3, MaxDeviation: 1.0 / 99, MeanDeviation: 1.0 / 150},
+			endpoints:      200,
+			endpointSlices: 3,
+			expected:       79.6646,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := CalculateScore(tc.result, tc.endpoints, tc.endpointSlices, 100, DefaultScoreWeights); !compareFloat(got, tc.expected, 0.0001) {
+				t.Errorf("expected score %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestInZoneTrafficScore(t *testing.T) {
+	testCases := []struct {
+		inZoneTraffic float64
+		expected      float64
+	}{
+		{inZoneTraffic: 0, expected: 0},
+		{inZoneTraffic: 0.25, expected: 25},
+		{inZoneTraffic: 1, expected: 100},
+	}
+	for _, tc := range testCases {
+		if got := InZoneTrafficScore(types.SimulationResult{InZoneTraffic: tc.inZoneTraffic}); !compareFloat(got, tc.expected, 0.0001) {
+			t.Errorf("expected in-zone traffic score %v of in-zone traffic %v, got %v", tc.expected, tc.inZoneTraffic, got)
+		}
+	}
+}
+
+func TestDeviationScore(t *testing.T) {
+	testCases := []struct {
+		maxDeviation  float64
+		meanDeviation float64
+		expected      float64
+	}{
+		{maxDeviation: 0, meanDeviation: 0, expected: 100},
+		{maxDeviation: 0.2, meanDeviation: 0, expected: 90},
+		{maxDeviation: 0.2, meanDeviation: 0.1, expected: 85},
+		{maxDeviation: 1, meanDeviation: 1, expected: 0},
+	}
+	for _, tc := range testCases {
+		result := types.SimulationResult{MaxDeviation: tc.maxDeviation, MeanDeviation: tc.meanDeviation}
+		if got := DeviationScore(result); !compareFloat(got, tc.expected, 0.0001) {
+			t.Errorf("expected deviation score %v of max deviation %v and mean deviation %v, got %v", tc.expected, tc.maxDeviation, tc.meanDeviation, got)
+		}
+	}
+}
+
+func TestSliceScore(t *testing.T) {
+	testCases := []struct {
+		endpoints      int
+		endpointSlices int
+		capacity       int
+		expected       float64
+	}{
+		{endpoints: 100, endpointSlices: 1, expected: 100},
+		{endpoints: 101, endpointSlices: 2, expected: 100},
+		{endpoints: 100, endpointSlices: 4, expected: 25},
+		{endpoints: 100, endpointSlices: 0, expected: 0},
+		{endpoints: 100, endpointSlices: 4, capacity: 50, expected: 50},
+		{endpoints: 100, endpointSlices: 4, capacity: -1, expected: 25},
+	}
+	for _, tc := range testCases {
+		got := SliceScoreWithCapacity(tc.endpoints, tc.endpointSlices, tc.capacity)
+		if !compareFloat(got, tc.expected, 0.0001) {
+			t.Errorf("expected slice score %v of %d endpoints in %d EndpointSlices of capacity %d, got %v", tc.expected, tc.endpoints, tc.endpointSlices, tc.capacity, got)
+		}
+		if tc.capacity == 0 && !compareFloat(SliceScore(tc.endpoints, tc.endpointSlices), got, 0.0001) {
+			t.Errorf("expected SliceScore to use the default capacity, got %v", SliceScore(tc.endpoints, tc.endpointSlices))
+		}
+	}
+}
+
+func TestScoreRange(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		result := types.SimulationResult{
+			InZoneTraffic: random.Float64(),
+			MaxDeviation:  random.Float64(),
+			MeanDeviation: random.Float64(),
+		}
+		endpoints := random.Intn(1000)
+		// no algorithm creates fewer EndpointSlices than the original one
+		endpointSlices := int(math.Ceil(float64(endpoints)/100)) + random.Intn(10)
+		score := CalculateScore(result, endpoints, endpointSlices, 100, DefaultScoreWeights)
+		if score < 0 || score > 100 {
+			t.Errorf("expected score in [0, 100] of %+v with %d endpoints in %d EndpointSlices, got %v", result, endpoints, endpointSlices, score)
+		}
+	}
+}
@@ -18,6 +18,7 @@ package algorithm
 
 import (
 	"errors"
+	"fmt"
 	"math"
 
 	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
@@ -36,6 +37,17 @@ type sharedGlobalAlgorithmCore struct {
 	// Threshold of global EndpointSliceGroup that if the total number of endpoints
 	// <= threshold, all endpoints go to global EndpointSliceGroup
 	globalThreshold int
+	// perZoneGlobalWeight overrides globalWeight for the zones listed
+	perZoneGlobalWeight map[string]float64
+}
+
+// zoneGlobalWeight returns the global weight of a zone, its override in
+// perZoneGlobalWeight if set, globalWeight otherwise
+func (alg sharedGlobalAlgorithmCore) zoneGlobalWeight(zoneName string) float64 {
+	if weight, ok := alg.perZoneGlobalWeight[zoneName]; ok {
+		return weight
+	}
+	return alg.globalWeight
 }
 
 // CreateSliceGroups takes a region of zones as input and output
@@ -44,6 +56,14 @@ func (alg sharedGlobalAlgorithmCore) CreateSliceGroups(region types.RegionInfo,
 	if region.ZoneDetails == nil {
 		return nil, errors.New("can't create EndpointSlices without zones specified")
 	}
+	if region.TotalEndpoints == 0 {
+		return map[string]types.EndpointSliceGroup{}, nil
+	}
+	for zoneName, weight := range alg.perZoneGlobalWeight {
+		if weight < 0 {
+			return nil, fmt.Errorf("global weight %v of zone %s should not be negative", weight, zoneName)
+		}
+	}
 	if region.TotalEndpoints <= alg.globalThreshold {
 		return OriginalAlgorithm{}.CreateSliceGroups(region)
 	}
@@ -52,7 +72,7 @@ func (alg sharedGlobalAlgorithmCore) CreateSliceGroups(region types.RegionInfo,
 	for _, zone := range region.ZoneDetails {
 		// Calculate the deviation based on the capacity(endpoints) and
 		// traffic(nodes) ratio
-		deviation[zone.Name] = float64(zone.Endpoints) - float64(region.TotalEndpoints)*zone.NodesRatio
+		deviation[zone.Name] = float64(zone.Endpoints) - zone.ExpectedEndpoints(region.TotalEndpoints)
 	}
 
 	// Output EndpointSlices
@@ -63,16 +83,27 @@ func (alg sharedGlobalAlgorithmCore) CreateSliceGroups(region types.RegionInfo,
 	globalSliceGroup.Composition = make(map[string]types.WeightedEndpoints)
 	globalSliceGroup.ZoneTrafficWeights = make(map[string]float64)
 	for name, zone := range region.ZoneDetails {
+		// zones without endpoints neither contribute to the global
+		// sliceGroup nor have a local sliceGroup, their traffic only goes to
+		// the global sliceGroup
+		globalWeight := alg.zoneGlobalWeight(name)
+		if region.HasZeroEndpoints(name) {
+			globalSliceGroup.ZoneTrafficWeights[name] = globalWeight
+			continue
+		}
 		var globalEndpoints types.WeightedEndpoints
 		// calculate the global contribution of current zone based on the global
 		// weight and the deviation of this zone If deviation > 0, this zone has
 		// more endpoints compared to the ratio of nodes. It should contribute
 		// the extra endpoints to the global sliceGroup with the weight counted.
-		globalEndpoints.Number = int(math.Min(math.Max(0.0, deviation[name])/alg.globalWeight, float64(zone.Endpoints)))
+		// A zone with a zero global weight contributes nothing.
+		if globalWeight > 0 {
+			globalEndpoints.Number = int(math.Min(math.Max(0.0, deviation[name])/globalWeight, float64(zone.Endpoints)))
+		}
 		globalEndpoints.Weight = 1
 
 		globalSliceGroup.Composition[name] = globalEndpoints
-		globalSliceGroup.ZoneTrafficWeights[name] = alg.globalWeight
+		globalSliceGroup.ZoneTrafficWeights[name] = globalWeight
 		if excludeContributor && globalEndpoints.Number != 0 && zone.Endpoints-globalEndpoints.Number != 0 {
 			globalSliceGroup.ZoneTrafficWeights[name] = 0
 		}
@@ -88,5 +119,8 @@ func (alg sharedGlobalAlgorithmCore) CreateSliceGroups(region types.RegionInfo,
 		sliceGroups[name] = localGroup
 	}
 	sliceGroups[globalSliceGroup.Label] = globalSliceGroup
+	if err := AssertEndpointConservation(region, sliceGroups); err != nil {
+		return nil, err
+	}
 	return sliceGroups, nil
 }
@@ -31,3 +31,10 @@ type SharedGlobalAlgorithm struct {
 func (alg SharedGlobalAlgorithm) CreateSliceGroups(region types.RegionInfo) (map[string]types.EndpointSliceGroup, error) {
 	return alg.sharedCoreAlgorithm.CreateSliceGroups(region, false)
 }
+
+// WithPerZoneGlobalWeight returns a copy of the algorithm which uses the global
+// weights of the zones listed in weights instead of the default one
+func (alg SharedGlobalAlgorithm) WithPerZoneGlobalWeight(weights map[string]float64) SharedGlobalAlgorithm {
+	alg.sharedCoreAlgorithm.perZoneGlobalWeight = weights
+	return alg
+}
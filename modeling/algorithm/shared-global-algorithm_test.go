@@ -17,8 +17,11 @@ limitations under the License.
 package algorithm
 
 import (
+	"math"
+	"reflect"
 	"testing"
 
+	"github.com/googleinterns/k8s-topology-simulator/modeling/simulator"
 	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
 )
 
@@ -227,3 +230,144 @@ func TestSharedGlobalAlgorithm(t *testing.T) {
 	}
 	localTest.doTest(t)
 }
+
+func TestSharedGlobalAlgorithmPerZoneGlobalWeight(t *testing.T) {
+	input := []types.Zone{
+		types.Zone{Nodes: 30, Endpoints: 60, Name: "ZoneA"},
+		types.Zone{Nodes: 30, Endpoints: 75, Name: "ZoneB"},
+		types.Zone{Nodes: 30, Endpoints: 55, Name: "ZoneC"},
+	}
+	localGroup := func(zone string, endpoints int) types.EndpointSliceGroup {
+		return types.EndpointSliceGroup{Label: zone, Composition: map[string]types.WeightedEndpoints{zone: {Number: endpoints, Weight: 1}}, ZoneTrafficWeights: map[string]float64{zone: 1}}
+	}
+	alg := SharedGlobalAlgorithm{sharedCoreAlgorithm: sharedGlobalAlgorithmCore{globalWeight: 0.4, globalThreshold: 100}}
+	// ZoneB has 11.67 endpoints more than expected, it contributes 29 endpoints
+	// with the default weight and nothing with a zero weight
+	tests := []routingAlgorithmTest{
+		{
+			algName: "SharedGlobal",
+			alg:     alg,
+			testCases: []algTestCase{{
+				name:  "default global weight",
+				input: input,
+				expectedOutput: map[string]types.EndpointSliceGroup{
+					"ZoneA": localGroup("ZoneA", 60),
+					"ZoneB": localGroup("ZoneB", 46),
+					"ZoneC": localGroup("ZoneC", 55),
+					"global": types.EndpointSliceGroup{
+						Label:              "global",
+						Composition:        map[string]types.WeightedEndpoints{"ZoneB": {Number: 29, Weight: 1}},
+						ZoneTrafficWeights: map[string]float64{"ZoneA": 0.4, "ZoneB": 0.4, "ZoneC": 0.4},
+					},
+				},
+			}},
+		},
+		{
+			algName: "SharedGlobalPerZoneWeight",
+			alg:     alg.WithPerZoneGlobalWeight(map[string]float64{"ZoneB": 0, "ZoneC": 1}),
+			testCases: []algTestCase{{
+				name:  "zero global weight of ZoneB",
+				input: input,
+				expectedOutput: map[string]types.EndpointSliceGroup{
+					"ZoneA": localGroup("ZoneA", 60),
+					"ZoneB": localGroup("ZoneB", 75),
+					"ZoneC": localGroup("ZoneC", 55),
+					"global": types.EndpointSliceGroup{
+						Label:              "global",
+						Composition:        map[string]types.WeightedEndpoints{},
+						ZoneTrafficWeights: map[string]float64{"ZoneA": 0.4, "ZoneB": 0, "ZoneC": 1},
+					},
+				},
+			}},
+		},
+	}
+	for _, test := range tests {
+		test.doTest(t)
+	}
+
+	region, err := types.CreateRegionInfo(input)
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	if _, err := alg.WithPerZoneGlobalWeight(map[string]float64{"ZoneA": -1}).CreateSliceGroups(region); err == nil {
+		t.Errorf("expected an error creating sliceGroups with a negative global weight")
+	}
+}
+
+func TestSharedGlobalAlgorithmCoreGlobalThreshold(t *testing.T) {
+	alg := sharedGlobalAlgorithmCore{globalWeight: 0.4, globalThreshold: 100}
+	testCases := []struct {
+		name           string
+		zoneCEndpoints int
+		fallback       bool
+	}{
+		// globalThreshold is inclusive, a region of exactly 100 endpoints
+		// stays global
+		{name: "total endpoints below threshold", zoneCEndpoints: 49, fallback: true},
+		{name: "total endpoints equal to threshold", zoneCEndpoints: 50, fallback: true},
+		{name: "total endpoints above threshold", zoneCEndpoints: 51, fallback: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			region, err := types.CreateRegionInfo([]types.Zone{
+				types.Zone{Nodes: 10, Endpoints: 20, Name: "ZoneA"},
+				types.Zone{Nodes: 10, Endpoints: 30, Name: "ZoneB"},
+				types.Zone{Nodes: 10, Endpoints: tc.zoneCEndpoints, Name: "ZoneC"},
+			})
+			if err != nil {
+				t.Fatalf("unexpected error creating region: %v", err)
+			}
+			sliceGroups, err := alg.CreateSliceGroups(region, false)
+			if err != nil {
+				t.Fatalf("unexpected error creating sliceGroups: %v", err)
+			}
+			original, err := OriginalAlgorithm{}.CreateSliceGroups(region)
+			if err != nil {
+				t.Fatalf("unexpected error creating original sliceGroups: %v", err)
+			}
+			if fallback := reflect.DeepEqual(sliceGroups, original); fallback != tc.fallback {
+				t.Errorf("expected fallback to the original sliceGroups %v with %d endpoints, got %v", tc.fallback, region.TotalEndpoints, sliceGroups)
+			}
+			if !tc.fallback {
+				for _, zone := range region.ZoneNames() {
+					if _, ok := sliceGroups[zone]; !ok {
+						t.Errorf("expected a local sliceGroup of %s, got %v", zone, sliceGroups)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSharedGlobalAlgorithmOnSharedMultiZoneInputs(t *testing.T) {
+	inZoneTraffic := func(t *testing.T, alg RoutingAlgorithm, region types.RegionInfo) float64 {
+		t.Helper()
+		sliceGroups, err := alg.CreateSliceGroups(region)
+		if err != nil {
+			t.Fatalf("unexpected error creating sliceGroups: %v", err)
+		}
+		checkSliceGroupInvariants(t, region, sliceGroups)
+		result, err := simulator.TheoreticalSimulator{}.Simulate(region, sliceGroups)
+		if err != nil {
+			t.Fatalf("unexpected error simulating: %v", err)
+		}
+		return result.InZoneTraffic
+	}
+	for _, tc := range sharedMultiZoneTestCases() {
+		t.Run(tc.name, func(t *testing.T) {
+			region, err := types.CreateRegionInfo(tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error creating region: %v", err)
+			}
+			shared := inZoneTraffic(t, NewAlgorithm("SharedGlobal"), region)
+			multiZone := inZoneTraffic(t, NewAlgorithm("SharedMultiZone"), region)
+			original := inZoneTraffic(t, OriginalAlgorithm{}, region)
+			// zones keep at least as much traffic as with the global weight 1
+			// of SharedMultiZone or with the original algorithm
+			lower := math.Max(multiZone, original) - 1e-9
+			if shared < lower || shared > 1 {
+				t.Errorf("expected in-zone traffic of SharedGlobal in [%v, 1], got %v", lower, shared)
+			}
+		})
+	}
+}
@@ -32,3 +32,10 @@ type SharedMultiZoneAlgorithm struct {
 func (alg SharedMultiZoneAlgorithm) CreateSliceGroups(region types.RegionInfo) (map[string]types.EndpointSliceGroup, error) {
 	return alg.sharedCoreAlgorithm.CreateSliceGroups(region, true)
 }
+
+// WithPerZoneGlobalWeight returns a copy of the algorithm which uses the global
+// weights of the zones listed in weights instead of the default one
+func (alg SharedMultiZoneAlgorithm) WithPerZoneGlobalWeight(weights map[string]float64) SharedMultiZoneAlgorithm {
+	alg.sharedCoreAlgorithm.perZoneGlobalWeight = weights
+	return alg
+}
@@ -17,13 +17,16 @@ limitations under the License.
 package algorithm
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
 )
 
-func TestSharedMultiZoneAlgorithm(t *testing.T) {
-	testCases := []algTestCase{
+// sharedMultiZoneTestCases are the test cases of SharedMultiZoneAlgorithm, their
+// inputs are also used to compare it with SharedGlobalAlgorithm
+func sharedMultiZoneTestCases() []algTestCase {
+	return []algTestCase{
 		{
 			name: "2 zones with no endpoints",
 			input: []types.Zone{
@@ -213,6 +216,9 @@ func TestSharedMultiZoneAlgorithm(t *testing.T) {
 			expectedErr: nil,
 		},
 	}
+}
+
+func TestSharedMultiZoneAlgorithm(t *testing.T) {
 	localTest := routingAlgorithmTest{
 		algName: "SharedMultiZone",
 		alg: SharedMultiZoneAlgorithm{
@@ -221,7 +227,82 @@ func TestSharedMultiZoneAlgorithm(t *testing.T) {
 				globalThreshold: 100,
 			},
 		},
-		testCases: testCases,
+		testCases: sharedMultiZoneTestCases(),
 	}
 	localTest.doTest(t)
 }
+
+func TestSharedGlobalAlgorithmCoreExcludeContributor(t *testing.T) {
+	testCases := []struct {
+		name         string
+		globalWeight float64
+		input        []types.Zone
+		// contributors are the zones expected to contribute endpoints to the
+		// global sliceGroup
+		contributors []string
+	}{
+		{
+			name:         "zone contributing exactly 1 endpoint",
+			globalWeight: 1,
+			input: []types.Zone{
+				{Name: "ZoneA", Nodes: 1, Endpoints: 11},
+				{Name: "ZoneB", Nodes: 1, Endpoints: 9},
+			},
+			contributors: []string{"ZoneA"},
+		},
+		{
+			name:         "no zone contributing",
+			globalWeight: 0.5,
+			input: []types.Zone{
+				{Name: "ZoneA", Nodes: 1, Endpoints: 10},
+				{Name: "ZoneB", Nodes: 1, Endpoints: 10},
+			},
+		},
+		{
+			name:         "5 zones with 3 contributing",
+			globalWeight: 0.5,
+			input: []types.Zone{
+				{Name: "ZoneA", Nodes: 1, Endpoints: 30},
+				{Name: "ZoneB", Nodes: 1, Endpoints: 30},
+				{Name: "ZoneC", Nodes: 1, Endpoints: 30},
+				{Name: "ZoneD", Nodes: 1, Endpoints: 5},
+				{Name: "ZoneE", Nodes: 1, Endpoints: 5},
+			},
+			contributors: []string{"ZoneA", "ZoneB", "ZoneC"},
+		},
+	}
+	for _, tc := range testCases {
+		region, err := types.CreateRegionInfo(tc.input)
+		if err != nil {
+			t.Fatalf("unexpected error creating region of %s: %v", tc.name, err)
+		}
+		contributors := map[string]bool{}
+		for _, zone := range tc.contributors {
+			contributors[zone] = true
+		}
+		for _, excludeContributor := range []bool{true, false} {
+			t.Run(fmt.Sprintf("%s/excludeContributor=%v", tc.name, excludeContributor), func(t *testing.T) {
+				alg := sharedGlobalAlgorithmCore{globalWeight: tc.globalWeight}
+				sliceGroups, err := alg.CreateSliceGroups(region, excludeContributor)
+				if err != nil {
+					t.Fatalf("unexpected error creating sliceGroups: %v", err)
+				}
+				checkSliceGroupInvariants(t, region, sliceGroups)
+				global := sliceGroups["global"]
+				for _, zone := range tc.input {
+					contributed := global.Composition[zone.Name].Number
+					if (contributed > 0) != contributors[zone.Name] {
+						t.Errorf("expected %s to contribute to the global sliceGroup: %v, got %d endpoints", zone.Name, contributors[zone.Name], contributed)
+					}
+					expectedWeight := tc.globalWeight
+					if excludeContributor && contributed > 0 {
+						expectedWeight = 0
+					}
+					if weight := global.ZoneTrafficWeights[zone.Name]; weight != expectedWeight {
+						t.Errorf("expected global weight %v of %s contributing %d endpoints, got %v", expectedWeight, zone.Name, contributed, weight)
+					}
+				}
+			})
+		}
+	}
+}
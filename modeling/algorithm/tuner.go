@@ -0,0 +1,73 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/simulator"
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// thresholds from 0.1 to 2.0 in steps of 0.1 are searched by AutoTuneThreshold
+const thresholdSteps, thresholdStep = 20, 0.1
+
+// AutoTuneThreshold searches the deviation threshold of the algorithm algName
+// over [0.1, 0.2, ..., 2.0] and returns the one with the highest average score
+// of trainingData. Every region is simulated by TheoreticalSimulator and scored
+// by scorer, the lowest threshold wins ties.
+func AutoTuneThreshold(algName string, trainingData []types.RegionInfo, scorer func(types.SimulationResult) float64) (float64, error) {
+	if len(trainingData) == 0 {
+		return 0, errors.New("no training data to tune threshold")
+	}
+	bestThreshold, bestScore := 0.0, 0.0
+	for step := 1; step <= thresholdSteps; step++ {
+		threshold := float64(step) * thresholdStep
+		alg, err := thresholdAlgorithm(algName, threshold)
+		if err != nil {
+			return 0, err
+		}
+		score, err := meanScore(alg, trainingData, scorer)
+		if err != nil {
+			return 0, fmt.Errorf("threshold %v: %v", threshold, err)
+		}
+		if bestThreshold == 0 || score > bestScore {
+			bestThreshold, bestScore = threshold, score
+		}
+	}
+	logger.Info("threshold tuned", "algorithm", algName, "threshold", bestThreshold, "mean_score", bestScore, "regions", len(trainingData))
+	return bestThreshold, nil
+}
+
+// meanScore returns the average score of alg over regions
+func meanScore(alg RoutingAlgorithm, regions []types.RegionInfo, scorer func(types.SimulationResult) float64) (float64, error) {
+	sim := simulator.TheoreticalSimulator{}
+	total := 0.0
+	for i, region := range regions {
+		sliceGroups, err := alg.CreateSliceGroups(region)
+		if err != nil {
+			return 0, fmt.Errorf("region %d: %v", i, err)
+		}
+		result, err := sim.Simulate(region, sliceGroups)
+		if err != nil {
+			return 0, fmt.Errorf("region %d: %v", i, err)
+		}
+		total += scorer(result)
+	}
+	return total / float64(len(regions)), nil
+}
@@ -0,0 +1,78 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+func TestAutoTuneThreshold(t *testing.T) {
+	// small regions where a low threshold leaves a zone unbalanced by integer
+	// rounding or falls back to OriginalAlgorithm
+	zoneInputs := [][]types.Zone{
+		{{Name: "zoneA", Nodes: 1, Endpoints: 2}, {Name: "zoneB", Nodes: 8, Endpoints: 6}, {Name: "zoneC", Nodes: 1, Endpoints: 11}, {Name: "zoneD", Nodes: 5, Endpoints: 2}},
+		{{Name: "zoneA", Nodes: 8, Endpoints: 2}, {Name: "zoneB", Nodes: 9, Endpoints: 1}},
+		{{Name: "zoneA", Nodes: 1, Endpoints: 10}, {Name: "zoneB", Nodes: 2, Endpoints: 2}, {Name: "zoneC", Nodes: 8, Endpoints: 3}},
+		{{Name: "zoneA", Nodes: 7, Endpoints: 1}, {Name: "zoneB", Nodes: 3, Endpoints: 1}, {Name: "zoneC", Nodes: 7, Endpoints: 2}},
+		{{Name: "zoneA", Nodes: 9, Endpoints: 1}, {Name: "zoneB", Nodes: 4, Endpoints: 1}, {Name: "zoneC", Nodes: 8, Endpoints: 5}, {Name: "zoneD", Nodes: 3, Endpoints: 9}},
+	}
+	var trainingData []types.RegionInfo
+	for _, zones := range zoneInputs {
+		region, err := types.CreateRegionInfo(zones)
+		if err != nil {
+			t.Fatalf("unexpected error creating region: %v", err)
+		}
+		trainingData = append(trainingData, region)
+	}
+	scorer := func(result types.SimulationResult) float64 {
+		return result.Score(DefaultScoreWeights, 0, 0)
+	}
+
+	for _, algName := range []string{"Local", "LocalShared"} {
+		t.Run(algName, func(t *testing.T) {
+			threshold, err := AutoTuneThreshold(algName, trainingData, scorer)
+			if err != nil {
+				t.Fatalf("unexpected error tuning threshold: %v", err)
+			}
+			if threshold < 0.1 || threshold > 2.0 {
+				t.Errorf("expected threshold in [0.1, 2.0], got %v", threshold)
+			}
+			defaultAlg, _ := thresholdAlgorithm(algName, 0.5)
+			tunedAlg, _ := thresholdAlgorithm(algName, threshold)
+			defaultScore, err := meanScore(defaultAlg, trainingData, scorer)
+			if err != nil {
+				t.Fatalf("unexpected error scoring default threshold: %v", err)
+			}
+			tunedScore, err := meanScore(tunedAlg, trainingData, scorer)
+			if err != nil {
+				t.Fatalf("unexpected error scoring tuned threshold: %v", err)
+			}
+			if tunedScore <= defaultScore {
+				t.Errorf("expected tuned threshold %v to improve mean score %v of the default threshold, got %v", threshold, defaultScore, tunedScore)
+			}
+		})
+	}
+
+	if _, err := AutoTuneThreshold("Local", nil, scorer); err == nil {
+		t.Error("expected error tuning without training data")
+	}
+	if _, err := AutoTuneThreshold("Original", trainingData, scorer); err == nil {
+		t.Error("expected error tuning an algorithm without threshold")
+	}
+}
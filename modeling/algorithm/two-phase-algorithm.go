@@ -0,0 +1,70 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"errors"
+	"math"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// TwoPhaseAlgorithm is a reference algorithm which assigns endpoints in two
+// explicit phases:
+//  1. Every zone keeps floor(NodesRatio * TotalEndpoints) of its own endpoints,
+//     or all of them if it has fewer, in a local EndpointSliceGroup.
+//  2. All endpoints left after phase 1 go to a global EndpointSliceGroup which
+//     zones consume with traffic weights of their proportion of nodes.
+type TwoPhaseAlgorithm struct{}
+
+// CreateSliceGroups creates a local EndpointSliceGroup for every zone keeping
+// endpoints in phase 1, and a global EndpointSliceGroup of the rest
+func (alg TwoPhaseAlgorithm) CreateSliceGroups(region types.RegionInfo) (map[string]types.EndpointSliceGroup, error) {
+	if region.ZoneDetails == nil {
+		return nil, errors.New("can't create EndpointSlices without zones specified")
+	}
+	if region.TotalEndpoints == 0 {
+		return map[string]types.EndpointSliceGroup{}, nil
+	}
+	sliceGroups := make(map[string]types.EndpointSliceGroup)
+	globalSG := types.EndpointSliceGroup{
+		Label:              "global",
+		Composition:        make(map[string]types.WeightedEndpoints),
+		ZoneTrafficWeights: make(map[string]float64),
+	}
+	for name, zone := range region.ZoneDetails {
+		// phase 1: keep the integer part of the expected endpoints in zone
+		expectedEndpoints := roundNearInteger(zone.ExpectedEndpoints(region.TotalEndpoints))
+		local := int(math.Min(math.Floor(expectedEndpoints), float64(zone.Endpoints)))
+		if local > 0 {
+			sliceGroups[name] = types.EndpointSliceGroup{
+				Label:              name,
+				Composition:        map[string]types.WeightedEndpoints{name: {Number: local, Weight: 1}},
+				ZoneTrafficWeights: map[string]float64{name: 1},
+			}
+		}
+		// phase 2: surplus endpoints and the ones left by rounding down are
+		// shared by all zones
+		globalSG.Composition[name] = types.WeightedEndpoints{Number: zone.Endpoints - local, Weight: 1}
+		globalSG.ZoneTrafficWeights[name] = zone.NodesRatio
+	}
+	sliceGroups[globalSG.Label] = globalSG
+	if err := AssertEndpointConservation(region, sliceGroups); err != nil {
+		return nil, err
+	}
+	return sliceGroups, nil
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+func TestTwoPhaseAlgorithm(t *testing.T) {
+	testCases := []algTestCase{
+		{
+			name: "unbalanced nodes distribution",
+			input: []types.Zone{
+				types.Zone{Nodes: 1, Endpoints: 5, Name: "ZoneA"},
+				types.Zone{Nodes: 2, Endpoints: 20, Name: "ZoneB"},
+				types.Zone{Nodes: 7, Endpoints: 20, Name: "ZoneC"},
+			},
+			expectedOutput: map[string]types.EndpointSliceGroup{
+				"ZoneA": {
+					Label:              "ZoneA",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneA": {Number: 4, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneA": 1},
+				},
+				"ZoneB": {
+					Label:              "ZoneB",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneB": {Number: 9, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneB": 1},
+				},
+				"ZoneC": {
+					Label:              "ZoneC",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneC": {Number: 20, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneC": 1},
+				},
+				"global": {
+					Label: "global",
+					Composition: map[string]types.WeightedEndpoints{
+						"ZoneA": {Number: 1, Weight: 1},
+						"ZoneB": {Number: 11, Weight: 1},
+						"ZoneC": {Number: 0, Weight: 1},
+					},
+					ZoneTrafficWeights: map[string]float64{"ZoneA": 0.1, "ZoneB": 0.2, "ZoneC": 0.7},
+				},
+			},
+		},
+		{
+			name: "balanced",
+			input: []types.Zone{
+				types.Zone{Nodes: 1, Endpoints: 10, Name: "ZoneA"},
+				types.Zone{Nodes: 1, Endpoints: 10, Name: "ZoneB"},
+			},
+			expectedOutput: map[string]types.EndpointSliceGroup{
+				"ZoneA": {
+					Label:              "ZoneA",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneA": {Number: 10, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneA": 1},
+				},
+				"ZoneB": {
+					Label:              "ZoneB",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneB": {Number: 10, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneB": 1},
+				},
+				"global": {
+					Label: "global",
+					Composition: map[string]types.WeightedEndpoints{
+						"ZoneA": {Number: 0, Weight: 1},
+						"ZoneB": {Number: 0, Weight: 1},
+					},
+					ZoneTrafficWeights: map[string]float64{"ZoneA": 0.5, "ZoneB": 0.5},
+				},
+			},
+		},
+		{
+			name: "zone without endpoints",
+			input: []types.Zone{
+				types.Zone{Nodes: 1, Endpoints: 0, Name: "ZoneA"},
+				types.Zone{Nodes: 1, Endpoints: 6, Name: "ZoneB"},
+				types.Zone{Nodes: 1, Endpoints: 7, Name: "ZoneC"},
+			},
+			expectedOutput: map[string]types.EndpointSliceGroup{
+				"ZoneB": {
+					Label:              "ZoneB",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneB": {Number: 4, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneB": 1},
+				},
+				"ZoneC": {
+					Label:              "ZoneC",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneC": {Number: 4, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneC": 1},
+				},
+				"global": {
+					Label: "global",
+					Composition: map[string]types.WeightedEndpoints{
+						"ZoneA": {Number: 0, Weight: 1},
+						"ZoneB": {Number: 2, Weight: 1},
+						"ZoneC": {Number: 3, Weight: 1},
+					},
+					ZoneTrafficWeights: map[string]float64{"ZoneA": 1.0 / 3, "ZoneB": 1.0 / 3, "ZoneC": 1.0 / 3},
+				},
+			},
+		},
+	}
+	algTest := routingAlgorithmTest{
+		algName:   "TwoPhaseAlgorithm",
+		alg:       TwoPhaseAlgorithm{},
+		testCases: testCases,
+	}
+	algTest.doTest(t)
+}
+
+func TestTwoPhaseAlgorithmSurplus(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		region := randomRegion(random)
+		sliceGroups, err := TwoPhaseAlgorithm{}.CreateSliceGroups(region)
+		if err != nil {
+			t.Fatalf("unexpected error creating sliceGroups of %+v: %v", region, err)
+		}
+		checkSliceGroupInvariants(t, region, sliceGroups)
+		global := sliceGroups["global"]
+		for name, zone := range region.ZoneDetails {
+			// every endpoint beyond the integer part of the expected endpoints
+			// of a zone goes to the global sliceGroup in phase 2
+			surplus := zone.Endpoints - int(math.Floor(roundNearInteger(zone.ExpectedEndpoints(region.TotalEndpoints))))
+			if surplus > 0 && global.Composition[name].Number != surplus {
+				t.Errorf("expected %d surplus endpoints of %s in the global sliceGroup, got %+v", surplus, name, global.Composition[name])
+			}
+			if surplus <= 0 && global.Composition[name].Number != 0 {
+				t.Errorf("expected no endpoints of %s without surplus in the global sliceGroup, got %+v", name, global.Composition[name])
+			}
+			if local := sliceGroups[name].Composition[name].Number; local+global.Composition[name].Number != zone.Endpoints {
+				t.Errorf("expected all %d endpoints of %s in its local and the global sliceGroup, got %d and %d", zone.Endpoints, name, local, global.Composition[name].Number)
+			}
+		}
+	}
+}
@@ -17,6 +17,9 @@ limitations under the License.
 package algorithm
 
 import (
+	"container/heap"
+	"errors"
+	"fmt"
 	"sort"
 
 	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
@@ -59,6 +62,76 @@ func (el *endpointsList) pop() {
 	el.byZone = el.byZone[1:]
 }
 
+// Total sums up the deviation of all zones in the list
+func (el *endpointsList) Total() int {
+	total := 0
+	for _, zone := range el.byZone {
+		total += zone.deviation
+	}
+	return total
+}
+
+// FindByName returns a pointer to the element of the zone and its index, or
+// nil and -1 if the zone is not in the list
+func (el *endpointsList) FindByName(name string) (*endpointDeviation, int) {
+	for index := range el.byZone {
+		if el.byZone[index].name == name {
+			return &el.byZone[index], index
+		}
+	}
+	return nil, -1
+}
+
+// Remove erases the element at index, the order of the remaining elements is
+// kept. Removing the front is O(1) the same as pop. An out of range index is
+// ignored.
+func (el *endpointsList) Remove(index int) {
+	if index < 0 || index >= len(el.byZone) {
+		return
+	}
+	if index == 0 {
+		el.pop()
+		return
+	}
+	el.byZone = append(el.byZone[:index], el.byZone[index+1:]...)
+}
+
+// SortByDeviationDescending orders the list by deviation from the largest,
+// ties are broken by zone name so the order doesn't depend on how the list was
+// filled
+func (el *endpointsList) SortByDeviationDescending() {
+	sort.SliceStable(el.byZone, func(i, j int) bool {
+		if el.byZone[i].deviation != el.byZone[j].deviation {
+			return el.byZone[i].deviation > el.byZone[j].deviation
+		}
+		return el.byZone[i].name < el.byZone[j].name
+	})
+}
+
+// errMaxIterations is returned when balancing sliceGroups doesn't finish within
+// maxBalanceIterations, which only happens if a pool is broken by a bug
+var errMaxIterations = errors.New("exceeded max iterations")
+
+// maxBalanceIterations is the max number of iterations to balance sliceGroups
+// of region, one per endpoint moved and one per zone finished
+func maxBalanceIterations(region types.RegionInfo) int {
+	return region.TotalEndpoints + len(region.ZoneDetails)
+}
+
+// AssertEndpointConservation returns an error if the number of endpoints in
+// sliceGroups differs from the total endpoints of region by more than 1, the
+// difference allowed for integer rounding
+func AssertEndpointConservation(region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup) error {
+	total := 0
+	for _, sliceGroup := range sliceGroups {
+		total += sliceGroup.NumberOfEndpoints()
+	}
+	if total > region.TotalEndpoints+1 || total < region.TotalEndpoints-1 {
+		return fmt.Errorf("sliceGroups have %d endpoints, region has %d", total, region.TotalEndpoints)
+	}
+	return nil
+}
+
 // ZonePriorityQueue sorts zone based on endpoints distribution ratio deviation
 // compared to nodes ratio
 type ZonePriorityQueue struct {
@@ -126,15 +199,64 @@ func (pq *ZonePriorityQueue) Swap(i, j int) {
 	pq.ZoneNames[i], pq.ZoneNames[j] = pq.ZoneNames[j], pq.ZoneNames[i]
 }
 
-// sortZoneByNames sorts the map by keys and returns an array of the sorted
+// Contains returns true if the zone is in the queue
+func (pq *ZonePriorityQueue) Contains(zoneName string) bool {
+	return pq.indexOf(zoneName) >= 0
+}
+
+// Remove erases the zone from the queue and keeps the heap invariant, it
+// returns false if the zone is not in the queue
+func (pq *ZonePriorityQueue) Remove(zoneName string) bool {
+	i := pq.indexOf(zoneName)
+	if i < 0 {
+		return false
+	}
+	// swaps the zone to the end, pops it and fixes the swapped element
+	heap.Remove(pq, i)
+	return true
+}
+
+// helper function to find the index of a zone with a linear scan, the number
+// of zones is small
+func (pq *ZonePriorityQueue) indexOf(zoneName string) int {
+	for i, name := range pq.ZoneNames {
+		if name == zoneName {
+			return i
+		}
+	}
+	return -1
+}
+
+// SortZoneByNames sorts the map by keys and returns an array of the sorted
 // zoneNames. It helps traverse the map with a deterministic order
-func sortZoneByNames(zones map[string]types.Zone) []string {
-	var names []string
-	for name := range zones {
-		names = append(names, name)
+func SortZoneByNames(zones map[string]types.Zone) []string {
+	return types.RegionInfo{ZoneDetails: zones}.ZoneNames()
+}
+
+// SortSliceGroupsByLabel sorts the map by keys and returns an array of the
+// sorted labels. It helps traverse the map with a deterministic order
+func SortSliceGroupsByLabel(groups map[string]types.EndpointSliceGroup) []string {
+	var labels []string
+	for label := range groups {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// CalculateDeviation calculates the deviation between endpoints in the local
+// EndpointSliceGroup of a zone and endpoints the zone expects. It returns false
+// if the zone or its local EndpointSliceGroup is not found.
+func CalculateDeviation(region types.RegionInfo, sliceGroups map[string]types.EndpointSliceGroup, zone string) (float64, bool) {
+	zoneInfo, ok := region.ZoneDetails[zone]
+	if !ok {
+		return 0.0, false
+	}
+	sliceGroup, ok := sliceGroups[zone]
+	if !ok {
+		return 0.0, false
 	}
-	sort.Strings(names)
-	return names
+	return float64(sliceGroup.NumberOfEndpoints()) - zoneInfo.ExpectedEndpoints(region.TotalEndpoints), true
 }
 
 // assignEndpoints helps distribute endpoints from rich zones to poor zones in
@@ -168,3 +290,22 @@ func assignEndpoints(receiveZone *endpointDeviation, endpointsAvailable *endpoin
 		}
 	}
 }
+
+// effectiveThreshold scales the deviation threshold of a zone by its bandwidth
+// compared to the average bandwidth of zones with a known bandwidth, zones
+// with a higher bandwidth tolerate more deviation. It returns threshold as is
+// if the zone has no bandwidth.
+func effectiveThreshold(threshold float64, region types.RegionInfo, zoneName string) float64 {
+	bandwidth := region.ZoneDetails[zoneName].BandwidthMbps
+	if bandwidth <= 0 {
+		return threshold
+	}
+	totalBandwidth, zones := 0.0, 0
+	for _, zone := range region.ZoneDetails {
+		if zone.BandwidthMbps > 0 {
+			totalBandwidth += zone.BandwidthMbps
+			zones++
+		}
+	}
+	return threshold * bandwidth / (totalBandwidth / float64(zones))
+}
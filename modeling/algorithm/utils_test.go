@@ -0,0 +1,370 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+func TestSortZoneByNames(t *testing.T) {
+	// insert zones in reverse order, the map iteration order is randomized
+	// anyway
+	zones := map[string]types.Zone{}
+	for i := 12; i > 0; i-- {
+		name := fmt.Sprintf("zone-%02d", i)
+		zones[name] = types.Zone{Name: name, Nodes: i, Endpoints: i}
+	}
+	for run := 0; run < 5; run++ {
+		names := SortZoneByNames(zones)
+		if len(names) != len(zones) {
+			t.Fatalf("expected %d zone names, got %d", len(zones), len(names))
+		}
+		if !sort.StringsAreSorted(names) {
+			t.Errorf("expected sorted zone names, got %v", names)
+		}
+	}
+	if names := SortZoneByNames(nil); len(names) != 0 {
+		t.Errorf("expected no zone names for nil zones, got %v", names)
+	}
+}
+
+func TestSortSliceGroupsByLabel(t *testing.T) {
+	groups := map[string]types.EndpointSliceGroup{}
+	for i := 12; i > 0; i-- {
+		label := fmt.Sprintf("zone-%02d", i)
+		groups[label] = types.EndpointSliceGroup{Label: label}
+	}
+	groups["shared-zone-01-zone-02"] = types.EndpointSliceGroup{Label: "shared-zone-01-zone-02"}
+	for run := 0; run < 5; run++ {
+		labels := SortSliceGroupsByLabel(groups)
+		if len(labels) != len(groups) {
+			t.Fatalf("expected %d labels, got %d", len(groups), len(labels))
+		}
+		if !sort.StringsAreSorted(labels) {
+			t.Errorf("expected sorted labels, got %v", labels)
+		}
+	}
+}
+
+func TestCalculateDeviation(t *testing.T) {
+	region, err := types.CreateRegionInfo([]types.Zone{
+		types.Zone{Nodes: 1, Endpoints: 5, Name: "ZoneA"},
+		types.Zone{Nodes: 3, Endpoints: 3, Name: "ZoneB"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	sliceGroups := map[string]types.EndpointSliceGroup{
+		"ZoneA": types.EndpointSliceGroup{
+			Label:       "ZoneA",
+			Composition: map[string]types.WeightedEndpoints{"ZoneA": types.WeightedEndpoints{Number: 5, Weight: 1}},
+		},
+		"ZoneB": types.EndpointSliceGroup{
+			Label:       "ZoneB",
+			Composition: map[string]types.WeightedEndpoints{"ZoneB": types.WeightedEndpoints{Number: 3, Weight: 1}},
+		},
+	}
+	testCases := []struct {
+		name              string
+		zone              string
+		expectedDeviation float64
+		expectedFound     bool
+	}{
+		{name: "more endpoints than expected", zone: "ZoneA", expectedDeviation: 3, expectedFound: true},
+		{name: "fewer endpoints than expected", zone: "ZoneB", expectedDeviation: -3, expectedFound: true},
+		{name: "zone not found", zone: "ZoneC", expectedDeviation: 0, expectedFound: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			deviation, found := CalculateDeviation(region, sliceGroups, tc.zone)
+			if found != tc.expectedFound || deviation != tc.expectedDeviation {
+				t.Errorf("expected (%v, %v), got (%v, %v)", tc.expectedDeviation, tc.expectedFound, deviation, found)
+			}
+		})
+	}
+}
+
+func TestZonePriorityQueueRemove(t *testing.T) {
+	var zones []types.Zone
+	sliceGroups := map[string]types.EndpointSliceGroup{}
+	for i := 0; i < 7; i++ {
+		name := fmt.Sprintf("zone%d", i)
+		zones = append(zones, types.Zone{Name: name, Nodes: i%3 + 1, Endpoints: i + 1})
+		sliceGroups[name] = types.EndpointSliceGroup{
+			Label:       name,
+			Composition: map[string]types.WeightedEndpoints{name: types.WeightedEndpoints{Number: i + 1, Weight: 1}},
+		}
+	}
+	region, err := types.CreateRegionInfo(zones)
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	for _, receive := range []bool{false, true} {
+		t.Run(fmt.Sprintf("receive endpoints %v", receive), func(t *testing.T) {
+			pq := &ZonePriorityQueue{SliceGroups: sliceGroups, Region: region, ReceiveEndpoint: receive}
+			for _, zone := range zones {
+				pq.ZoneNames = append(pq.ZoneNames, zone.Name)
+			}
+			heap.Init(pq)
+			// remove a non-root element
+			removed := pq.ZoneNames[3]
+			if !pq.Remove(removed) {
+				t.Fatalf("expected %s to be removed", removed)
+			}
+			if pq.Contains(removed) || pq.Len() != len(zones)-1 {
+				t.Fatalf("expected %s not in the queue of %d zones, got %v", removed, len(zones)-1, pq.ZoneNames)
+			}
+			if pq.Remove(removed) {
+				t.Errorf("expected removing %s again to fail", removed)
+			}
+			for i := 1; i < pq.Len(); i++ {
+				if pq.Less(i, (i-1)/2) {
+					t.Fatalf("heap invariant broken at index %d: %v", i, pq.ZoneNames)
+				}
+			}
+			for _, zone := range pq.ZoneNames {
+				if !pq.Contains(zone) {
+					t.Errorf("expected queue to contain %s", zone)
+				}
+			}
+		})
+	}
+}
+
+func TestZonePriorityQueue(t *testing.T) {
+	// nodes per endpoint of zones: ZoneA 1/2, ZoneB 2/8, ZoneC 3/3, ZoneD 4/6,
+	// ZoneE 5/25, ZoneF 6/3; after giving out one endpoint: ZoneA 1/1, ZoneB
+	// 2/7, ZoneC 3/2, ZoneD 4/5, ZoneE 5/24, ZoneF 6/2
+	zones := []types.Zone{
+		{Name: "ZoneA", Nodes: 1, Endpoints: 2},
+		{Name: "ZoneB", Nodes: 2, Endpoints: 8},
+		{Name: "ZoneC", Nodes: 3, Endpoints: 3},
+		{Name: "ZoneD", Nodes: 4, Endpoints: 6},
+		{Name: "ZoneE", Nodes: 5, Endpoints: 25},
+		{Name: "ZoneF", Nodes: 6, Endpoints: 3},
+	}
+	sliceGroups := map[string]types.EndpointSliceGroup{}
+	for _, zone := range zones {
+		sliceGroups[zone.Name] = types.EndpointSliceGroup{
+			Label:       zone.Name,
+			Composition: map[string]types.WeightedEndpoints{zone.Name: {Number: zone.Endpoints, Weight: 1}},
+		}
+	}
+	region, err := types.CreateRegionInfo(zones)
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	testCases := []struct {
+		name    string
+		receive bool
+		// expected order of popping ZoneA to ZoneE
+		expected []string
+		// expected order after pushing ZoneF
+		expectedPushed []string
+	}{
+		{
+			name:           "receive endpoints in descending deviation order",
+			receive:        true,
+			expected:       []string{"ZoneC", "ZoneD", "ZoneA", "ZoneB", "ZoneE"},
+			expectedPushed: []string{"ZoneF", "ZoneC", "ZoneD", "ZoneA", "ZoneB", "ZoneE"},
+		},
+		{
+			name:           "give endpoints in ascending post-donation deviation order",
+			receive:        false,
+			expected:       []string{"ZoneE", "ZoneB", "ZoneD", "ZoneA", "ZoneC"},
+			expectedPushed: []string{"ZoneE", "ZoneB", "ZoneD", "ZoneA", "ZoneC", "ZoneF"},
+		},
+	}
+	popAll := func(pq *ZonePriorityQueue) []string {
+		var popped []string
+		for pq.Len() > 0 {
+			popped = append(popped, heap.Pop(pq).(string))
+		}
+		return popped
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pq := &ZonePriorityQueue{SliceGroups: sliceGroups, Region: region, ReceiveEndpoint: tc.receive, ZoneNames: []string{"ZoneA", "ZoneB", "ZoneC", "ZoneD", "ZoneE"}}
+			heap.Init(pq)
+			if popped := popAll(pq); !reflect.DeepEqual(popped, tc.expected) {
+				t.Errorf("expected zones popped in order %v, got %v", tc.expected, popped)
+			}
+			if pq.Len() != 0 {
+				t.Errorf("expected an empty queue after popping all zones, got %v", pq.ZoneNames)
+			}
+
+			pq.ZoneNames = []string{"ZoneA", "ZoneB", "ZoneC", "ZoneD", "ZoneE"}
+			heap.Init(pq)
+			heap.Push(pq, "ZoneF")
+			for i := 1; i < pq.Len(); i++ {
+				if pq.Less(i, (i-1)/2) {
+					t.Fatalf("heap invariant broken at index %d after push: %v", i, pq.ZoneNames)
+				}
+			}
+			if popped := popAll(pq); !reflect.DeepEqual(popped, tc.expectedPushed) {
+				t.Errorf("expected zones popped in order %v after push, got %v", tc.expectedPushed, popped)
+			}
+		})
+	}
+}
+
+func TestAssertEndpointConservation(t *testing.T) {
+	region, err := types.CreateRegionInfo([]types.Zone{
+		{Name: "ZoneA", Nodes: 1, Endpoints: 5},
+		{Name: "ZoneB", Nodes: 2, Endpoints: 20},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	sliceGroups, err := OriginalAlgorithm{}.CreateSliceGroups(region)
+	if err != nil {
+		t.Fatalf("unexpected error creating sliceGroups: %v", err)
+	}
+	testCases := []struct {
+		name      string
+		extra     int
+		expectErr bool
+	}{
+		{name: "conserved endpoints", extra: 0},
+		{name: "one extra endpoint from rounding", extra: 1},
+		{name: "one missing endpoint from rounding", extra: -1},
+		{name: "extra endpoints", extra: 2, expectErr: true},
+		{name: "missing endpoints", extra: -2, expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			injected := map[string]types.EndpointSliceGroup{"global": sliceGroups["global"].Clone()}
+			updateSGComposition(injected["global"], "ZoneA", tc.extra, 1)
+			if err := AssertEndpointConservation(region, injected); (err != nil) != tc.expectErr {
+				t.Errorf("expected error %v with %d extra endpoints, got %v", tc.expectErr, tc.extra, err)
+			}
+		})
+	}
+
+	// every algorithm conserves endpoints of random regions
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		region := randomRegion(random)
+		for _, name := range ListAlgorithms() {
+			sliceGroups, err := NewAlgorithm(name).CreateSliceGroups(region)
+			if err != nil {
+				continue
+			}
+			if err := AssertEndpointConservation(region, sliceGroups); err != nil {
+				t.Errorf("unexpected error of %s on %s: %v", name, region.Summarize(), err)
+			}
+		}
+	}
+}
+
+func TestEndpointsList(t *testing.T) {
+	t.Run("empty list", func(t *testing.T) {
+		list := endpointsList{}
+		if total := list.Total(); total != 0 {
+			t.Errorf("expected total 0, got %d", total)
+		}
+		if zone, index := list.FindByName("zoneA"); zone != nil || index != -1 {
+			t.Errorf("expected (nil, -1), got (%v, %d)", zone, index)
+		}
+		list.Remove(0)
+		list.pop()
+		if len(list.byZone) != 0 {
+			t.Errorf("expected empty list, got %v", list.byZone)
+		}
+	})
+
+	list := endpointsList{}
+	list.push(endpointDeviation{name: "zoneB", deviation: 2})
+	list.push(endpointDeviation{name: "zoneC", deviation: 3})
+	list.pushFront(endpointDeviation{name: "zoneA", deviation: 1})
+	list.push(endpointDeviation{name: "zoneD", deviation: 4})
+	if total := list.Total(); total != 10 {
+		t.Errorf("expected total 10, got %d", total)
+	}
+	if zone, index := list.FindByName("zoneE"); zone != nil || index != -1 {
+		t.Errorf("expected (nil, -1) for missing zone, got (%v, %d)", zone, index)
+	}
+	zone, index := list.FindByName("zoneC")
+	if zone == nil || index != 2 {
+		t.Fatalf("expected zoneC at index 2, got (%v, %d)", zone, index)
+	}
+	// the pointer refers to the element in the list
+	zone.deviation = 5
+	if total := list.Total(); total != 12 {
+		t.Errorf("expected total 12 after updating zoneC, got %d", total)
+	}
+
+	list.Remove(index)
+	list.Remove(len(list.byZone))
+	list.Remove(0)
+	var names []string
+	for _, zone := range list.byZone {
+		names = append(names, zone.name)
+	}
+	if fmt.Sprint(names) != "[zoneB zoneD]" {
+		t.Errorf("expected [zoneB zoneD] after removing, got %v", names)
+	}
+}
+
+func TestEndpointsListSortByDeviationDescending(t *testing.T) {
+	list := endpointsList{}
+	list.push(endpointDeviation{name: "zoneD", deviation: 2})
+	list.push(endpointDeviation{name: "zoneA", deviation: 1})
+	list.push(endpointDeviation{name: "zoneC", deviation: 2})
+	list.push(endpointDeviation{name: "zoneE", deviation: 3})
+	list.push(endpointDeviation{name: "zoneB", deviation: 2})
+	list.SortByDeviationDescending()
+	var names []string
+	for _, zone := range list.byZone {
+		names = append(names, zone.name)
+	}
+	// zones with the same deviation are in alphabetical order
+	if fmt.Sprint(names) != "[zoneE zoneB zoneC zoneD zoneA]" {
+		t.Errorf("expected [zoneE zoneB zoneC zoneD zoneA] after sorting, got %v", names)
+	}
+
+	empty := endpointsList{}
+	empty.SortByDeviationDescending()
+	if len(empty.byZone) != 0 {
+		t.Errorf("expected empty list, got %v", empty.byZone)
+	}
+}
+
+func TestEffectiveThreshold(t *testing.T) {
+	region, err := types.CreateRegionInfo([]types.Zone{
+		types.Zone{Nodes: 1, Endpoints: 1, Name: "ZoneA", BandwidthMbps: 400},
+		types.Zone{Nodes: 1, Endpoints: 1, Name: "ZoneB", BandwidthMbps: 100},
+		types.Zone{Nodes: 1, Endpoints: 1, Name: "ZoneC", BandwidthMbps: 100},
+		types.Zone{Nodes: 1, Endpoints: 1, Name: "ZoneD"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	// the average bandwidth of zones with a known bandwidth is 200
+	for zone, expected := range map[string]float64{"ZoneA": 1, "ZoneB": 0.25, "ZoneC": 0.25, "ZoneD": 0.5} {
+		if threshold := effectiveThreshold(0.5, region, zone); threshold != expected {
+			t.Errorf("expected threshold %v for %s, got %v", expected, zone, threshold)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// WeightedOriginalAlgorithm is a variation of OriginalAlgorithm which puts all
+// endpoints into a global EndpointSliceGroup, optionally weighting the traffic
+// of zones by their proportion of nodes
+type WeightedOriginalAlgorithm struct {
+	// UseNodeWeights sets the traffic weight of a zone to its NodesRatio
+	// instead of 1
+	UseNodeWeights bool
+}
+
+// CreateSliceGroups puts all endpoints into a global EndpointSliceGroup. Zones
+// without nodes send no traffic and keep a weight of 1, so that every zone can
+// reach the global EndpointSliceGroup.
+func (alg WeightedOriginalAlgorithm) CreateSliceGroups(region types.RegionInfo) (map[string]types.EndpointSliceGroup, error) {
+	sliceGroups, err := OriginalAlgorithm{}.CreateSliceGroups(region)
+	if err != nil || !alg.UseNodeWeights {
+		return sliceGroups, err
+	}
+	globalSG, ok := sliceGroups["global"]
+	if !ok {
+		return sliceGroups, nil
+	}
+	for zoneName, zone := range region.ZoneDetails {
+		if zone.NodesRatio > 0 {
+			globalSG.ZoneTrafficWeights[zoneName] = zone.NodesRatio
+		}
+	}
+	return sliceGroups, nil
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package algorithm
+
+import (
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/simulator"
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+func TestWeightedOriginalAlgorithm(t *testing.T) {
+	testCases := []struct {
+		name  string
+		input []types.Zone
+	}{
+		{
+			name: "equal nodes distribution",
+			input: []types.Zone{
+				types.Zone{Nodes: 10, Endpoints: 5, Name: "ZoneA"},
+				types.Zone{Nodes: 10, Endpoints: 20, Name: "ZoneB"},
+				types.Zone{Nodes: 10, Endpoints: 20, Name: "ZoneC"},
+			},
+		},
+		{
+			name: "unequal nodes distribution",
+			input: []types.Zone{
+				types.Zone{Nodes: 1, Endpoints: 5, Name: "ZoneA"},
+				types.Zone{Nodes: 2, Endpoints: 20, Name: "ZoneB"},
+				types.Zone{Nodes: 7, Endpoints: 20, Name: "ZoneC"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			region, err := types.CreateRegionInfo(tc.input)
+			if err != nil {
+				t.Fatalf("unexpected error creating region: %v", err)
+			}
+			original, err := WeightedOriginalAlgorithm{}.CreateSliceGroups(region)
+			if err != nil {
+				t.Fatalf("unexpected error creating sliceGroups: %v", err)
+			}
+			weighted, err := WeightedOriginalAlgorithm{UseNodeWeights: true}.CreateSliceGroups(region)
+			if err != nil {
+				t.Fatalf("unexpected error creating weighted sliceGroups: %v", err)
+			}
+			checkSliceGroupInvariants(t, region, weighted)
+			for zoneName, zone := range region.ZoneDetails {
+				if weight := weighted["global"].ZoneTrafficWeights[zoneName]; weight != zone.NodesRatio {
+					t.Errorf("expected traffic weight %v of %s, got %v", zone.NodesRatio, zoneName, weight)
+				}
+			}
+			originalResult, err := simulator.TheoreticalSimulator{}.Simulate(region, original)
+			if err != nil {
+				t.Fatalf("unexpected error simulating: %v", err)
+			}
+			weightedResult, err := simulator.TheoreticalSimulator{}.Simulate(region, weighted)
+			if err != nil {
+				t.Fatalf("unexpected error simulating weighted sliceGroups: %v", err)
+			}
+			// TheoreticalSimulator splits the traffic of a zone over the
+			// EndpointSliceGroups it can reach, so scaling the weight of a zone
+			// in the only global EndpointSliceGroup keeps its traffic unchanged
+			if !compareFloat(originalResult.InZoneTraffic, weightedResult.InZoneTraffic, 0.00001) || !compareFloat(originalResult.MaxDeviation, weightedResult.MaxDeviation, 0.00001) {
+				t.Errorf("expected the same traffic with and without node weights, got %+v and %+v", originalResult, weightedResult)
+			}
+		})
+	}
+}
@@ -18,12 +18,30 @@ package modeling
 
 import (
 	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/googleinterns/k8s-topology-simulator/modeling/algorithm"
 	"github.com/googleinterns/k8s-topology-simulator/modeling/simulator"
 	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
 )
 
+// AlgorithmResult is the evaluation of one algorithm on the region of a model
+type AlgorithmResult struct {
+	// AlgorithmName is the name the evaluated algorithm is compared under
+	AlgorithmName string
+	// SimulationResult of the EndpointSliceGroups created by the algorithm
+	SimulationResult types.SimulationResult
+	// Score of the simulation result, higher is better
+	Score float64
+}
+
 // Model wrapper class for the simulation components
 type Model struct {
 	slices    map[string]types.EndpointSliceGroup
@@ -31,26 +49,110 @@ type Model struct {
 	simulator simulator.TrafficSimulator
 	region    types.RegionInfo
 
-	// SliceCapacity is the number of max endpoints per slice
-	SliceCapacity int
+	// sliceCapacity is the number of max endpoints per slice
+	sliceCapacity int
+	// randSeed seeds the random source of every RunMultipleSimulations call,
+	// a new seed is drawn per call if it is nil
+	randSeed *int64
+}
+
+// ModelOption configures a Model created by NewModelWithOptions
+type ModelOption func(*Model)
+
+// WithAlgorithm sets the routing algorithm of the model, this option is
+// required
+func WithAlgorithm(alg algorithm.RoutingAlgorithm) ModelOption {
+	return func(m *Model) {
+		m.alg = alg
+	}
+}
+
+// WithSimulator sets the traffic simulator of the model, TheoreticalSimulator
+// is used by default
+func WithSimulator(sim simulator.TrafficSimulator) ModelOption {
+	return func(m *Model) {
+		m.simulator = sim
+	}
+}
+
+// WithSliceCapacity sets the number of max endpoints per slice, 100 by default
+func WithSliceCapacity(capacity int) ModelOption {
+	return func(m *Model) {
+		m.sliceCapacity = capacity
+	}
+}
+
+// WithRandSeed sets the seed of the random source perturbing endpoints in
+// RunMultipleSimulations, making its results reproducible. A time based seed is
+// used by default.
+func WithRandSeed(seed int64) ModelOption {
+	return func(m *Model) {
+		m.randSeed = &seed
+	}
+}
+
+// NewModelWithOptions creates a model configured by opts
+func NewModelWithOptions(opts ...ModelOption) (*Model, error) {
+	model := &Model{
+		sliceCapacity: 100,
+		simulator:     simulator.TheoreticalSimulator{},
+	}
+	for _, opt := range opts {
+		opt(model)
+	}
+	if model.alg == nil || model.simulator == nil {
+		return nil, errors.New("can't create model with nil algorithm or simulator")
+	}
+	if model.sliceCapacity <= 0 {
+		return nil, fmt.Errorf("can't create model with slice capacity %d", model.sliceCapacity)
+	}
+	return model, nil
 }
 
 // NewModel creates a model with routing algorithm and traffic simulator
+//
+// Deprecated: use NewModelWithOptions instead.
 func NewModel(alg algorithm.RoutingAlgorithm, sim simulator.TrafficSimulator) (*Model, error) {
 	if alg == nil || sim == nil {
 		return nil, errors.New("can't create model with nil algorithm or simulator")
 	}
-	model := &Model{
-		SliceCapacity: 100,
-		alg:           alg,
-		simulator:     sim,
+	return NewModelWithOptions(WithAlgorithm(alg), WithSimulator(sim))
+}
+
+// NewModelWithRepeatedSim creates a model whose traffic simulator runs sim
+// repeatedly for the given number of runs and reports averaged results with
+// confidence intervals
+func NewModelWithRepeatedSim(alg algorithm.RoutingAlgorithm, sim simulator.TrafficSimulator, runs int) (*Model, error) {
+	if sim == nil {
+		return nil, errors.New("can't create model with nil simulator")
 	}
-	return model, nil
+	if runs < 1 {
+		return nil, fmt.Errorf("can't create model with %d simulation runs", runs)
+	}
+	return NewModelWithOptions(WithAlgorithm(alg), WithSimulator(simulator.RepeatedSimulator{Inner: sim, Runs: runs}))
+}
+
+// UpdateAlgorithm replaces the routing algorithm of the model, the new
+// algorithm is used from the next UpdateRegion call. The current region and
+// EndpointSliceGroups stay valid until then.
+func (m *Model) UpdateAlgorithm(alg algorithm.RoutingAlgorithm) error {
+	if alg == nil {
+		return errors.New("can't update model with nil algorithm")
+	}
+	m.alg = alg
+	return nil
+}
+
+// Reset clears the region and EndpointSliceGroups of the model
+func (m *Model) Reset() {
+	m.region = types.RegionInfo{}
+	m.slices = nil
 }
 
 // UpdateRegion updates the region of the model, this is used to run the
-// algorithm on different zone inputs
+// algorithm on different zone inputs. A failed update leaves the model reset.
 func (m *Model) UpdateRegion(zones []types.Zone) error {
+	m.Reset()
 	region, err := types.CreateRegionInfo(zones)
 	if err != nil {
 		return err
@@ -66,16 +168,268 @@ func (m *Model) UpdateRegion(zones []types.Zone) error {
 
 // StartSimulation based on the zones(Region) and EndpointSliceGroups
 func (m *Model) StartSimulation() (types.SimulationResult, error) {
+	if m.region.ZoneDetails == nil || m.slices == nil {
+		return types.SimulationResult{}, errors.New("can't start simulation before the region is updated")
+	}
+	if !m.IsReady() {
+		return types.SimulationResult{}, errors.New("can't start simulation of a region without endpoints")
+	}
 	return m.simulator.Simulate(m.region, m.slices)
 }
 
+// CompareAlgorithms runs every algorithm on the current region of the model and
+// returns their results named by names, which are parallel to algorithms,
+// sorted by score in descending order. The EndpointSliceGroups of the model
+// are not changed.
+func (m *Model) CompareAlgorithms(names []string, algorithms []algorithm.RoutingAlgorithm) ([]AlgorithmResult, error) {
+	if m.region.ZoneDetails == nil {
+		return nil, errors.New("can't compare algorithms before the region is updated")
+	}
+	if len(names) != len(algorithms) {
+		return nil, fmt.Errorf("can't compare %d algorithms with %d names", len(algorithms), len(names))
+	}
+	var results []AlgorithmResult
+	for i, alg := range algorithms {
+		if alg == nil {
+			return nil, errors.New("can't compare nil algorithm")
+		}
+		slices, err := alg.CreateSliceGroups(m.region)
+		if err != nil {
+			return nil, err
+		}
+		simRes, err := m.simulator.Simulate(m.region, slices)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, AlgorithmResult{
+			AlgorithmName:    names[i],
+			SimulationResult: simRes,
+			Score:            m.scoreResult(simRes, slices),
+		})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results, nil
+}
+
+// scoreResult scores the simulation result of slices with the slice capacity of
+// the model, results without EndpointSlices get a zero score
+func (m *Model) scoreResult(result types.SimulationResult, slices map[string]types.EndpointSliceGroup) float64 {
+	endpointSlices := m.countEndpointSlices(slices)
+	if endpointSlices == 0 {
+		return 0
+	}
+	return algorithm.CalculateScore(result, m.region.TotalEndpoints, endpointSlices, m.sliceCapacity, algorithm.DefaultScoreWeights)
+}
+
+// RunMultipleSimulations runs n simulations on the current region of the
+// model, each time perturbing the number of endpoints of every zone by a random
+// fraction within ±perturbFactor, and summarizes the results. perturbFactor = 0
+// runs n identical simulations. The model state is not changed.
+func (m *Model) RunMultipleSimulations(n int, perturbFactor float64) (types.AggregateResult, error) {
+	if m.region.ZoneDetails == nil {
+		return types.AggregateResult{}, errors.New("can't run simulations before the region is updated")
+	}
+	if n < 1 {
+		return types.AggregateResult{}, fmt.Errorf("can't run %d simulations", n)
+	}
+	if perturbFactor < 0 {
+		return types.AggregateResult{}, fmt.Errorf("perturb factor %v should not be negative", perturbFactor)
+	}
+	// perturb zones in name order to keep the sequence of random numbers
+	// consumed by each zone deterministic
+	zoneNames := m.region.ZoneNames()
+	seed := time.Now().UnixNano()
+	if m.randSeed != nil {
+		seed = *m.randSeed
+	}
+	random := rand.New(rand.NewSource(seed))
+
+	aggregate := types.AggregateResult{Runs: n}
+	var inZoneTraffic, meanDeviation, maxDeviation []float64
+	for run := 0; run < n; run++ {
+		var zones []types.Zone
+		for _, name := range zoneNames {
+			zone := m.region.ZoneDetails[name]
+			if perturbFactor > 0 {
+				perturbation := (random.Float64()*2 - 1) * perturbFactor
+				zone.Endpoints = int(math.Max(0, math.Round(float64(zone.Endpoints)*(1+perturbation))))
+			}
+			zones = append(zones, zone)
+		}
+		region, err := types.CreateRegionInfo(zones)
+		if err != nil {
+			return types.AggregateResult{}, err
+		}
+		slices, err := m.alg.CreateSliceGroups(region)
+		if err != nil {
+			return types.AggregateResult{}, err
+		}
+		simRes, err := m.simulator.Simulate(region, slices)
+		if err != nil {
+			return types.AggregateResult{}, err
+		}
+		if simRes.Invalid {
+			aggregate.InvalidRuns++
+			continue
+		}
+		inZoneTraffic = append(inZoneTraffic, simRes.InZoneTraffic)
+		meanDeviation = append(meanDeviation, simRes.MeanDeviation)
+		maxDeviation = append(maxDeviation, simRes.MaxDeviation)
+	}
+	aggregate.InZoneTraffic = summarizeMetric(inZoneTraffic)
+	aggregate.MeanDeviation = summarizeMetric(meanDeviation)
+	aggregate.MaxDeviation = summarizeMetric(maxDeviation)
+	return aggregate, nil
+}
+
+// summarizeMetric calculates mean, standard deviation, min and max of values
+func summarizeMetric(values []float64) types.MetricSummary {
+	if len(values) == 0 {
+		return types.MetricSummary{}
+	}
+	summary := types.MetricSummary{Min: values[0], Max: values[0]}
+	for _, value := range values {
+		summary.Mean += value
+		summary.Min = math.Min(summary.Min, value)
+		summary.Max = math.Max(summary.Max, value)
+	}
+	summary.Mean /= float64(len(values))
+	for _, value := range values {
+		summary.StdDev += math.Pow(value-summary.Mean, 2)
+	}
+	summary.StdDev = math.Sqrt(summary.StdDev / float64(len(values)))
+	return summary
+}
+
+// Explain returns a human-readable description of the current
+// EndpointSliceGroups and zones of the model. SliceGroups are sorted by label
+// and zones by name to keep the output stable.
+func (m *Model) Explain() string {
+	if m.region.ZoneDetails == nil {
+		return "model has no region\n"
+	}
+	var builder strings.Builder
+	writer := tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(writer, "EndpointSliceGroup\tEndpoints\tComposition\tZone Traffic Weights")
+	for _, label := range algorithm.SortSliceGroupsByLabel(m.slices) {
+		sliceGroup := m.slices[label]
+		var composition []string
+		for _, zone := range sortedKeys(sliceGroup.Composition) {
+			endpoints := sliceGroup.Composition[zone]
+			entry := zone + ":" + strconv.Itoa(endpoints.Number)
+			if endpoints.Weight != 1 {
+				entry += "x" + strconv.FormatFloat(endpoints.Weight, 'f', 2, 64)
+			}
+			composition = append(composition, entry)
+		}
+		var weights []string
+		for _, zone := range sortedKeys(sliceGroup.ZoneTrafficWeights) {
+			weights = append(weights, zone+":"+strconv.FormatFloat(sliceGroup.ZoneTrafficWeights[zone], 'f', 2, 64))
+		}
+		fmt.Fprintf(writer, "%s\t%d\t%s\t%s\n", label, sliceGroup.NumberOfEndpoints(), strings.Join(composition, " "), strings.Join(weights, " "))
+	}
+
+	// writing to a strings.Builder never fails
+	_ = writer.Flush()
+	builder.WriteString("\n")
+	writer = tabwriter.NewWriter(&builder, 0, 0, 2, ' ', 0)
+	// deviation: a positive value means the zone has more endpoints than its
+	// proportion of nodes expects
+	fmt.Fprintln(writer, "Zone\tNodes\tEndpoints\tExpected Endpoints\tDeviation")
+	for _, zone := range m.region.ZoneNames() {
+		zoneInfo := m.region.ZoneDetails[zone]
+		expected := zoneInfo.ExpectedEndpoints(m.region.TotalEndpoints)
+		fmt.Fprintf(writer, "%s\t%d\t%d\t%s\t%s\n", zone, zoneInfo.Nodes, zoneInfo.Endpoints,
+			strconv.FormatFloat(expected, 'f', 2, 64), strconv.FormatFloat(float64(zoneInfo.Endpoints)-expected, 'f', 2, 64))
+	}
+	// writing to a strings.Builder never fails
+	_ = writer.Flush()
+	return builder.String()
+}
+
+// sortedKeys returns the keys of a map keyed by zone names in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// SimulateTransition simulates the traffic after the region changes from
+// oldZones to newZones and measures the endpoint turnover caused by the
+// transition. The model state is not changed.
+func (m *Model) SimulateTransition(oldZones []types.Zone, newZones []types.Zone) (types.SimulationResult, error) {
+	oldRegion, err := types.CreateRegionInfo(oldZones)
+	if err != nil {
+		return types.SimulationResult{}, err
+	}
+	oldSlices, err := m.alg.CreateSliceGroups(oldRegion)
+	if err != nil {
+		return types.SimulationResult{}, err
+	}
+	newRegion, err := types.CreateRegionInfo(newZones)
+	if err != nil {
+		return types.SimulationResult{}, err
+	}
+	newSlices, err := m.alg.CreateSliceGroups(newRegion)
+	if err != nil {
+		return types.SimulationResult{}, err
+	}
+	return simulator.ChurnSimulator{Inner: m.simulator}.SimulateTransition(oldRegion, oldSlices, newRegion, newSlices)
+}
+
+// GetSliceGroups returns a deep copy of the current EndpointSliceGroups,
+// modifying the returned map doesn't affect the model
+func (m *Model) GetSliceGroups() map[string]types.EndpointSliceGroup {
+	if m.slices == nil {
+		return nil
+	}
+	slices := make(map[string]types.EndpointSliceGroup, len(m.slices))
+	for label, sliceGroup := range m.slices {
+		slices[label] = sliceGroup.Clone()
+	}
+	return slices
+}
+
+// GetRegionInfo returns a deep copy of the current region, modifying the
+// returned region doesn't affect the model
+func (m *Model) GetRegionInfo() types.RegionInfo {
+	return m.region.Clone()
+}
+
+// GetZoneCount returns the number of zones of the current region
+func (m *Model) GetZoneCount() int {
+	return len(m.region.ZoneDetails)
+}
+
+// IsReady returns true if the region has been updated with endpoints and the
+// EndpointSliceGroups have been created, so the model can be simulated
+func (m *Model) IsReady() bool {
+	return m.region.TotalEndpoints > 0 && m.slices != nil
+}
+
+// GetSliceCapacity returns the number of max endpoints per slice
+func (m *Model) GetSliceCapacity() int {
+	return m.sliceCapacity
+}
+
 // GetNumberOfEndpointSlices returns the number of EndpointSlices
 func (m *Model) GetNumberOfEndpointSlices() int {
+	return m.countEndpointSlices(m.slices)
+}
+
+// countEndpointSlices returns the number of EndpointSlices needed by slices
+func (m *Model) countEndpointSlices(slices map[string]types.EndpointSliceGroup) int {
 	totalSlices := 0
-	for _, slice := range m.slices {
+	for _, slice := range slices {
 		endpoints := slice.NumberOfEndpoints()
-		totalSlices += endpoints / m.SliceCapacity
-		if endpoints%m.SliceCapacity != 0 {
+		totalSlices += endpoints / m.sliceCapacity
+		if endpoints%m.sliceCapacity != 0 {
 			totalSlices++
 		}
 	}
@@ -0,0 +1,448 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package modeling
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/algorithm"
+	"github.com/googleinterns/k8s-topology-simulator/modeling/simulator"
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// zones used across model tests
+var threeZones = []types.Zone{
+	types.Zone{Nodes: 1, Endpoints: 5, Name: "ZoneA"},
+	types.Zone{Nodes: 2, Endpoints: 20, Name: "ZoneB"},
+	types.Zone{Nodes: 7, Endpoints: 20, Name: "ZoneC"},
+}
+
+func TestSimulateTransition(t *testing.T) {
+	fourZones := append(append([]types.Zone{}, threeZones...), types.Zone{Nodes: 2, Endpoints: 10, Name: "ZoneD"})
+	testCases := []struct {
+		name     string
+		alg      algorithm.RoutingAlgorithm
+		expected float64
+	}{
+		// all endpoints stay in the global sliceGroup, only the 10 endpoints
+		// of ZoneD are new: 10 / (45 + 55)
+		{name: "original algorithm", alg: algorithm.OriginalAlgorithm{}, expected: 0.1},
+		// a negative value skips the exact comparison
+		{name: "local algorithm", alg: algorithm.NewAlgorithm("Local"), expected: -1},
+	}
+	for _, testcase := range testCases {
+		t.Run(testcase.name, func(t *testing.T) {
+			model, err := NewModel(testcase.alg, simulator.TheoreticalSimulator{})
+			if err != nil {
+				t.Fatalf("unexpected error creating model: %v", err)
+			}
+			result, err := model.SimulateTransition(threeZones, fourZones)
+			if err != nil {
+				t.Fatalf("unexpected error simulating transition: %v", err)
+			}
+			if testcase.expected >= 0 && math.Abs(result.EndpointTurnover-testcase.expected) > 0.00001 {
+				t.Errorf("got turnover %v, expected %v", result.EndpointTurnover, testcase.expected)
+			}
+			if result.EndpointTurnover <= 0 || result.EndpointTurnover > 1 {
+				t.Errorf("expected turnover in (0, 1], got %v", result.EndpointTurnover)
+			}
+			if model.slices != nil {
+				t.Errorf("expected model state not to be changed by the transition")
+			}
+		})
+	}
+}
+
+func TestCompareAlgorithms(t *testing.T) {
+	names := algorithm.ListAlgorithms()
+	var algs []algorithm.RoutingAlgorithm
+	for _, name := range names {
+		algs = append(algs, algorithm.NewAlgorithm(name))
+	}
+	model, err := NewModel(algorithm.OriginalAlgorithm{}, simulator.TheoreticalSimulator{})
+	if err != nil {
+		t.Fatalf("unexpected error creating model: %v", err)
+	}
+	if _, err := model.CompareAlgorithms(names, algs); err == nil {
+		t.Errorf("expected an error comparing algorithms before updating the region")
+	}
+	if err := model.UpdateRegion(threeZones); err != nil {
+		t.Fatalf("unexpected error updating region: %v", err)
+	}
+	if _, err := model.CompareAlgorithms(names[1:], algs); err == nil {
+		t.Errorf("expected an error comparing algorithms with missing names")
+	}
+	slices := model.slices
+	results, err := model.CompareAlgorithms(names, algs)
+	if err != nil {
+		t.Fatalf("unexpected error comparing algorithms: %v", err)
+	}
+	if len(results) != len(algs) {
+		t.Fatalf("got %d results, expected %d", len(results), len(algs))
+	}
+	// algorithms of the same type, i.e. Local and LocalSliceNoRebalance, are
+	// told apart by their names
+	seen := map[string]bool{}
+	for i, result := range results {
+		if seen[result.AlgorithmName] {
+			t.Errorf("expected unique algorithm names, got %s twice", result.AlgorithmName)
+		}
+		seen[result.AlgorithmName] = true
+		if result.Score < 0 {
+			t.Errorf("expected non-negative score, got %v for %s", result.Score, result.AlgorithmName)
+		}
+		if i > 0 && result.Score > results[i-1].Score {
+			t.Errorf("expected results sorted by score, got %v after %v", result.Score, results[i-1].Score)
+		}
+	}
+	if len(model.slices) != len(slices) || model.slices["global"].NumberOfEndpoints() != 45 {
+		t.Errorf("expected model slices not to be changed, got %+v", model.slices)
+	}
+}
+
+func TestCompareAlgorithmsSliceCapacity(t *testing.T) {
+	model, err := NewModelWithOptions(WithAlgorithm(algorithm.OriginalAlgorithm{}), WithSliceCapacity(10))
+	if err != nil {
+		t.Fatalf("unexpected error creating model: %v", err)
+	}
+	if err := model.UpdateRegion(threeZones); err != nil {
+		t.Fatalf("unexpected error updating region: %v", err)
+	}
+	alg := algorithm.NewAlgorithm("Local")
+	results, err := model.CompareAlgorithms([]string{"Local"}, []algorithm.RoutingAlgorithm{alg})
+	if err != nil {
+		t.Fatalf("unexpected error comparing algorithms: %v", err)
+	}
+	slices, err := alg.CreateSliceGroups(model.region)
+	if err != nil {
+		t.Fatalf("unexpected error creating slices: %v", err)
+	}
+	endpointSlices := model.countEndpointSlices(slices)
+	expected := algorithm.CalculateScore(results[0].SimulationResult, 45, endpointSlices, 10, algorithm.DefaultScoreWeights)
+	if results[0].Score != expected {
+		t.Errorf("got score %v, expected %v scored with slice capacity 10", results[0].Score, expected)
+	}
+	if defaultScore := algorithm.CalculateScore(results[0].SimulationResult, 45, endpointSlices, 100, algorithm.DefaultScoreWeights); results[0].Score == defaultScore {
+		t.Errorf("expected score %v to differ from the score with the default slice capacity", results[0].Score)
+	}
+}
+
+func TestRunMultipleSimulations(t *testing.T) {
+	model, err := NewModelWithOptions(WithAlgorithm(algorithm.NewAlgorithm("LocalShared")), WithRandSeed(1))
+	if err != nil {
+		t.Fatalf("unexpected error creating model: %v", err)
+	}
+	if _, err := model.RunMultipleSimulations(5, 0); err == nil {
+		t.Errorf("expected an error running simulations before updating the region")
+	}
+	if err := model.UpdateRegion(threeZones); err != nil {
+		t.Fatalf("unexpected error updating region: %v", err)
+	}
+	expected, err := model.StartSimulation()
+	if err != nil {
+		t.Fatalf("unexpected error simulating: %v", err)
+	}
+
+	aggregate, err := model.RunMultipleSimulations(10, 0)
+	if err != nil {
+		t.Fatalf("unexpected error running simulations: %v", err)
+	}
+	identical := types.MetricSummary{Mean: expected.InZoneTraffic, Min: expected.InZoneTraffic, Max: expected.InZoneTraffic}
+	if aggregate.Runs != 10 || aggregate.InvalidRuns != 0 || !compareSummary(aggregate.InZoneTraffic, identical) {
+		t.Errorf("expected 10 identical runs with in-zone traffic %v, got %+v", expected.InZoneTraffic, aggregate)
+	}
+	if aggregate.MaxDeviation.StdDev > 1e-12 || aggregate.MeanDeviation.StdDev > 1e-12 {
+		t.Errorf("expected no variation without perturbation, got %+v", aggregate)
+	}
+
+	aggregate, err = model.RunMultipleSimulations(20, 0.5)
+	if err != nil {
+		t.Fatalf("unexpected error running perturbed simulations: %v", err)
+	}
+	if aggregate.InZoneTraffic.Min > aggregate.InZoneTraffic.Mean || aggregate.InZoneTraffic.Mean > aggregate.InZoneTraffic.Max {
+		t.Errorf("expected min <= mean <= max, got %+v", aggregate.InZoneTraffic)
+	}
+}
+
+func TestRunMultipleSimulationsSeeded(t *testing.T) {
+	model, err := NewModelWithOptions(WithAlgorithm(algorithm.NewAlgorithm("LocalShared")), WithRandSeed(42))
+	if err != nil {
+		t.Fatalf("unexpected error creating model: %v", err)
+	}
+	if err := model.UpdateRegion(threeZones); err != nil {
+		t.Fatalf("unexpected error updating region: %v", err)
+	}
+	first, err := model.RunMultipleSimulations(20, 0.5)
+	if err != nil {
+		t.Fatalf("unexpected error running perturbed simulations: %v", err)
+	}
+	second, err := model.RunMultipleSimulations(20, 0.5)
+	if err != nil {
+		t.Fatalf("unexpected error running perturbed simulations: %v", err)
+	}
+	if first.Runs != second.Runs || first.InvalidRuns != second.InvalidRuns || !compareSummary(first.InZoneTraffic, second.InZoneTraffic) ||
+		!compareSummary(first.MeanDeviation, second.MeanDeviation) || !compareSummary(first.MaxDeviation, second.MaxDeviation) {
+		t.Errorf("expected identical results with a fixed seed, got %+v and %+v", first, second)
+	}
+}
+
+func TestRunMultipleSimulationsConcurrently(t *testing.T) {
+	model, err := NewModelWithOptions(WithAlgorithm(algorithm.NewAlgorithm("LocalShared")), WithRandSeed(1))
+	if err != nil {
+		t.Fatalf("unexpected error creating model: %v", err)
+	}
+	if err := model.UpdateRegion(threeZones); err != nil {
+		t.Fatalf("unexpected error updating region: %v", err)
+	}
+	// every call perturbs endpoints with its own random source seeded the same,
+	// run with -race to detect a shared one
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			aggregate, err := model.RunMultipleSimulations(20, 0.5)
+			if err != nil {
+				t.Errorf("unexpected error running perturbed simulations: %v", err)
+				return
+			}
+			if aggregate.Runs != 20 {
+				t.Errorf("expected 20 runs, got %+v", aggregate)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// compareSummary compares two metric summaries within a float epsilon
+func compareSummary(a types.MetricSummary, b types.MetricSummary) bool {
+	return math.Abs(a.Mean-b.Mean) < 1e-9 && math.Abs(a.StdDev-b.StdDev) < 1e-9 &&
+		math.Abs(a.Min-b.Min) < 1e-9 && math.Abs(a.Max-b.Max) < 1e-9
+}
+
+func TestNewModelWithOptions(t *testing.T) {
+	if _, err := NewModelWithOptions(WithSimulator(simulator.TheoreticalSimulator{})); err == nil {
+		t.Errorf("expected an error creating model without algorithm")
+	}
+	if _, err := NewModelWithOptions(WithAlgorithm(algorithm.OriginalAlgorithm{}), WithSliceCapacity(0)); err == nil {
+		t.Errorf("expected an error creating model with zero slice capacity")
+	}
+	testCases := []struct {
+		name           string
+		opts           []ModelOption
+		endpointSlices int
+	}{
+		{name: "default slice capacity", opts: nil, endpointSlices: 1},
+		{name: "slice capacity 50", opts: []ModelOption{WithSliceCapacity(50)}, endpointSlices: 2},
+		{name: "slice capacity 10", opts: []ModelOption{WithSliceCapacity(10)}, endpointSlices: 6},
+	}
+	zones := []types.Zone{
+		types.Zone{Nodes: 1, Endpoints: 30, Name: "ZoneA"},
+		types.Zone{Nodes: 1, Endpoints: 30, Name: "ZoneB"},
+	}
+	for _, testcase := range testCases {
+		t.Run(testcase.name, func(t *testing.T) {
+			model, err := NewModelWithOptions(append(testcase.opts, WithAlgorithm(algorithm.OriginalAlgorithm{}))...)
+			if err != nil {
+				t.Fatalf("unexpected error creating model: %v", err)
+			}
+			if err := model.UpdateRegion(zones); err != nil {
+				t.Fatalf("unexpected error updating region: %v", err)
+			}
+			if slices := model.GetNumberOfEndpointSlices(); slices != testcase.endpointSlices {
+				t.Errorf("got %d EndpointSlices, expected %d", slices, testcase.endpointSlices)
+			}
+		})
+	}
+}
+
+func TestExplain(t *testing.T) {
+	model, err := NewModelWithOptions(WithAlgorithm(algorithm.NewAlgorithm("Local")))
+	if err != nil {
+		t.Fatalf("unexpected error creating model: %v", err)
+	}
+	if explanation := model.Explain(); !strings.Contains(explanation, "no region") {
+		t.Errorf("expected explanation of an empty model, got %s", explanation)
+	}
+	if err := model.UpdateRegion(threeZones); err != nil {
+		t.Fatalf("unexpected error updating region: %v", err)
+	}
+	explanation := model.Explain()
+	for _, expected := range []string{
+		"ZoneA:5",
+		"ZoneB:9",
+		"ZoneB:11 ZoneC:20",
+		"ZoneC:1.00",
+		// ZoneC: 7 nodes out of 10, 0.7 * 45 endpoints expected
+		"31.50",
+		"-11.50",
+	} {
+		if !strings.Contains(explanation, expected) {
+			t.Errorf("expected explanation to contain %q, got\n%s", expected, explanation)
+		}
+	}
+	if explanation != model.Explain() {
+		t.Errorf("expected a stable explanation")
+	}
+}
+
+func TestGetSliceGroups(t *testing.T) {
+	model, err := NewModelWithOptions(WithAlgorithm(algorithm.NewAlgorithm("Local")))
+	if err != nil {
+		t.Fatalf("unexpected error creating model: %v", err)
+	}
+	if slices := model.GetSliceGroups(); slices != nil {
+		t.Errorf("expected nil sliceGroups before updating the region, got %+v", slices)
+	}
+	if err := model.UpdateRegion(threeZones); err != nil {
+		t.Fatalf("unexpected error updating region: %v", err)
+	}
+	expected, err := model.StartSimulation()
+	if err != nil {
+		t.Fatalf("unexpected error simulating: %v", err)
+	}
+	slices := model.GetSliceGroups()
+	if len(slices) != 3 || slices["ZoneC"].Composition["ZoneB"].Number != 11 {
+		t.Fatalf("got unexpected sliceGroups %+v", slices)
+	}
+	slices["ZoneC"].Composition["ZoneB"] = types.WeightedEndpoints{Number: 0, Weight: 1}
+	slices["ZoneC"].ZoneTrafficWeights["ZoneA"] = 1
+	delete(slices, "ZoneA")
+
+	result, err := model.StartSimulation()
+	if err != nil {
+		t.Fatalf("unexpected error simulating: %v", err)
+	}
+	if math.Abs(result.InZoneTraffic-expected.InZoneTraffic) > 1e-9 || math.Abs(result.MaxDeviation-expected.MaxDeviation) > 1e-9 {
+		t.Errorf("expected simulation result not to be changed by modifying returned sliceGroups, got %+v, expected %+v", result, expected)
+	}
+}
+
+func TestReset(t *testing.T) {
+	model, err := NewModelWithOptions(WithAlgorithm(algorithm.NewAlgorithm("Local")))
+	if err != nil {
+		t.Fatalf("unexpected error creating model: %v", err)
+	}
+	if err := model.UpdateRegion(threeZones); err != nil {
+		t.Fatalf("unexpected error updating region: %v", err)
+	}
+	invalidZones := []types.Zone{types.Zone{Nodes: -1, Endpoints: 1, Name: "ZoneA"}}
+	if err := model.UpdateRegion(invalidZones); err == nil {
+		t.Fatalf("expected an error updating region with invalid zones")
+	}
+	if model.slices != nil || model.region.ZoneDetails != nil {
+		t.Errorf("expected a failed update to leave the model reset, got %+v, %+v", model.region, model.slices)
+	}
+	if _, err := model.StartSimulation(); err == nil {
+		t.Errorf("expected an error simulating after a failed update")
+	}
+
+	model.Reset()
+	if _, err := model.StartSimulation(); err == nil {
+		t.Errorf("expected an error simulating a reset model")
+	}
+	if err := model.UpdateRegion(threeZones); err != nil {
+		t.Fatalf("unexpected error updating region after reset: %v", err)
+	}
+	if _, err := model.StartSimulation(); err != nil {
+		t.Errorf("unexpected error simulating after reset and update: %v", err)
+	}
+}
+
+func TestUpdateAlgorithm(t *testing.T) {
+	model, err := NewModel(algorithm.OriginalAlgorithm{}, simulator.TheoreticalSimulator{})
+	if err != nil {
+		t.Fatalf("unexpected error creating model: %v", err)
+	}
+	if err := model.UpdateRegion(threeZones); err != nil {
+		t.Fatalf("unexpected error updating region: %v", err)
+	}
+	original, err := model.StartSimulation()
+	if err != nil {
+		t.Fatalf("unexpected error simulating: %v", err)
+	}
+
+	if err := model.UpdateAlgorithm(nil); err == nil {
+		t.Errorf("expected an error updating model with nil algorithm")
+	}
+	if err := model.UpdateAlgorithm(algorithm.NewAlgorithm("Local")); err != nil {
+		t.Fatalf("unexpected error updating algorithm: %v", err)
+	}
+	// the region and sliceGroups of OriginalAlgorithm are kept until the next
+	// UpdateRegion call
+	kept, err := model.StartSimulation()
+	if err != nil {
+		t.Fatalf("unexpected error simulating after updating algorithm: %v", err)
+	}
+	if kept.InZoneTraffic != original.InZoneTraffic {
+		t.Errorf("expected in-zone traffic %v before the next region update, got %v", original.InZoneTraffic, kept.InZoneTraffic)
+	}
+
+	if err := model.UpdateRegion(threeZones); err != nil {
+		t.Fatalf("unexpected error updating region: %v", err)
+	}
+	local, err := model.StartSimulation()
+	if err != nil {
+		t.Fatalf("unexpected error simulating: %v", err)
+	}
+	if local.InZoneTraffic == original.InZoneTraffic {
+		t.Errorf("expected in-zone traffic of LocalSliceAlgorithm to differ from OriginalAlgorithm, both got %v", local.InZoneTraffic)
+	}
+}
+
+func TestGetRegionInfo(t *testing.T) {
+	model, err := NewModelWithOptions(WithAlgorithm(algorithm.NewAlgorithm("Local")))
+	if err != nil {
+		t.Fatalf("unexpected error creating model: %v", err)
+	}
+	if model.IsReady() || model.GetZoneCount() != 0 || model.GetRegionInfo().ZoneDetails != nil {
+		t.Errorf("expected an uninitialized model not to be ready and have no zones, got %+v", model.GetRegionInfo())
+	}
+	if _, err := model.StartSimulation(); err == nil {
+		t.Errorf("expected an error simulating an uninitialized model")
+	}
+
+	if err := model.UpdateRegion(threeZones); err != nil {
+		t.Fatalf("unexpected error updating region: %v", err)
+	}
+	if !model.IsReady() || model.GetZoneCount() != 3 {
+		t.Errorf("expected a ready model with 3 zones, got %d zones", model.GetZoneCount())
+	}
+	region := model.GetRegionInfo()
+	if region.TotalEndpoints != model.GetNumberOfEndpoints() || len(region.ZoneDetails) != 3 {
+		t.Fatalf("got unexpected region %+v", region)
+	}
+	region.ZoneDetails["ZoneA"] = types.Zone{Name: "ZoneA"}
+	delete(region.ZoneDetails, "ZoneB")
+	if actual := model.GetRegionInfo(); len(actual.ZoneDetails) != 3 || actual.ZoneDetails["ZoneA"].Endpoints == 0 {
+		t.Errorf("expected region not to be changed by modifying returned region, got %+v", actual)
+	}
+
+	noEndpoints := []types.Zone{{Name: "ZoneA", Nodes: 1}, {Name: "ZoneB", Nodes: 2}}
+	if err := model.UpdateRegion(noEndpoints); err != nil {
+		t.Fatalf("unexpected error updating region without endpoints: %v", err)
+	}
+	if model.IsReady() || model.GetZoneCount() != 2 {
+		t.Errorf("expected a model with 2 zones without endpoints not to be ready")
+	}
+	if _, err := model.StartSimulation(); err == nil {
+		t.Errorf("expected an error simulating a region without endpoints")
+	}
+}
@@ -0,0 +1,80 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"errors"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// ChurnSimulator simulates the traffic after a topology transition (i.e. a new
+// zone is added) and measures how many endpoints are reassigned between
+// EndpointSliceGroups by the transition
+type ChurnSimulator struct {
+	// Inner simulator used to simulate traffic after the transition
+	Inner TrafficSimulator
+}
+
+// SimulateTransition simulates the traffic of the new state with the Inner
+// simulator and records the endpoint turnover from the old state in the result
+func (sim ChurnSimulator) SimulateTransition(oldRegion types.RegionInfo, oldSlices map[string]types.EndpointSliceGroup, newRegion types.RegionInfo, newSlices map[string]types.EndpointSliceGroup) (types.SimulationResult, error) {
+	if sim.Inner == nil {
+		return types.SimulationResult{}, errors.New("can't simulate transition with nil inner simulator")
+	}
+	result, err := sim.Inner.Simulate(newRegion, newSlices)
+	if err != nil {
+		return types.SimulationResult{}, err
+	}
+	result.EndpointTurnover = EndpointTurnoverRatio(oldRegion, oldSlices, newRegion, newSlices)
+	return result, nil
+}
+
+// EndpointTurnoverRatio calculates the size of the symmetric difference between
+// endpoints assigned in old and new EndpointSliceGroups, normalized by the
+// endpoints of both states. Moving one endpoint from one sliceGroup to another
+// counts once in each state, so the ratio equals the fraction of moved
+// endpoints when the total stays the same, and 1 if nothing is kept in place.
+func EndpointTurnoverRatio(oldRegion types.RegionInfo, oldSlices map[string]types.EndpointSliceGroup, newRegion types.RegionInfo, newSlices map[string]types.EndpointSliceGroup) float64 {
+	totalEndpoints := oldRegion.TotalEndpoints + newRegion.TotalEndpoints
+	if totalEndpoints == 0 {
+		return 0
+	}
+	changed := 0
+	// assignments only in the old state or changed in the new state
+	for label, oldGroup := range oldSlices {
+		for zone, endpoints := range oldGroup.Composition {
+			changed += absInt(newSlices[label].Composition[zone].Number - endpoints.Number)
+		}
+	}
+	// assignments only in the new state
+	for label, newGroup := range newSlices {
+		for zone, endpoints := range newGroup.Composition {
+			if _, ok := oldSlices[label].Composition[zone]; !ok {
+				changed += endpoints.Number
+			}
+		}
+	}
+	return float64(changed) / float64(totalEndpoints)
+}
+
+func absInt(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
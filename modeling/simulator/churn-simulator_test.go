@@ -0,0 +1,79 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+func TestEndpointTurnoverRatio(t *testing.T) {
+	oldZones := []types.Zone{
+		types.Zone{Nodes: 1, Endpoints: 3, Name: "ZoneA"},
+		types.Zone{Nodes: 1, Endpoints: 3, Name: "ZoneB"},
+		types.Zone{Nodes: 1, Endpoints: 4, Name: "ZoneC"},
+	}
+	newZones := append(oldZones, types.Zone{Nodes: 1, Endpoints: 0, Name: "ZoneD"})
+	oldRegion, err := types.CreateRegionInfo(oldZones)
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	newRegion, err := types.CreateRegionInfo(newZones)
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	oldSlices := map[string]types.EndpointSliceGroup{
+		"ZoneA": {Label: "ZoneA", Composition: map[string]types.WeightedEndpoints{"ZoneA": {Number: 3, Weight: 1}}, ZoneTrafficWeights: map[string]float64{"ZoneA": 1}},
+		"ZoneB": {Label: "ZoneB", Composition: map[string]types.WeightedEndpoints{"ZoneB": {Number: 3, Weight: 1}}, ZoneTrafficWeights: map[string]float64{"ZoneB": 1}},
+		"ZoneC": {Label: "ZoneC", Composition: map[string]types.WeightedEndpoints{"ZoneC": {Number: 4, Weight: 1}}, ZoneTrafficWeights: map[string]float64{"ZoneC": 1}},
+	}
+	// ZoneD receives one endpoint from ZoneC and one from ZoneA
+	newSlices := map[string]types.EndpointSliceGroup{
+		"ZoneA": {Label: "ZoneA", Composition: map[string]types.WeightedEndpoints{"ZoneA": {Number: 2, Weight: 1}}, ZoneTrafficWeights: map[string]float64{"ZoneA": 1}},
+		"ZoneB": {Label: "ZoneB", Composition: map[string]types.WeightedEndpoints{"ZoneB": {Number: 3, Weight: 1}}, ZoneTrafficWeights: map[string]float64{"ZoneB": 1}},
+		"ZoneC": {Label: "ZoneC", Composition: map[string]types.WeightedEndpoints{"ZoneC": {Number: 3, Weight: 1}}, ZoneTrafficWeights: map[string]float64{"ZoneC": 1}},
+		"ZoneD": {Label: "ZoneD", Composition: map[string]types.WeightedEndpoints{"ZoneA": {Number: 1, Weight: 1}, "ZoneC": {Number: 1, Weight: 1}}, ZoneTrafficWeights: map[string]float64{"ZoneD": 1}},
+	}
+	testCases := []struct {
+		name      string
+		oldSlices map[string]types.EndpointSliceGroup
+		newSlices map[string]types.EndpointSliceGroup
+		expected  float64
+	}{
+		{name: "no transition", oldSlices: oldSlices, newSlices: oldSlices, expected: 0},
+		{name: "2 of 10 endpoints moved to a new zone", oldSlices: oldSlices, newSlices: newSlices, expected: 0.2},
+		{name: "all endpoints dropped from sliceGroups", oldSlices: oldSlices, newSlices: map[string]types.EndpointSliceGroup{}, expected: 0.5},
+	}
+	for _, testcase := range testCases {
+		t.Run(testcase.name, func(t *testing.T) {
+			turnover := EndpointTurnoverRatio(oldRegion, testcase.oldSlices, newRegion, testcase.newSlices)
+			if math.Abs(turnover-testcase.expected) > 0.00001 {
+				t.Errorf("got turnover %v, expected %v", turnover, testcase.expected)
+			}
+		})
+	}
+
+	result, err := ChurnSimulator{Inner: TheoreticalSimulator{}}.SimulateTransition(oldRegion, oldSlices, newRegion, newSlices)
+	if err != nil {
+		t.Fatalf("unexpected error simulating transition: %v", err)
+	}
+	if math.Abs(result.EndpointTurnover-0.2) > 0.00001 || result.Invalid {
+		t.Errorf("expected a valid result with 0.2 turnover, got %+v", result)
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"errors"
+	"math"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// tCritical95 stores the two-sided 95% critical values of the t-distribution
+// indexed by degrees of freedom - 1, used when there are less than 30 runs
+var tCritical95 = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045,
+}
+
+// zCritical95 is the two-sided 95% critical value of the normal distribution
+const zCritical95 = 1.96
+
+// RepeatedSimulator runs the Inner simulator multiple times and aggregates the
+// results, reporting the mean of every metric together with 95% confidence
+// intervals of InZoneTraffic and MeanDeviation
+type RepeatedSimulator struct {
+	// Inner simulator to run repeatedly
+	Inner TrafficSimulator
+	// Runs is the number of times Inner is called
+	Runs int
+}
+
+// Simulate calls the Inner simulator Runs times and returns the averaged
+// result with confidence intervals populated
+func (sim RepeatedSimulator) Simulate(region types.RegionInfo, endpointSlices map[string]types.EndpointSliceGroup) (types.SimulationResult, error) {
+	if sim.Inner == nil {
+		return types.SimulationResult{}, errors.New("can't run repeated simulation with nil inner simulator")
+	}
+	if sim.Runs < 1 {
+		return types.SimulationResult{}, errors.New("number of runs of repeated simulation should be at least 1")
+	}
+	var inZoneTraffic, meanDeviation, maxDeviation, deviationSD []float64
+	var result types.SimulationResult
+	for run := 0; run < sim.Runs; run++ {
+		runResult, err := sim.Inner.Simulate(region, endpointSlices)
+		if err != nil {
+			return types.SimulationResult{}, err
+		}
+		// an invalid run can't be averaged with other runs, the aggregated
+		// result is invalid as well
+		if runResult.Invalid {
+			return types.SimulationResult{Invalid: true}, nil
+		}
+		if run == 0 {
+			result.TrafficDistribution = runResult.TrafficDistribution
+			result.ZoneTrafficMatrix = runResult.ZoneTrafficMatrix
+			result.InZoneTrafficByZone = runResult.InZoneTrafficByZone
+		}
+		inZoneTraffic = append(inZoneTraffic, runResult.InZoneTraffic)
+		meanDeviation = append(meanDeviation, runResult.MeanDeviation)
+		maxDeviation = append(maxDeviation, runResult.MaxDeviation)
+		deviationSD = append(deviationSD, runResult.DeviationSD)
+	}
+	result.InZoneTraffic, result.InZoneTrafficCI95 = confidenceInterval95(inZoneTraffic)
+	result.MeanDeviation, result.MeanDeviationCI95 = confidenceInterval95(meanDeviation)
+	result.MaxDeviation, _ = confidenceInterval95(maxDeviation)
+	result.DeviationSD, _ = confidenceInterval95(deviationSD)
+	return result, nil
+}
+
+// confidenceInterval95 returns the mean of samples and its 95% confidence
+// interval. The t-distribution is used for less than 30 samples, the normal
+// approximation otherwise.
+func confidenceInterval95(samples []float64) (float64, [2]float64) {
+	n := len(samples)
+	var sum float64
+	for _, sample := range samples {
+		sum += sample
+	}
+	mean := sum / float64(n)
+	if n < 2 {
+		return mean, [2]float64{mean, mean}
+	}
+	var squareSum float64
+	for _, sample := range samples {
+		squareSum += math.Pow(sample-mean, 2)
+	}
+	// sample standard deviation
+	sd := math.Sqrt(squareSum / float64(n-1))
+	critical := zCritical95
+	if n < 30 {
+		critical = tCritical95[n-2]
+	}
+	margin := critical * sd / math.Sqrt(float64(n))
+	return mean, [2]float64{mean - margin, mean + margin}
+}
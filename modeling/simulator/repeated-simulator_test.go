@@ -0,0 +1,96 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// noisySimulator returns results around a known true mean with gaussian noise
+type noisySimulator struct {
+	random        *rand.Rand
+	inZoneTraffic float64
+	meanDeviation float64
+	noise         float64
+}
+
+func (sim noisySimulator) Simulate(types.RegionInfo, map[string]types.EndpointSliceGroup) (types.SimulationResult, error) {
+	return types.SimulationResult{
+		InZoneTraffic: sim.inZoneTraffic + sim.random.NormFloat64()*sim.noise,
+		MeanDeviation: sim.meanDeviation + sim.random.NormFloat64()*sim.noise,
+	}, nil
+}
+
+func TestRepeatedSimulatorCoverage(t *testing.T) {
+	for _, runs := range []int{10, 50} {
+		inner := noisySimulator{random: rand.New(rand.NewSource(1)), inZoneTraffic: 0.7, meanDeviation: 0.2, noise: 0.05}
+		sim := RepeatedSimulator{Inner: inner, Runs: runs}
+		inZoneCovered, deviationCovered := 0, 0
+		for experiment := 0; experiment < 100; experiment++ {
+			result, err := sim.Simulate(types.RegionInfo{}, nil)
+			if err != nil {
+				t.Fatalf("[runs %d] unexpected error: %v", runs, err)
+			}
+			if result.InZoneTrafficCI95[0] <= inner.inZoneTraffic && inner.inZoneTraffic <= result.InZoneTrafficCI95[1] {
+				inZoneCovered++
+			}
+			if result.MeanDeviationCI95[0] <= inner.meanDeviation && inner.meanDeviation <= result.MeanDeviationCI95[1] {
+				deviationCovered++
+			}
+		}
+		if inZoneCovered < 90 || deviationCovered < 90 {
+			t.Errorf("[runs %d] expected CI95 to contain the true mean in at least 90 of 100 experiments, got in-zone: %d, deviation: %d",
+				runs, inZoneCovered, deviationCovered)
+		}
+	}
+}
+
+func TestRepeatedSimulatorDeterministicInner(t *testing.T) {
+	zones := []types.Zone{
+		types.Zone{Nodes: 1, Endpoints: 2, Name: "ZoneA"},
+		types.Zone{Nodes: 1, Endpoints: 2, Name: "ZoneB"},
+	}
+	region, err := types.CreateRegionInfo(zones)
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	slices := map[string]types.EndpointSliceGroup{
+		"global": types.EndpointSliceGroup{
+			Label:              "global",
+			Composition:        map[string]types.WeightedEndpoints{"ZoneA": {Number: 2, Weight: 1}, "ZoneB": {Number: 2, Weight: 1}},
+			ZoneTrafficWeights: map[string]float64{"ZoneA": 1, "ZoneB": 1},
+		},
+	}
+	expected, err := TheoreticalSimulator{}.Simulate(region, slices)
+	if err != nil {
+		t.Fatalf("unexpected error simulating: %v", err)
+	}
+	result, err := RepeatedSimulator{Inner: TheoreticalSimulator{}, Runs: 5}.Simulate(region, slices)
+	if err != nil {
+		t.Fatalf("unexpected error simulating repeatedly: %v", err)
+	}
+	if result.InZoneTraffic != expected.InZoneTraffic || result.InZoneTrafficCI95 != [2]float64{expected.InZoneTraffic, expected.InZoneTraffic} {
+		t.Errorf("expected a zero-width CI around %v for a deterministic simulator, got %v %v",
+			expected.InZoneTraffic, result.InZoneTraffic, result.InZoneTrafficCI95)
+	}
+	if _, err := (RepeatedSimulator{Inner: TheoreticalSimulator{}}).Simulate(region, slices); err == nil {
+		t.Errorf("expected an error with 0 runs")
+	}
+}
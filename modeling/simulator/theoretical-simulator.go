@@ -112,7 +112,7 @@ func (zd zoneSGDetails) getEndpointsTrafficLoadDetails(region types.RegionInfo,
 				continue
 			}
 			// calcualte the ratio of the endpoints in the sliceGroup
-			zoneRatioInSG := float64(sliceGroup.Composition[zone].Number) * sliceGroup.Composition[zone].Weight / sliceGroup.NumberOfWeightedEndpoints()
+			zoneRatioInSG := sliceGroup.Composition[zone].EffectiveEndpoints() / sliceGroup.NumberOfWeightedEndpoints()
 			// zone endpoints traffic load in this sliceGroup = sliceGroup
 			// traffic * zone ratio in this sliceGroup
 			trafficLoad := sgTrafficRatio[label] * zoneRatioInSG / float64(sliceGroup.Composition[zone].Number)
@@ -134,7 +134,7 @@ func (zd zoneSGDetails) getZoneToZoneTraffic(region types.RegionInfo, endpointSl
 				continue
 			}
 			for destZone := range region.ZoneDetails {
-				desZoneRatioInSG := float64(sliceGroup.Composition[destZone].Number) * sliceGroup.Composition[destZone].Weight / sliceGroup.NumberOfWeightedEndpoints()
+				desZoneRatioInSG := sliceGroup.Composition[destZone].EffectiveEndpoints() / sliceGroup.NumberOfWeightedEndpoints()
 				// traffic oriZone -> desZone: sum(traffic distribution of
 				// oriZone * traffic ratio from oriZone to this sliceGroup *
 				// desZone ratio in this sliceGroup)
@@ -166,12 +166,18 @@ func getSimulationResult(zd zoneSGDetails, region types.RegionInfo, endpointSlic
 	var simResult types.SimulationResult
 	// traffic distribution details by zone
 	simResult.TrafficDistribution = map[string]types.ZoneTraffic{}
+	simResult.InZoneTrafficByZone = map[string]float64{}
 
 	var totalDeviation float64
 	var maxDeviation float64
 	for zoneName, zoneInfo := range region.ZoneDetails {
 		// zoneX -> zoneX forms inzone traffic
 		simResult.InZoneTraffic += zoneTrafficToZone[zoneName][zoneName]
+		// zoneTrafficToZone is the ratio of all traffic, in-zone traffic of
+		// a zone is relative to the traffic sent from the zone
+		if zoneInfo.NodesRatio > 0 {
+			simResult.InZoneTrafficByZone[zoneName] = zoneTrafficToZone[zoneName][zoneName] / zoneInfo.NodesRatio
+		}
 		zoneMaxDeviation := 0.0
 		zoneDeviation := 0.0
 		var maxLabel string
@@ -198,6 +204,8 @@ func getSimulationResult(zd zoneSGDetails, region types.RegionInfo, endpointSlic
 		traffic.ZoneTrafficDetail.MeanDeviation = zoneDeviation / float64(zoneInfo.Endpoints)
 		traffic.ZoneTrafficDetail.EndpointsTrafficLoad = zd[zoneName].endpointsTrafficLoad
 		traffic.ZoneTrafficDetail.EndpointsTrafficLoadDeviation = zd[zoneName].endpointsTrafficLoadDeviation
+		traffic.ZoneTrafficDetail.P95Deviation = traffic.ZoneTrafficDetail.PercentileDeviation(0.95)
+		traffic.ZoneTrafficDetail.P99Deviation = traffic.ZoneTrafficDetail.PercentileDeviation(0.99)
 
 		simResult.TrafficDistribution[zoneName] = traffic
 	}
@@ -213,6 +221,7 @@ func getSimulationResult(zd zoneSGDetails, region types.RegionInfo, endpointSlic
 	}
 	deviationSD = math.Sqrt(squareSum / float64(region.TotalEndpoints))
 
+	simResult.ZoneTrafficMatrix = zoneTrafficToZone
 	simResult.MaxDeviation = maxDeviation
 	simResult.MeanDeviation = meanDeviation
 	simResult.DeviationSD = deviationSD
@@ -0,0 +1,165 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+func TestTheoreticalSimulatorInZoneTrafficByZone(t *testing.T) {
+	zones := []types.Zone{
+		types.Zone{Nodes: 1, Endpoints: 5, Name: "ZoneA"},
+		types.Zone{Nodes: 2, Endpoints: 20, Name: "ZoneB"},
+		types.Zone{Nodes: 7, Endpoints: 20, Name: "ZoneC"},
+	}
+	region, err := types.CreateRegionInfo(zones)
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	testCases := []struct {
+		name     string
+		slices   map[string]types.EndpointSliceGroup
+		expected map[string]float64
+	}{
+		{
+			name: "global slice group",
+			slices: map[string]types.EndpointSliceGroup{
+				"global": {
+					Label:              "global",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneA": {Number: 5, Weight: 1}, "ZoneB": {Number: 20, Weight: 1}, "ZoneC": {Number: 20, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneA": 1, "ZoneB": 1, "ZoneC": 1},
+				},
+			},
+			expected: map[string]float64{"ZoneA": 5.0 / 45, "ZoneB": 20.0 / 45, "ZoneC": 20.0 / 45},
+		},
+		{
+			name: "local slice groups",
+			slices: map[string]types.EndpointSliceGroup{
+				"ZoneA": {
+					Label:              "ZoneA",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneA": {Number: 5, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneA": 1},
+				},
+				"ZoneB": {
+					Label:              "ZoneB",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneB": {Number: 9, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneB": 1},
+				},
+				"ZoneC": {
+					Label:              "ZoneC",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneB": {Number: 11, Weight: 1}, "ZoneC": {Number: 20, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneC": 1},
+				},
+			},
+			expected: map[string]float64{"ZoneA": 1, "ZoneB": 1, "ZoneC": 20.0 / 31},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := TheoreticalSimulator{}.Simulate(region, tc.slices)
+			if err != nil {
+				t.Fatalf("unexpected error simulating: %v", err)
+			}
+			weightedSum := 0.0
+			for zoneName, zone := range region.ZoneDetails {
+				if math.Abs(result.InZoneTrafficByZone[zoneName]-tc.expected[zoneName]) > 1e-9 {
+					t.Errorf("expected in-zone traffic %v of %s, got %v", tc.expected[zoneName], zoneName, result.InZoneTrafficByZone[zoneName])
+				}
+				weightedSum += result.InZoneTrafficByZone[zoneName] * zone.NodesRatio
+			}
+			if math.Abs(weightedSum-result.InZoneTraffic) > 1e-9 {
+				t.Errorf("expected in-zone traffic of zones weighted by nodes ratio to sum up to %v, got %v", result.InZoneTraffic, weightedSum)
+			}
+		})
+	}
+}
+
+func TestTheoreticalSimulatorZeroTrafficWeights(t *testing.T) {
+	region, err := types.CreateRegionInfo([]types.Zone{
+		types.Zone{Nodes: 1, Endpoints: 2, Name: "ZoneA"},
+		types.Zone{Nodes: 1, Endpoints: 2, Name: "ZoneB"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	// ZoneB can't reach any endpoint with all its traffic weights set to 0
+	slices := map[string]types.EndpointSliceGroup{
+		"ZoneA": {
+			Label:              "ZoneA",
+			Composition:        map[string]types.WeightedEndpoints{"ZoneA": {Number: 2, Weight: 1}},
+			ZoneTrafficWeights: map[string]float64{"ZoneA": 1, "ZoneB": 0},
+		},
+		"ZoneB": {
+			Label:              "ZoneB",
+			Composition:        map[string]types.WeightedEndpoints{"ZoneB": {Number: 2, Weight: 1}},
+			ZoneTrafficWeights: map[string]float64{"ZoneB": 0},
+		},
+	}
+	result, err := TheoreticalSimulator{}.Simulate(region, slices)
+	if err != nil {
+		t.Fatalf("unexpected error simulating: %v", err)
+	}
+	if !result.Invalid {
+		t.Errorf("expected an invalid result with a zone reaching no endpoints, got %+v", result)
+	}
+}
+
+func TestTheoreticalSimulatorZoneWithoutEndpointsInSliceGroup(t *testing.T) {
+	region, err := types.CreateRegionInfo([]types.Zone{
+		types.Zone{Nodes: 1, Endpoints: 2, Name: "ZoneA"},
+		types.Zone{Nodes: 1, Endpoints: 2, Name: "ZoneB"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	// ZoneA has an entry of 0 endpoints in the global sliceGroup
+	slices := map[string]types.EndpointSliceGroup{
+		"ZoneA": {
+			Label:              "ZoneA",
+			Composition:        map[string]types.WeightedEndpoints{"ZoneA": {Number: 2, Weight: 1}},
+			ZoneTrafficWeights: map[string]float64{"ZoneA": 1},
+		},
+		"global": {
+			Label:              "global",
+			Composition:        map[string]types.WeightedEndpoints{"ZoneA": {Number: 0, Weight: 1}, "ZoneB": {Number: 2, Weight: 1}},
+			ZoneTrafficWeights: map[string]float64{"ZoneB": 1},
+		},
+	}
+	result, err := TheoreticalSimulator{}.Simulate(region, slices)
+	if err != nil {
+		t.Fatalf("unexpected error simulating: %v", err)
+	}
+	if result.Invalid {
+		t.Fatalf("expected a valid result, got %+v", result)
+	}
+	if _, ok := result.TrafficDistribution["ZoneA"].ZoneTrafficDetail.EndpointsTrafficLoad["global"]; ok {
+		t.Errorf("expected no traffic load of ZoneA in the global sliceGroup without its endpoints")
+	}
+	for zoneName, traffic := range result.TrafficDistribution {
+		for label, deviation := range traffic.ZoneTrafficDetail.EndpointsTrafficLoadDeviation {
+			if math.IsNaN(deviation) || math.IsInf(deviation, 0) {
+				t.Errorf("expected a finite deviation of %s in %s, got %v", zoneName, label, deviation)
+			}
+		}
+	}
+	if result.MaxDeviation != 0 || result.InZoneTraffic != 1 {
+		t.Errorf("expected balanced in-zone traffic, got max deviation %v and in-zone traffic %v", result.MaxDeviation, result.InZoneTraffic)
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// ZoneFailureSimulator simulates the traffic distribution when FailedZone goes
+// offline: its nodes stop sending traffic and its endpoints stop receiving
+// traffic, while EndpointSliceGroups stay as they were before the failure.
+type ZoneFailureSimulator struct {
+	// FailedZone is the name of the zone going offline
+	FailedZone string
+	// Inner simulator to run on the region without the failed zone
+	Inner TrafficSimulator
+}
+
+// Simulate removes the failed zone from the region and EndpointSliceGroups,
+// then simulates the remaining traffic with the Inner simulator
+func (sim ZoneFailureSimulator) Simulate(region types.RegionInfo, endpointSlices map[string]types.EndpointSliceGroup) (types.SimulationResult, error) {
+	if sim.Inner == nil {
+		return types.SimulationResult{}, errors.New("can't simulate zone failure with nil inner simulator")
+	}
+	if _, ok := region.ZoneDetails[sim.FailedZone]; !ok {
+		return types.SimulationResult{}, fmt.Errorf("failed zone %s doesn't exist in the region", sim.FailedZone)
+	}
+	var zones []types.Zone
+	for name, zone := range region.ZoneDetails {
+		if name != sim.FailedZone {
+			zones = append(zones, zone)
+		}
+	}
+	// recalculate nodes and endpoints ratios over the remaining zones
+	remainingRegion, err := types.CreateRegionInfo(zones)
+	if err != nil {
+		return types.SimulationResult{}, err
+	}
+
+	remainingSlices := map[string]types.EndpointSliceGroup{}
+	for label, sliceGroup := range endpointSlices {
+		remainingGroup := types.EndpointSliceGroup{
+			Label:              sliceGroup.Label,
+			Composition:        map[string]types.WeightedEndpoints{},
+			ZoneTrafficWeights: map[string]float64{},
+		}
+		for zone, endpoints := range sliceGroup.Composition {
+			if zone != sim.FailedZone {
+				remainingGroup.Composition[zone] = endpoints
+			}
+		}
+		for zone, weight := range sliceGroup.ZoneTrafficWeights {
+			if zone != sim.FailedZone {
+				remainingGroup.ZoneTrafficWeights[zone] = weight
+			}
+		}
+		remainingSlices[label] = remainingGroup
+	}
+	return sim.Inner.Simulate(remainingRegion, remainingSlices)
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package simulator
+
+import (
+	"math"
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+func TestZoneFailureSimulator(t *testing.T) {
+	zones := []types.Zone{
+		types.Zone{Nodes: 2, Endpoints: 4, Name: "ZoneA"},
+		types.Zone{Nodes: 1, Endpoints: 1, Name: "ZoneB"},
+		types.Zone{Nodes: 1, Endpoints: 1, Name: "ZoneC"},
+	}
+	region, err := types.CreateRegionInfo(zones)
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	testCases := []struct {
+		name          string
+		slices        map[string]types.EndpointSliceGroup
+		failedZone    string
+		inZoneTraffic float64
+		invalid       bool
+		expectedErr   bool
+	}{
+		{
+			name: "in-zone traffic of the failed zone is lost",
+			slices: map[string]types.EndpointSliceGroup{
+				"ZoneA": types.EndpointSliceGroup{
+					Label:              "ZoneA",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneA": {Number: 4, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneA": 1},
+				},
+				"shared": types.EndpointSliceGroup{
+					Label:              "shared",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneB": {Number: 1, Weight: 1}, "ZoneC": {Number: 1, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneB": 1, "ZoneC": 1},
+				},
+			},
+			failedZone:    "ZoneA",
+			inZoneTraffic: 0.5,
+		},
+		{
+			name: "failed zone is the sole source of endpoints",
+			slices: map[string]types.EndpointSliceGroup{
+				"global": types.EndpointSliceGroup{
+					Label:              "global",
+					Composition:        map[string]types.WeightedEndpoints{"ZoneA": {Number: 4, Weight: 1}},
+					ZoneTrafficWeights: map[string]float64{"ZoneA": 1, "ZoneB": 1, "ZoneC": 1},
+				},
+			},
+			failedZone: "ZoneA",
+			invalid:    true,
+		},
+		{
+			name:        "unknown failed zone",
+			slices:      map[string]types.EndpointSliceGroup{},
+			failedZone:  "ZoneD",
+			expectedErr: true,
+		},
+	}
+	for _, testcase := range testCases {
+		t.Run(testcase.name, func(t *testing.T) {
+			result, err := ZoneFailureSimulator{FailedZone: testcase.failedZone, Inner: TheoreticalSimulator{}}.Simulate(region, testcase.slices)
+			if (err != nil) != testcase.expectedErr {
+				t.Fatalf("got error: %v, expected error: %v", err, testcase.expectedErr)
+			}
+			if err != nil {
+				return
+			}
+			if result.Invalid != testcase.invalid {
+				t.Fatalf("got invalid: %v, expected invalid: %v", result.Invalid, testcase.invalid)
+			}
+			if testcase.invalid {
+				return
+			}
+			before, err := TheoreticalSimulator{}.Simulate(region, testcase.slices)
+			if err != nil {
+				t.Fatalf("unexpected error simulating without failure: %v", err)
+			}
+			if result.InZoneTraffic >= before.InZoneTraffic {
+				t.Errorf("expected in-zone traffic to decrease from %v, got %v", before.InZoneTraffic, result.InZoneTraffic)
+			}
+			if math.Abs(result.InZoneTraffic-testcase.inZoneTraffic) > 0.00001 {
+				t.Errorf("got in-zone traffic %v, expected %v", result.InZoneTraffic, testcase.inZoneTraffic)
+			}
+			// the original slice groups should be left untouched
+			if testcase.slices[testcase.failedZone].Composition[testcase.failedZone].Number == 0 {
+				t.Errorf("expected original slice groups not to be modified")
+			}
+		})
+	}
+}
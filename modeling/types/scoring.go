@@ -0,0 +1,94 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"math"
+)
+
+// endpointsPerSlice is the default capacity of EndpointSlices created by the
+// original algorithm, used as the baseline of SliceScore
+const endpointsPerSlice = 100
+
+// ScoreWeights are the weights of the component scores in the total score
+type ScoreWeights struct {
+	// InZoneTraffic is the weight of InZoneTrafficScore
+	InZoneTraffic float64
+	// Deviation is the weight of DeviationScore
+	Deviation float64
+	// SliceCount is the weight of SliceScore
+	SliceCount float64
+}
+
+// InZoneTrafficScore uses the in-zone traffic percentage as the score
+func (s SimulationResult) InZoneTrafficScore() float64 {
+	return s.InZoneTraffic * 100
+}
+
+// DeviationScore scores the max and mean traffic load deviation equally
+func (s SimulationResult) DeviationScore() float64 {
+	deviationMaxScore := 100.0 - s.MaxDeviation*100
+	deviationMeanScore := 100.0 - s.MeanDeviation*100
+	return 0.5*deviationMaxScore + 0.5*deviationMeanScore
+}
+
+// SliceScore compares the number of EndpointSlices the original algorithm would
+// create for endpoints with endpointSlices, it's 0 if there is no EndpointSlice
+func SliceScore(endpoints int, endpointSlices int) float64 {
+	return SliceScoreWithCapacity(endpoints, endpointSlices, endpointsPerSlice)
+}
+
+// SliceScoreWithCapacity is SliceScore with EndpointSlices of the original
+// algorithm holding capacity endpoints, 100 is used if capacity isn't positive
+func SliceScoreWithCapacity(endpoints int, endpointSlices int, capacity int) float64 {
+	if endpointSlices == 0 {
+		return 0
+	}
+	if capacity <= 0 {
+		capacity = endpointsPerSlice
+	}
+	numberOfOriginalSlices := math.Ceil(float64(endpoints) / float64(capacity))
+	return numberOfOriginalSlices / float64(endpointSlices) * 100
+}
+
+// Score calculates the total score of the simulation result weighted from the
+// component scores, invalid results get a zero score
+func (s SimulationResult) Score(weights ScoreWeights, totalEndpoints int, endpointSlices int) float64 {
+	return s.ScoreWithCapacity(weights, totalEndpoints, endpointSlices, endpointsPerSlice)
+}
+
+// ScoreWithCapacity is Score with the slice score based on EndpointSlices
+// holding capacity endpoints
+func (s SimulationResult) ScoreWithCapacity(weights ScoreWeights, totalEndpoints int, endpointSlices int, capacity int) float64 {
+	if s.Invalid {
+		return 0
+	}
+	return weights.InZoneTraffic*s.InZoneTrafficScore() + weights.Deviation*s.DeviationScore() + weights.SliceCount*SliceScoreWithCapacity(totalEndpoints, endpointSlices, capacity)
+}
+
+// BestOf returns the index and score of the highest-scoring result, the first
+// one wins ties. It returns -1 if results is empty.
+func BestOf(results []SimulationResult, weights ScoreWeights, totalEndpoints int, endpointSlices int) (int, float64) {
+	bestIndex, bestScore := -1, 0.0
+	for i, result := range results {
+		score := result.Score(weights, totalEndpoints, endpointSlices)
+		if bestIndex == -1 || score > bestScore {
+			bestIndex, bestScore = i, score
+		}
+	}
+	return bestIndex, bestScore
+}
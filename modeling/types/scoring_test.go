@@ -0,0 +1,82 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	weights := ScoreWeights{InZoneTraffic: 0.45, Deviation: 0.4, SliceCount: 0.15}
+	result := SimulationResult{InZoneTraffic: 2.0 / 3, MaxDeviation: 1.0 / 99, MeanDeviation: 1.0 / 150}
+	if score := result.Score(weights, 200, 3); math.Abs(score-79.6646) > 0.0001 {
+		t.Errorf("expected score 79.6646, got %v", score)
+	}
+	result.Invalid = true
+	if score := result.Score(weights, 200, 3); score != 0 {
+		t.Errorf("expected score of invalid result to be 0, got %v", score)
+	}
+}
+
+func TestBestOf(t *testing.T) {
+	weights := ScoreWeights{InZoneTraffic: 0.45, Deviation: 0.4, SliceCount: 0.15}
+	worse := SimulationResult{InZoneTraffic: 0.2, MaxDeviation: 0.5, MeanDeviation: 0.3}
+	better := SimulationResult{InZoneTraffic: 0.9, MaxDeviation: 0.05, MeanDeviation: 0.02}
+	testCases := []struct {
+		name          string
+		results       []SimulationResult
+		expectedIndex int
+	}{
+		{
+			name:          "better result last",
+			results:       []SimulationResult{worse, better},
+			expectedIndex: 1,
+		},
+		{
+			name:          "better result first",
+			results:       []SimulationResult{better, worse},
+			expectedIndex: 0,
+		},
+		{
+			name:          "invalid result",
+			results:       []SimulationResult{{Invalid: true, InZoneTraffic: 1}, worse},
+			expectedIndex: 1,
+		},
+		{
+			name:          "tie",
+			results:       []SimulationResult{worse, worse},
+			expectedIndex: 0,
+		},
+		{
+			name:          "empty",
+			results:       nil,
+			expectedIndex: -1,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			index, score := BestOf(tc.results, weights, 100, 1)
+			if index != tc.expectedIndex {
+				t.Fatalf("expected index %d, got %d", tc.expectedIndex, index)
+			}
+			if index >= 0 && score != tc.results[index].Score(weights, 100, 1) {
+				t.Errorf("expected score of the best result, got %v", score)
+			}
+		})
+	}
+}
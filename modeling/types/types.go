@@ -16,7 +16,14 @@ limitations under the License.
 
 package types
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
 
 // Zone abstracts the conception of 'zone' in clouds
 type Zone struct {
@@ -30,6 +37,16 @@ type Zone struct {
 	EndpointsRatio float64
 	// NodesRatio of this zone compared to all nodes
 	NodesRatio float64
+	// MaxEndpoints this zone is responsible for, 0 means unlimited
+	MaxEndpoints int
+	// Labels are topology metadata of this zone, i.e.
+	// topology.kubernetes.io/region
+	Labels map[string]string
+	// AvgCrossZoneLatencyMs is the average latency in milliseconds of traffic
+	// from other zones to this zone, 0 means unknown
+	AvgCrossZoneLatencyMs float64
+	// BandwidthMbps of cross-zone traffic of this zone, 0 means unknown
+	BandwidthMbps float64
 }
 
 // EndpointSliceGroup represents all the EndpointSlices under a same label, one
@@ -55,8 +72,14 @@ type SimulationResult struct {
 	Invalid bool
 	// InZoneTraffic is the total ratio of traffic that stays in the same zone
 	InZoneTraffic float64
+	// InZoneTrafficByZone is the ratio of traffic from each zone (key) that
+	// stays in the zone
+	InZoneTrafficByZone map[string]float64
 	// TrafficDistribution groups zoneTraffic by zone name
 	TrafficDistribution map[string]ZoneTraffic
+	// ZoneTrafficMatrix stores the ratio of all traffic sent from a zone
+	// (first key) to endpoints of a zone (second key)
+	ZoneTrafficMatrix map[string]map[string]float64
 	// MaxDeviation of traffic load of all endpoints
 	MaxDeviation float64
 	// MeanDeviation of traffic load of all endpoints
@@ -64,6 +87,49 @@ type SimulationResult struct {
 	// DeviationSD represents the standard deviation of the daviation of traffic
 	// load across all endpoints
 	DeviationSD float64
+	// InZoneTrafficCI95 is the 95% confidence interval [low, high] of
+	// InZoneTraffic when the result is aggregated from repeated simulations
+	InZoneTrafficCI95 [2]float64
+	// MeanDeviationCI95 is the 95% confidence interval [low, high] of
+	// MeanDeviation when the result is aggregated from repeated simulations
+	MeanDeviationCI95 [2]float64
+	// EndpointTurnover is the ratio of endpoints moved between
+	// EndpointSliceGroups when the result comes from a topology transition
+	EndpointTurnover float64
+	// AlgorithmName is the name of the algorithm the result is simulated with,
+	// empty if it's unknown to the simulator
+	AlgorithmName string
+	// RegionName is the input name of the simulated region, empty if it's
+	// unknown to the simulator
+	RegionName string
+}
+
+// MetricSummary summarizes the distribution of one metric over multiple
+// simulations
+type MetricSummary struct {
+	// Mean of the metric
+	Mean float64
+	// StdDev is the standard deviation of the metric
+	StdDev float64
+	// Min value of the metric
+	Min float64
+	// Max value of the metric
+	Max float64
+}
+
+// AggregateResult collects statistics of multiple simulation results
+type AggregateResult struct {
+	// Runs is the number of simulations aggregated
+	Runs int
+	// InvalidRuns is the number of simulations with invalid results, these are
+	// excluded from the summaries below
+	InvalidRuns int
+	// InZoneTraffic summary across valid simulations
+	InZoneTraffic MetricSummary
+	// MeanDeviation summary across valid simulations
+	MeanDeviation MetricSummary
+	// MaxDeviation summary across valid simulations
+	MaxDeviation MetricSummary
 }
 
 // RegionInfo wraps information of zones in a region
@@ -74,6 +140,9 @@ type RegionInfo struct {
 	TotalEndpoints int
 	// ZoneDetails by zone
 	ZoneDetails map[string]Zone
+	// ZeroEndpointZones are the sorted names of zones without endpoints,
+	// algorithms skip them as endpoint contributors
+	ZeroEndpointZones []string
 }
 
 // WeightedEndpoints are used to do routing inside an EndpointSliceGroup
@@ -114,6 +183,33 @@ type EndpointsTraffic struct {
 	MaxDeviationSG string
 	// MeanDeviation of endpoints in a zone
 	MeanDeviation float64
+	// P95Deviation and P99Deviation of endpoints in a zone, see
+	// PercentileDeviation
+	P95Deviation float64
+	P99Deviation float64
+}
+
+// ExpectedEndpoints calculates the number of endpoints this zone expects based
+// on its proportion of nodes
+func (z Zone) ExpectedEndpoints(totalEndpoints int) float64 {
+	return z.NodesRatio * float64(totalEndpoints)
+}
+
+// CanAcceptEndpoints returns true if the zone having current endpoints can take
+// delta more endpoints without exceeding MaxEndpoints
+func (z Zone) CanAcceptEndpoints(current, delta int) bool {
+	return z.MaxEndpoints <= 0 || current+delta <= z.MaxEndpoints
+}
+
+// EffectiveEndpoints is the number of endpoints scaled by their weight
+func (we WeightedEndpoints) EffectiveEndpoints() float64 {
+	return float64(we.Number) * we.Weight
+}
+
+// IsZero returns true if the endpoints receive no traffic, either because there
+// is none or because their weight is 0
+func (we WeightedEndpoints) IsZero() bool {
+	return we.Number == 0 || we.Weight == 0
 }
 
 // NumberOfEndpoints calculates number of endpoints of a specific
@@ -131,16 +227,138 @@ func (e EndpointSliceGroup) NumberOfEndpoints() int {
 func (e EndpointSliceGroup) NumberOfWeightedEndpoints() float64 {
 	total := 0.0
 	for _, endpoints := range e.Composition {
-		total += float64(endpoints.Number) * endpoints.Weight
+		total += endpoints.EffectiveEndpoints()
 	}
 	return total
 }
 
+// Clone returns a deep copy of the EndpointSliceGroup, so the copy can be
+// mutated without affecting the original one
+func (e EndpointSliceGroup) Clone() EndpointSliceGroup {
+	clone := EndpointSliceGroup{Label: e.Label}
+	if e.Composition != nil {
+		clone.Composition = make(map[string]WeightedEndpoints, len(e.Composition))
+		for zone, endpoints := range e.Composition {
+			clone.Composition[zone] = endpoints
+		}
+	}
+	if e.ZoneTrafficWeights != nil {
+		clone.ZoneTrafficWeights = make(map[string]float64, len(e.ZoneTrafficWeights))
+		for zone, weight := range e.ZoneTrafficWeights {
+			clone.ZoneTrafficWeights[zone] = weight
+		}
+	}
+	return clone
+}
+
+// Clone returns a deep copy of the RegionInfo, so the copy can be mutated
+// without affecting the original one
+func (r RegionInfo) Clone() RegionInfo {
+	clone := RegionInfo{TotalNodes: r.TotalNodes, TotalEndpoints: r.TotalEndpoints}
+	if r.ZoneDetails != nil {
+		clone.ZoneDetails = make(map[string]Zone, len(r.ZoneDetails))
+		for name, zone := range r.ZoneDetails {
+			if zone.Labels != nil {
+				labels := make(map[string]string, len(zone.Labels))
+				for key, value := range zone.Labels {
+					labels[key] = value
+				}
+				zone.Labels = labels
+			}
+			clone.ZoneDetails[name] = zone
+		}
+	}
+	if r.ZeroEndpointZones != nil {
+		clone.ZeroEndpointZones = append([]string{}, r.ZeroEndpointZones...)
+	}
+	return clone
+}
+
+// Merge adds endpoints and traffic weights of other into the
+// EndpointSliceGroup, summing numbers of endpoints and weights of matching
+// zones. The label of the merged group is e.Label + "-" + other.Label.
+func (e *EndpointSliceGroup) Merge(other EndpointSliceGroup) {
+	if e.Composition == nil {
+		e.Composition = make(map[string]WeightedEndpoints, len(other.Composition))
+	}
+	if e.ZoneTrafficWeights == nil {
+		e.ZoneTrafficWeights = make(map[string]float64, len(other.ZoneTrafficWeights))
+	}
+	for zone, endpoints := range other.Composition {
+		merged := e.Composition[zone]
+		merged.Number += endpoints.Number
+		merged.Weight = endpoints.Weight
+		e.Composition[zone] = merged
+	}
+	for zone, weight := range other.ZoneTrafficWeights {
+		e.ZoneTrafficWeights[zone] += weight
+	}
+	e.Label += "-" + other.Label
+}
+
+// Validate returns an error if the EndpointSliceGroup has a negative number of
+// endpoints, or a composition or traffic weight that is negative, NaN or
+// infinite
+func (e EndpointSliceGroup) Validate() error {
+	for zone, endpoints := range e.Composition {
+		if endpoints.Number < 0 {
+			return fmt.Errorf("negative number of endpoints %d from zone %s in %s", endpoints.Number, zone, e.Label)
+		}
+		if !validWeight(endpoints.Weight) {
+			return fmt.Errorf("invalid endpoints weight %v from zone %s in %s", endpoints.Weight, zone, e.Label)
+		}
+	}
+	for zone, weight := range e.ZoneTrafficWeights {
+		if !validWeight(weight) {
+			return fmt.Errorf("invalid traffic weight %v of zone %s in %s", weight, zone, e.Label)
+		}
+	}
+	return nil
+}
+
+// SerializeSliceGroups encodes groups to JSON, so they can be inspected or
+// replayed later with DeserializeSliceGroups
+func SerializeSliceGroups(groups map[string]EndpointSliceGroup) ([]byte, error) {
+	return json.Marshal(groups)
+}
+
+// DeserializeSliceGroups decodes EndpointSliceGroups encoded by
+// SerializeSliceGroups, and returns an error if any of them is invalid
+func DeserializeSliceGroups(data []byte) (map[string]EndpointSliceGroup, error) {
+	var groups map[string]EndpointSliceGroup
+	if err := json.Unmarshal(data, &groups); err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		if err := group.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return groups, nil
+}
+
+// validWeight returns true if weight is a finite non-negative number
+func validWeight(weight float64) bool {
+	return weight >= 0 && !math.IsInf(weight, 1)
+}
+
 // CreateRegionInfo creates regionInfo with zone infos
 func CreateRegionInfo(zones []Zone) (RegionInfo, error) {
 	if len(zones) == 0 {
 		return RegionInfo{}, errors.New("creating zoneinfos with zero length []Zone")
 	}
+	// zones with the same name would overwrite each other in ZoneDetails,
+	// reject them before summing up
+	nameCounts := make(map[string]int)
+	for _, zone := range zones {
+		if zone.Name == "" {
+			return RegionInfo{}, errors.New("zone name must not be empty")
+		}
+		nameCounts[zone.Name]++
+		if nameCounts[zone.Name] > 1 {
+			return RegionInfo{}, fmt.Errorf("duplicate zone name: %s", zone.Name)
+		}
+	}
 	var totalEndpoints, totalNodes int
 
 	region := RegionInfo{ZoneDetails: make(map[string]Zone)}
@@ -148,6 +366,18 @@ func CreateRegionInfo(zones []Zone) (RegionInfo, error) {
 		if zone.Endpoints < 0 || zone.Nodes < 0 {
 			return RegionInfo{}, errors.New("invalid zones with number of nodes or endpoints < 0")
 		}
+		if zone.AvgCrossZoneLatencyMs < 0 {
+			return RegionInfo{}, fmt.Errorf("invalid zone %s with cross-zone latency %vms < 0", zone.Name, zone.AvgCrossZoneLatencyMs)
+		}
+		if zone.BandwidthMbps < 0 {
+			return RegionInfo{}, fmt.Errorf("invalid zone %s with bandwidth %vMbps < 0", zone.Name, zone.BandwidthMbps)
+		}
+		if zone.MaxEndpoints < 0 {
+			return RegionInfo{}, fmt.Errorf("invalid zone %s with max endpoints %d < 0", zone.Name, zone.MaxEndpoints)
+		}
+		if zone.MaxEndpoints > 0 && zone.Endpoints > zone.MaxEndpoints {
+			return RegionInfo{}, fmt.Errorf("invalid zone %s with %d endpoints more than max endpoints %d", zone.Name, zone.Endpoints, zone.MaxEndpoints)
+		}
 		totalEndpoints += zone.Endpoints
 		totalNodes += zone.Nodes
 	}
@@ -165,6 +395,262 @@ func CreateRegionInfo(zones []Zone) (RegionInfo, error) {
 			zone.NodesRatio = float64(zone.Nodes) / float64(totalNodes)
 		}
 		region.ZoneDetails[zone.Name] = zone
+		if zone.Endpoints == 0 {
+			region.ZeroEndpointZones = append(region.ZeroEndpointZones, zone.Name)
+		}
 	}
+	sort.Strings(region.ZeroEndpointZones)
 	return region, nil
 }
+
+// HasZeroEndpoints returns true if the zone is listed in ZeroEndpointZones
+func (r RegionInfo) HasZeroEndpoints(zoneName string) bool {
+	i := sort.SearchStrings(r.ZeroEndpointZones, zoneName)
+	return i < len(r.ZeroEndpointZones) && r.ZeroEndpointZones[i] == zoneName
+}
+
+// ZoneNames returns the sorted names of all zones in the region. It helps
+// traverse ZoneDetails with a deterministic order
+func (r RegionInfo) ZoneNames() []string {
+	var names []string
+	for name := range r.ZoneDetails {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ZonesByLabel returns the sorted names of zones having the label key=value
+func (r RegionInfo) ZonesByLabel(key, value string) []string {
+	var names []string
+	for _, name := range r.ZoneNames() {
+		if labelValue, ok := r.ZoneDetails[name].Labels[key]; ok && labelValue == value {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// LargestZoneByNodes returns the name of the zone with the most nodes, ties
+// are broken by the lexicographically first name. It returns an empty string
+// if the region has no zones.
+func (r RegionInfo) LargestZoneByNodes() string {
+	return r.extremeZone(func(a, b Zone) bool { return a.Nodes > b.Nodes })
+}
+
+// SmallestZoneByNodes returns the name of the zone with the fewest nodes, ties
+// are broken by the lexicographically first name. It returns an empty string
+// if the region has no zones.
+func (r RegionInfo) SmallestZoneByNodes() string {
+	return r.extremeZone(func(a, b Zone) bool { return a.Nodes < b.Nodes })
+}
+
+// LargestZoneByEndpoints returns the name of the zone with the most endpoints,
+// ties are broken by the lexicographically first name. It returns an empty
+// string if the region has no zones.
+func (r RegionInfo) LargestZoneByEndpoints() string {
+	return r.extremeZone(func(a, b Zone) bool { return a.Endpoints > b.Endpoints })
+}
+
+// extremeZone returns the first zone in name order that no other zone is
+// better than
+func (r RegionInfo) extremeZone(better func(a, b Zone) bool) string {
+	result := ""
+	for _, name := range r.ZoneNames() {
+		if result == "" || better(r.ZoneDetails[name], r.ZoneDetails[result]) {
+			result = name
+		}
+	}
+	return result
+}
+
+// EndpointDensity returns the number of endpoints per node of every zone by
+// name, zones without nodes have a density of 0
+func (r RegionInfo) EndpointDensity() map[string]float64 {
+	densities := make(map[string]float64, len(r.ZoneDetails))
+	for name, zone := range r.ZoneDetails {
+		densities[name] = 0
+		if zone.Nodes > 0 {
+			densities[name] = float64(zone.Endpoints) / float64(zone.Nodes)
+		}
+	}
+	return densities
+}
+
+// AvgEndpointDensity returns the mean of EndpointDensity over all zones, 0 if
+// the region has no zones
+func (r RegionInfo) AvgEndpointDensity() float64 {
+	if len(r.ZoneDetails) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, density := range r.EndpointDensity() {
+		sum += density
+	}
+	return sum / float64(len(r.ZoneDetails))
+}
+
+// EndpointDensityStdDev returns the population standard deviation of
+// EndpointDensity over all zones, 0 if the region has no zones
+func (r RegionInfo) EndpointDensityStdDev() float64 {
+	if len(r.ZoneDetails) == 0 {
+		return 0
+	}
+	mean := r.AvgEndpointDensity()
+	variance := 0.0
+	for _, density := range r.EndpointDensity() {
+		variance += (density - mean) * (density - mean)
+	}
+	return math.Sqrt(variance / float64(len(r.ZoneDetails)))
+}
+
+// Summarize returns a one-line statistics summary of the region, it's used for
+// debugging and logging
+func (r RegionInfo) Summarize() string {
+	if len(r.ZoneDetails) == 0 {
+		return "zones: 0"
+	}
+	names := r.ZoneNames()
+
+	first := r.ZoneDetails[names[0]]
+	minEndpoints, maxEndpoints := first.Endpoints, first.Endpoints
+	minNodes, maxNodes := first.Nodes, first.Nodes
+	// density of a zone is its number of endpoints per node, zones without
+	// nodes are excluded
+	mostDense, leastDense := "", ""
+	maxDensity, minDensity := 0.0, 0.0
+	for _, name := range names {
+		zone := r.ZoneDetails[name]
+		if zone.Endpoints < minEndpoints {
+			minEndpoints = zone.Endpoints
+		}
+		if zone.Endpoints > maxEndpoints {
+			maxEndpoints = zone.Endpoints
+		}
+		if zone.Nodes < minNodes {
+			minNodes = zone.Nodes
+		}
+		if zone.Nodes > maxNodes {
+			maxNodes = zone.Nodes
+		}
+		if zone.Nodes == 0 {
+			continue
+		}
+		density := float64(zone.Endpoints) / float64(zone.Nodes)
+		if mostDense == "" || density > maxDensity {
+			mostDense, maxDensity = name, density
+		}
+		if leastDense == "" || density < minDensity {
+			leastDense, minDensity = name, density
+		}
+	}
+	zones := float64(len(names))
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "zones: %d, nodes: %d, endpoints: %d", len(names), r.TotalNodes, r.TotalEndpoints)
+	fmt.Fprintf(&builder, ", endpoints per zone (min/max/mean): %d/%d/%.2f", minEndpoints, maxEndpoints, float64(r.TotalEndpoints)/zones)
+	fmt.Fprintf(&builder, ", nodes per zone (min/max/mean): %d/%d/%.2f", minNodes, maxNodes, float64(r.TotalNodes)/zones)
+	if mostDense == "" {
+		builder.WriteString(", most dense zone: none, least dense zone: none")
+	} else {
+		fmt.Fprintf(&builder, ", most dense zone: %s (%.2f endpoints/node), least dense zone: %s (%.2f endpoints/node)", mostDense, maxDensity, leastDense, minDensity)
+	}
+	fmt.Fprintf(&builder, ", endpoint density SD: %.2f", r.EndpointDensityStdDev())
+	return builder.String()
+}
+
+// PercentileDeviation returns the p-th (0.0 to 1.0) percentile of the
+// deviations of all sliceGroups in EndpointsTrafficLoadDeviation with the
+// nearest-rank method, 0 if there is none
+func (et EndpointsTraffic) PercentileDeviation(p float64) float64 {
+	if len(et.EndpointsTrafficLoadDeviation) == 0 {
+		return 0
+	}
+	deviations := make([]float64, 0, len(et.EndpointsTrafficLoadDeviation))
+	for _, deviation := range et.EndpointsTrafficLoadDeviation {
+		deviations = append(deviations, deviation)
+	}
+	sort.Float64s(deviations)
+	rank := int(math.Ceil(p * float64(len(deviations))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(deviations) {
+		rank = len(deviations)
+	}
+	return deviations[rank-1]
+}
+
+// IsBalanced returns true if the result is valid and its max deviation of
+// traffic load doesn't exceed maxDeviationThreshold
+func (s SimulationResult) IsBalanced(maxDeviationThreshold float64) bool {
+	return !s.Invalid && s.MaxDeviation <= maxDeviationThreshold
+}
+
+// IsHealthy returns true if the result is valid and every zone in
+// TrafficDistribution receives traffic
+func (s SimulationResult) IsHealthy() bool {
+	if s.Invalid {
+		return false
+	}
+	for _, zoneTraffic := range s.TrafficDistribution {
+		if zoneTraffic.TrafficLoad <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Merge combines the results of two independent regions weighted by
+// selfRegionWeight and otherRegionWeight, e.g. their traffic. InZoneTraffic,
+// deviations, confidence intervals and EndpointTurnover are weighted averages,
+// MaxDeviation is the larger one, and per-zone maps are the union of both, zones
+// in both keep the values of s. Names only differing in the two results are
+// dropped. Weights are normalized and both count equally if they don't sum up
+// to a positive number. The merged result is invalid if either result is.
+func (s SimulationResult) Merge(other SimulationResult, selfRegionWeight, otherRegionWeight float64) SimulationResult {
+	if s.Invalid || other.Invalid {
+		return SimulationResult{Invalid: true}
+	}
+	w := 0.5
+	if total := selfRegionWeight + otherRegionWeight; total > 0 {
+		w = selfRegionWeight / total
+	}
+	average := func(a, b float64) float64 {
+		return w*a + (1-w)*b
+	}
+	merged := SimulationResult{
+		InZoneTraffic:       average(s.InZoneTraffic, other.InZoneTraffic),
+		InZoneTrafficByZone: mergeZoneMaps(s.InZoneTrafficByZone, other.InZoneTrafficByZone),
+		TrafficDistribution: mergeZoneMaps(s.TrafficDistribution, other.TrafficDistribution),
+		ZoneTrafficMatrix:   mergeZoneMaps(s.ZoneTrafficMatrix, other.ZoneTrafficMatrix),
+		MaxDeviation:        math.Max(s.MaxDeviation, other.MaxDeviation),
+		MeanDeviation:       average(s.MeanDeviation, other.MeanDeviation),
+		DeviationSD:         average(s.DeviationSD, other.DeviationSD),
+		InZoneTrafficCI95:   [2]float64{average(s.InZoneTrafficCI95[0], other.InZoneTrafficCI95[0]), average(s.InZoneTrafficCI95[1], other.InZoneTrafficCI95[1])},
+		MeanDeviationCI95:   [2]float64{average(s.MeanDeviationCI95[0], other.MeanDeviationCI95[0]), average(s.MeanDeviationCI95[1], other.MeanDeviationCI95[1])},
+		EndpointTurnover:    average(s.EndpointTurnover, other.EndpointTurnover),
+	}
+	if s.AlgorithmName == other.AlgorithmName {
+		merged.AlgorithmName = s.AlgorithmName
+	}
+	if s.RegionName == other.RegionName {
+		merged.RegionName = s.RegionName
+	}
+	return merged
+}
+
+// mergeZoneMaps returns the union of a and b, keys in both keep the value of
+// a. It returns nil if both are nil.
+func mergeZoneMaps[V any](a, b map[string]V) map[string]V {
+	if a == nil && b == nil {
+		return nil
+	}
+	merged := make(map[string]V, len(a)+len(b))
+	for zone, value := range b {
+		merged[zone] = value
+	}
+	for zone, value := range a {
+		merged[zone] = value
+	}
+	return merged
+}
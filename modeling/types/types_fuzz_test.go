@@ -0,0 +1,82 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"math"
+	"testing"
+)
+
+// fuzzSeeds are nodes and endpoints of three zones used by existing algorithm
+// test cases
+var fuzzSeeds = [][6]int{
+	{1, 5, 2, 20, 7, 20},
+	{1, 0, 1, 6, 1, 7},
+	{16, 5, 8, 1, 1, 0},
+	{30, 100, 30, 0, 30, 0},
+	{30, 1, 30, 0, 30, 0},
+	{1, 3, 2, 2, 2, 2},
+	{0, 0, 0, 0, 0, 0},
+	{-1, 5, 2, 20, 7, 20},
+}
+
+func FuzzCreateRegionInfo(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed[0], seed[1], seed[2], seed[3], seed[4], seed[5])
+	}
+	f.Fuzz(func(t *testing.T, nodesA, endpointsA, nodesB, endpointsB, nodesC, endpointsC int) {
+		// keep totals away from integer overflow
+		for _, number := range []int{nodesA, endpointsA, nodesB, endpointsB, nodesC, endpointsC} {
+			if number > math.MaxInt32 || number < math.MinInt32 {
+				t.Skip()
+			}
+		}
+		zones := []Zone{
+			{Name: "ZoneA", Nodes: nodesA, Endpoints: endpointsA},
+			{Name: "ZoneB", Nodes: nodesB, Endpoints: endpointsB},
+			{Name: "ZoneC", Nodes: nodesC, Endpoints: endpointsC},
+		}
+		region, err := CreateRegionInfo(zones)
+		negative := nodesA < 0 || endpointsA < 0 || nodesB < 0 || endpointsB < 0 || nodesC < 0 || endpointsC < 0
+		if negative {
+			if err == nil {
+				t.Fatalf("expected an error creating region with negative numbers %+v", zones)
+			}
+			return
+		}
+		if err != nil {
+			t.Fatalf("unexpected error creating region with %+v: %v", zones, err)
+		}
+		if region.TotalNodes != nodesA+nodesB+nodesC || region.TotalEndpoints != endpointsA+endpointsB+endpointsC {
+			t.Fatalf("got unexpected totals of region %+v", region)
+		}
+		var nodesRatio, endpointsRatio float64
+		for _, zone := range region.ZoneDetails {
+			if zone.NodesRatio < 0 || zone.NodesRatio > 1 || zone.EndpointsRatio < 0 || zone.EndpointsRatio > 1 {
+				t.Fatalf("got unexpected ratios of zone %+v", zone)
+			}
+			nodesRatio += zone.NodesRatio
+			endpointsRatio += zone.EndpointsRatio
+		}
+		if region.TotalNodes > 0 && math.Abs(nodesRatio-1) > 1e-9 {
+			t.Errorf("expected nodes ratios to sum up to 1, got %v", nodesRatio)
+		}
+		if region.TotalEndpoints > 0 && math.Abs(endpointsRatio-1) > 1e-9 {
+			t.Errorf("expected endpoints ratios to sum up to 1, got %v", endpointsRatio)
+		}
+	})
+}
@@ -0,0 +1,657 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package types
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	testCases := []struct {
+		name  string
+		group EndpointSliceGroup
+	}{
+		{
+			name: "populated group",
+			group: EndpointSliceGroup{
+				Label: "ZoneA",
+				Composition: map[string]WeightedEndpoints{
+					"ZoneA": WeightedEndpoints{Number: 3, Weight: 1},
+					"ZoneB": WeightedEndpoints{Number: 2, Weight: 0.5},
+				},
+				ZoneTrafficWeights: map[string]float64{"ZoneA": 1, "ZoneB": 0.25},
+			},
+		},
+		{
+			name:  "empty group",
+			group: EndpointSliceGroup{Label: "global"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			clone := tc.group.Clone()
+			if !reflect.DeepEqual(clone, tc.group) {
+				t.Fatalf("expected clone %+v to equal original %+v", clone, tc.group)
+			}
+			if clone.Composition == nil {
+				return
+			}
+			endpoints := clone.Composition["ZoneA"]
+			endpoints.Number = 100
+			clone.Composition["ZoneA"] = endpoints
+			clone.Composition["ZoneC"] = WeightedEndpoints{Number: 1, Weight: 1}
+			clone.ZoneTrafficWeights["ZoneA"] = 0
+			if tc.group.Composition["ZoneA"].Number != 3 || len(tc.group.Composition) != 2 {
+				t.Errorf("expected original composition to be unchanged, got %+v", tc.group.Composition)
+			}
+			if tc.group.ZoneTrafficWeights["ZoneA"] != 1 {
+				t.Errorf("expected original weights to be unchanged, got %+v", tc.group.ZoneTrafficWeights)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	groupA := EndpointSliceGroup{
+		Label: "ZoneA",
+		Composition: map[string]WeightedEndpoints{
+			"ZoneA": WeightedEndpoints{Number: 3, Weight: 1},
+			"ZoneB": WeightedEndpoints{Number: 1, Weight: 1},
+		},
+		ZoneTrafficWeights: map[string]float64{"ZoneA": 1},
+	}
+	groupB := EndpointSliceGroup{
+		Label: "ZoneB",
+		Composition: map[string]WeightedEndpoints{
+			"ZoneB": WeightedEndpoints{Number: 4, Weight: 1},
+			"ZoneC": WeightedEndpoints{Number: 2, Weight: 1},
+		},
+		ZoneTrafficWeights: map[string]float64{"ZoneA": 0.5, "ZoneB": 1},
+	}
+
+	t.Run("commutativity", func(t *testing.T) {
+		ab := groupA.Clone()
+		ab.Merge(groupB)
+		ba := groupB.Clone()
+		ba.Merge(groupA)
+		if ab.Label != "ZoneA-ZoneB" || ba.Label != "ZoneB-ZoneA" {
+			t.Errorf("got unexpected merged labels %s and %s", ab.Label, ba.Label)
+		}
+		if ab.NumberOfEndpoints() != 10 || ba.NumberOfEndpoints() != 10 {
+			t.Errorf("expected 10 endpoints in both merged groups, got %d and %d", ab.NumberOfEndpoints(), ba.NumberOfEndpoints())
+		}
+		if !reflect.DeepEqual(ab.Composition, ba.Composition) || !reflect.DeepEqual(ab.ZoneTrafficWeights, ba.ZoneTrafficWeights) {
+			t.Errorf("expected merged groups to be equal, got %+v and %+v", ab, ba)
+		}
+		if ab.Composition["ZoneB"].Number != 5 || ab.ZoneTrafficWeights["ZoneA"] != 1.5 {
+			t.Errorf("expected matching zones to be summed, got %+v", ab)
+		}
+		if groupB.Composition["ZoneB"].Number != 4 || len(groupB.ZoneTrafficWeights) != 2 {
+			t.Errorf("expected merged-in group to be unchanged, got %+v", groupB)
+		}
+	})
+
+	t.Run("identical groups", func(t *testing.T) {
+		merged := groupA.Clone()
+		merged.Merge(groupA)
+		for zone, endpoints := range groupA.Composition {
+			if merged.Composition[zone].Number != 2*endpoints.Number {
+				t.Errorf("expected %d endpoints from %s, got %d", 2*endpoints.Number, zone, merged.Composition[zone].Number)
+			}
+		}
+		for zone, weight := range groupA.ZoneTrafficWeights {
+			if merged.ZoneTrafficWeights[zone] != 2*weight {
+				t.Errorf("expected weight %f for %s, got %f", 2*weight, zone, merged.ZoneTrafficWeights[zone])
+			}
+		}
+	})
+
+	t.Run("empty group", func(t *testing.T) {
+		merged := EndpointSliceGroup{Label: "shared"}
+		merged.Merge(groupA)
+		if merged.Label != "shared-ZoneA" || !reflect.DeepEqual(merged.Composition, groupA.Composition) {
+			t.Errorf("got unexpected merged group %+v", merged)
+		}
+	})
+}
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name  string
+		group EndpointSliceGroup
+		valid bool
+	}{
+		{
+			name:  "valid group",
+			group: EndpointSliceGroup{Label: "ZoneA", Composition: map[string]WeightedEndpoints{"ZoneA": {Number: 3, Weight: 1}}, ZoneTrafficWeights: map[string]float64{"ZoneA": 0.5}},
+			valid: true,
+		},
+		{
+			name:  "empty group",
+			group: EndpointSliceGroup{Label: "ZoneA"},
+			valid: true,
+		},
+		{
+			name:  "negative number of endpoints",
+			group: EndpointSliceGroup{Label: "ZoneA", Composition: map[string]WeightedEndpoints{"ZoneA": {Number: -1, Weight: 1}}},
+		},
+		{
+			name:  "NaN endpoints weight",
+			group: EndpointSliceGroup{Label: "ZoneA", Composition: map[string]WeightedEndpoints{"ZoneA": {Number: 1, Weight: math.NaN()}}},
+		},
+		{
+			name:  "infinite traffic weight",
+			group: EndpointSliceGroup{Label: "ZoneA", ZoneTrafficWeights: map[string]float64{"ZoneA": math.Inf(1)}},
+		},
+		{
+			name:  "negative traffic weight",
+			group: EndpointSliceGroup{Label: "ZoneA", ZoneTrafficWeights: map[string]float64{"ZoneA": -0.1}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.group.Validate(); (err == nil) != tc.valid {
+				t.Errorf("expected valid %v, got error %v", tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestSerializeSliceGroups(t *testing.T) {
+	groups := map[string]EndpointSliceGroup{
+		"global": {
+			Label:              "global",
+			Composition:        map[string]WeightedEndpoints{"ZoneA": {Number: 3, Weight: 0.25}, "ZoneB": {Number: 7, Weight: 1}},
+			ZoneTrafficWeights: map[string]float64{"ZoneA": 0.4, "ZoneB": 1.0 / 3, "ZoneC": 0},
+		},
+		"ZoneA-ZoneC": {
+			Label:              "ZoneA-ZoneC",
+			Composition:        map[string]WeightedEndpoints{"ZoneA": {Number: 12, Weight: 0.123456789}},
+			ZoneTrafficWeights: map[string]float64{"ZoneA": 0.6, "ZoneC": 1},
+		},
+		"empty": {Label: "empty", Composition: map[string]WeightedEndpoints{}, ZoneTrafficWeights: map[string]float64{}},
+	}
+	data, err := SerializeSliceGroups(groups)
+	if err != nil {
+		t.Fatalf("unexpected error serializing sliceGroups: %v", err)
+	}
+	actual, err := DeserializeSliceGroups(data)
+	if err != nil {
+		t.Fatalf("unexpected error deserializing sliceGroups: %v", err)
+	}
+	if !reflect.DeepEqual(actual, groups) {
+		t.Errorf("expected sliceGroups %+v after round trip, got %+v", groups, actual)
+	}
+
+	if _, err := DeserializeSliceGroups([]byte(`{"global":{"Label":"global","Composition":{"ZoneA":{"Number":-1,"Weight":1}}}}`)); err == nil {
+		t.Errorf("expected error deserializing an invalid sliceGroup")
+	}
+	if _, err := DeserializeSliceGroups([]byte(`not json`)); err == nil {
+		t.Errorf("expected error deserializing malformed data")
+	}
+}
+
+func TestExpectedEndpoints(t *testing.T) {
+	testCases := []struct {
+		name           string
+		zone           Zone
+		totalEndpoints int
+		expected       float64
+	}{
+		{name: "quarter of nodes", zone: Zone{NodesRatio: 0.25}, totalEndpoints: 20, expected: 5},
+		{name: "no nodes", zone: Zone{NodesRatio: 0}, totalEndpoints: 20, expected: 0},
+		{name: "no endpoints", zone: Zone{NodesRatio: 0.5}, totalEndpoints: 0, expected: 0},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := tc.zone.ExpectedEndpoints(tc.totalEndpoints); got != tc.expected {
+				t.Errorf("expected %v endpoints, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestWeightedEndpoints(t *testing.T) {
+	testCases := []struct {
+		name              string
+		endpoints         WeightedEndpoints
+		expectedEffective float64
+		expectedZero      bool
+	}{
+		{name: "weighted endpoints", endpoints: WeightedEndpoints{Number: 4, Weight: 0.5}, expectedEffective: 2},
+		{name: "no endpoints", endpoints: WeightedEndpoints{Number: 0, Weight: 1}, expectedEffective: 0, expectedZero: true},
+		{name: "zero weight", endpoints: WeightedEndpoints{Number: 1, Weight: 0}, expectedEffective: 0, expectedZero: true},
+		{name: "no endpoints and zero weight", endpoints: WeightedEndpoints{}, expectedEffective: 0, expectedZero: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if effective := tc.endpoints.EffectiveEndpoints(); effective != tc.expectedEffective {
+				t.Errorf("expected %v effective endpoints, got %v", tc.expectedEffective, effective)
+			}
+			if zero := tc.endpoints.IsZero(); zero != tc.expectedZero {
+				t.Errorf("expected IsZero %v, got %v", tc.expectedZero, zero)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	region, err := CreateRegionInfo([]Zone{
+		Zone{Nodes: 1, Endpoints: 5, Name: "ZoneA"},
+		Zone{Nodes: 2, Endpoints: 20, Name: "ZoneB"},
+		Zone{Nodes: 7, Endpoints: 20, Name: "ZoneC"},
+		Zone{Nodes: 0, Endpoints: 3, Name: "ZoneD"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	summary := region.Summarize()
+	for _, expected := range []string{
+		"zones: 4",
+		"nodes: 10",
+		"endpoints: 48",
+		"endpoints per zone (min/max/mean): 3/20/12.00",
+		"nodes per zone (min/max/mean): 0/7/2.50",
+		"most dense zone: ZoneB (10.00 endpoints/node)",
+		"least dense zone: ZoneC (2.86 endpoints/node)",
+		"endpoint density SD: 3.66",
+	} {
+		if !strings.Contains(summary, expected) {
+			t.Errorf("expected summary %q to contain %q", summary, expected)
+		}
+	}
+	if summary := (RegionInfo{}).Summarize(); summary != "zones: 0" {
+		t.Errorf("got unexpected summary of an empty region %q", summary)
+	}
+}
+
+func TestEndpointDensity(t *testing.T) {
+	region, err := CreateRegionInfo([]Zone{
+		Zone{Nodes: 1, Endpoints: 5, Name: "ZoneA"},
+		Zone{Nodes: 2, Endpoints: 20, Name: "ZoneB"},
+		Zone{Nodes: 8, Endpoints: 20, Name: "ZoneC"},
+		Zone{Nodes: 0, Endpoints: 3, Name: "ZoneD"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	expected := map[string]float64{"ZoneA": 5, "ZoneB": 10, "ZoneC": 2.5, "ZoneD": 0}
+	densities := region.EndpointDensity()
+	if !reflect.DeepEqual(densities, expected) {
+		t.Errorf("expected endpoint densities %v, got %v", expected, densities)
+	}
+	sum := 0.0
+	for _, density := range densities {
+		sum += density
+	}
+	if avg := region.AvgEndpointDensity(); math.Abs(avg-sum/4) > 1e-9 {
+		t.Errorf("expected average endpoint density %v, got %v", sum/4, avg)
+	}
+	// densities 5, 10, 2.5 and 0 have a mean of 4.375
+	expectedStdDev := math.Sqrt((0.625*0.625 + 5.625*5.625 + 1.875*1.875 + 4.375*4.375) / 4)
+	if stdDev := region.EndpointDensityStdDev(); math.Abs(stdDev-expectedStdDev) > 1e-9 {
+		t.Errorf("expected endpoint density standard deviation %v, got %v", expectedStdDev, stdDev)
+	}
+
+	empty := RegionInfo{}
+	if len(empty.EndpointDensity()) != 0 || empty.AvgEndpointDensity() != 0 || empty.EndpointDensityStdDev() != 0 {
+		t.Errorf("expected no endpoint density of an empty region")
+	}
+}
+
+func TestIsBalanced(t *testing.T) {
+	testCases := []struct {
+		name     string
+		result   SimulationResult
+		expected bool
+	}{
+		{name: "below threshold", result: SimulationResult{MaxDeviation: 0.05}, expected: true},
+		{name: "exactly at threshold", result: SimulationResult{MaxDeviation: 0.1}, expected: true},
+		{name: "just over threshold", result: SimulationResult{MaxDeviation: 0.1000001}, expected: false},
+		{name: "invalid", result: SimulationResult{Invalid: true}, expected: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if balanced := tc.result.IsBalanced(0.1); balanced != tc.expected {
+				t.Errorf("expected IsBalanced %v, got %v", tc.expected, balanced)
+			}
+		})
+	}
+}
+
+func TestPercentileDeviation(t *testing.T) {
+	et := EndpointsTraffic{EndpointsTrafficLoadDeviation: map[string]float64{"sg1": 0.3, "sg2": -0.1, "sg3": 0.5, "sg4": 0, "sg5": 0.2}}
+	testCases := []struct {
+		name     string
+		p        float64
+		expected float64
+	}{
+		{name: "min", p: 0, expected: -0.1},
+		{name: "median", p: 0.5, expected: 0.2},
+		{name: "P95", p: 0.95, expected: 0.5},
+		{name: "max", p: 1, expected: 0.5},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if deviation := et.PercentileDeviation(tc.p); deviation != tc.expected {
+				t.Errorf("expected percentile %v deviation %v, got %v", tc.p, tc.expected, deviation)
+			}
+		})
+	}
+	if deviation := (EndpointsTraffic{}).PercentileDeviation(0.5); deviation != 0 {
+		t.Errorf("expected deviation 0 without sliceGroups, got %v", deviation)
+	}
+}
+
+func TestSimulationResultMerge(t *testing.T) {
+	result := SimulationResult{
+		InZoneTraffic:       0.7,
+		InZoneTrafficByZone: map[string]float64{"ZoneA": 0.6, "ZoneB": 0.8},
+		TrafficDistribution: map[string]ZoneTraffic{
+			"ZoneA": {ZoneName: "ZoneA", Incoming: 0.5, TrafficLoad: 1.1},
+			"ZoneB": {ZoneName: "ZoneB", Incoming: 0.5, TrafficLoad: 0.9},
+		},
+		ZoneTrafficMatrix: map[string]map[string]float64{"ZoneA": {"ZoneA": 0.3, "ZoneB": 0.2}},
+		MaxDeviation:      0.3,
+		MeanDeviation:     0.1,
+		DeviationSD:       0.05,
+		InZoneTrafficCI95: [2]float64{0.65, 0.75},
+		EndpointTurnover:  0.2,
+		AlgorithmName:     "LocalSliceAlgorithm",
+		RegionName:        "region-1",
+	}
+	if merged := result.Merge(result, 0.5, 0.5); !reflect.DeepEqual(merged, result) {
+		t.Errorf("expected merging a result with itself to return %+v, got %+v", result, merged)
+	}
+
+	other := SimulationResult{
+		InZoneTraffic:       0.3,
+		TrafficDistribution: map[string]ZoneTraffic{"ZoneC": {ZoneName: "ZoneC", Incoming: 1, TrafficLoad: 1}},
+		MaxDeviation:        0.2,
+		MeanDeviation:       0.5,
+		DeviationSD:         0.25,
+		AlgorithmName:       "LocalSliceAlgorithm",
+		RegionName:          "region-2",
+	}
+	merged := result.Merge(other, 3, 1)
+	for name, values := range map[string][2]float64{
+		"InZoneTraffic": {merged.InZoneTraffic, 0.6},
+		"MeanDeviation": {merged.MeanDeviation, 0.2},
+		"DeviationSD":   {merged.DeviationSD, 0.1},
+		"MaxDeviation":  {merged.MaxDeviation, 0.3},
+	} {
+		if math.Abs(values[0]-values[1]) > 1e-9 {
+			t.Errorf("expected merged %s %v, got %v", name, values[1], values[0])
+		}
+	}
+	if len(merged.TrafficDistribution) != 3 || !reflect.DeepEqual(merged.TrafficDistribution["ZoneC"], other.TrafficDistribution["ZoneC"]) {
+		t.Errorf("expected the union of traffic distributions, got %+v", merged.TrafficDistribution)
+	}
+	if merged.AlgorithmName != "LocalSliceAlgorithm" || merged.RegionName != "" {
+		t.Errorf("expected the common algorithm name and no region name, got %q and %q", merged.AlgorithmName, merged.RegionName)
+	}
+	if zeroWeights := result.Merge(other, 0, 0); math.Abs(zeroWeights.InZoneTraffic-0.5) > 1e-9 {
+		t.Errorf("expected results to count equally without weights, got in-zone traffic %v", zeroWeights.InZoneTraffic)
+	}
+
+	invalid := SimulationResult{Invalid: true, InZoneTraffic: 1}
+	for name, merged := range map[string]SimulationResult{
+		"invalid self":  invalid.Merge(result, 0.5, 0.5),
+		"invalid other": result.Merge(invalid, 0.5, 0.5),
+		"both invalid":  invalid.Merge(invalid, 0.5, 0.5),
+	} {
+		if !merged.Invalid {
+			t.Errorf("%s: expected merged result to be invalid, got %+v", name, merged)
+		}
+	}
+}
+
+func TestIsHealthy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		result   SimulationResult
+		expected bool
+	}{
+		{
+			name: "all zones receive traffic",
+			result: SimulationResult{TrafficDistribution: map[string]ZoneTraffic{
+				"zoneA": {TrafficLoad: 0.5},
+				"zoneB": {TrafficLoad: 1.5},
+			}},
+			expected: true,
+		},
+		{
+			name: "a zone receives no traffic",
+			result: SimulationResult{TrafficDistribution: map[string]ZoneTraffic{
+				"zoneA": {TrafficLoad: 2},
+				"zoneB": {TrafficLoad: 0},
+			}},
+			expected: false,
+		},
+		{
+			name: "invalid",
+			result: SimulationResult{Invalid: true, TrafficDistribution: map[string]ZoneTraffic{
+				"zoneA": {TrafficLoad: 1},
+			}},
+			expected: false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if healthy := tc.result.IsHealthy(); healthy != tc.expected {
+				t.Errorf("expected IsHealthy %v, got %v", tc.expected, healthy)
+			}
+		})
+	}
+}
+
+func TestZoneHelpers(t *testing.T) {
+	testCases := []struct {
+		name               string
+		zones              map[string]Zone
+		expectedNames      []string
+		largestByNodes     string
+		smallestByNodes    string
+		largestByEndpoints string
+	}{
+		{
+			name: "distinct zones",
+			zones: map[string]Zone{
+				"zoneC": {Name: "zoneC", Nodes: 1, Endpoints: 9},
+				"zoneA": {Name: "zoneA", Nodes: 5, Endpoints: 3},
+				"zoneB": {Name: "zoneB", Nodes: 3, Endpoints: 4},
+			},
+			expectedNames:      []string{"zoneA", "zoneB", "zoneC"},
+			largestByNodes:     "zoneA",
+			smallestByNodes:    "zoneC",
+			largestByEndpoints: "zoneC",
+		},
+		{
+			name: "tied zones",
+			zones: map[string]Zone{
+				"zoneD": {Name: "zoneD", Nodes: 5, Endpoints: 7},
+				"zoneC": {Name: "zoneC", Nodes: 1, Endpoints: 7},
+				"zoneB": {Name: "zoneB", Nodes: 5, Endpoints: 2},
+				"zoneA": {Name: "zoneA", Nodes: 1, Endpoints: 2},
+			},
+			expectedNames:      []string{"zoneA", "zoneB", "zoneC", "zoneD"},
+			largestByNodes:     "zoneB",
+			smallestByNodes:    "zoneA",
+			largestByEndpoints: "zoneC",
+		},
+		{
+			name:  "empty region",
+			zones: map[string]Zone{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			region := RegionInfo{ZoneDetails: tc.zones}
+			if names := region.ZoneNames(); !reflect.DeepEqual(names, tc.expectedNames) {
+				t.Errorf("expected zone names %v, got %v", tc.expectedNames, names)
+			}
+			if zone := region.LargestZoneByNodes(); zone != tc.largestByNodes {
+				t.Errorf("expected largest zone by nodes %q, got %q", tc.largestByNodes, zone)
+			}
+			if zone := region.SmallestZoneByNodes(); zone != tc.smallestByNodes {
+				t.Errorf("expected smallest zone by nodes %q, got %q", tc.smallestByNodes, zone)
+			}
+			if zone := region.LargestZoneByEndpoints(); zone != tc.largestByEndpoints {
+				t.Errorf("expected largest zone by endpoints %q, got %q", tc.largestByEndpoints, zone)
+			}
+		})
+	}
+}
+
+func TestMaxEndpoints(t *testing.T) {
+	testCases := []struct {
+		name        string
+		zone        Zone
+		expectedErr bool
+	}{
+		{name: "unlimited", zone: Zone{Name: "zoneA", Nodes: 1, Endpoints: 10}, expectedErr: false},
+		{name: "at max endpoints", zone: Zone{Name: "zoneA", Nodes: 1, Endpoints: 10, MaxEndpoints: 10}, expectedErr: false},
+		{name: "over max endpoints", zone: Zone{Name: "zoneA", Nodes: 1, Endpoints: 11, MaxEndpoints: 10}, expectedErr: true},
+		{name: "negative max endpoints", zone: Zone{Name: "zoneA", Nodes: 1, Endpoints: 1, MaxEndpoints: -1}, expectedErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := CreateRegionInfo([]Zone{tc.zone, {Name: "zoneB", Nodes: 1, Endpoints: 1}})
+			if (err != nil) != tc.expectedErr {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+			}
+		})
+	}
+
+	zone := Zone{MaxEndpoints: 5}
+	if !zone.CanAcceptEndpoints(4, 1) || zone.CanAcceptEndpoints(5, 1) {
+		t.Errorf("expected a zone with max endpoints 5 to accept the 5th endpoint only")
+	}
+	if !(Zone{}).CanAcceptEndpoints(1000, 1) {
+		t.Errorf("expected a zone without max endpoints to accept endpoints")
+	}
+}
+
+func TestZonesByLabel(t *testing.T) {
+	region, err := CreateRegionInfo([]Zone{
+		{Name: "zoneA", Nodes: 1, Endpoints: 1, Labels: map[string]string{"region": "us", "tier": "gold"}},
+		{Name: "zoneB", Nodes: 1, Endpoints: 1, Labels: map[string]string{"region": "eu", "tier": "gold"}},
+		{Name: "zoneC", Nodes: 1, Endpoints: 1, Labels: map[string]string{"region": "us"}},
+		{Name: "zoneD", Nodes: 1, Endpoints: 1},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating region: %v", err)
+	}
+	if labels := region.ZoneDetails["zoneA"].Labels; !reflect.DeepEqual(labels, map[string]string{"region": "us", "tier": "gold"}) {
+		t.Errorf("expected labels to be preserved, got %v", labels)
+	}
+	testCases := []struct {
+		key, value    string
+		expectedZones []string
+	}{
+		{key: "region", value: "us", expectedZones: []string{"zoneA", "zoneC"}},
+		{key: "region", value: "eu", expectedZones: []string{"zoneB"}},
+		{key: "tier", value: "gold", expectedZones: []string{"zoneA", "zoneB"}},
+		{key: "tier", value: "", expectedZones: nil},
+		{key: "rack", value: "1", expectedZones: nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.key+"="+tc.value, func(t *testing.T) {
+			if zones := region.ZonesByLabel(tc.key, tc.value); !reflect.DeepEqual(zones, tc.expectedZones) {
+				t.Errorf("expected zones %v, got %v", tc.expectedZones, zones)
+			}
+		})
+	}
+}
+
+func TestCreateRegionInfoDuplicateNames(t *testing.T) {
+	testCases := []struct {
+		name        string
+		zones       []Zone
+		expectedErr string
+	}{
+		{
+			name:        "two zones with the same name",
+			zones:       []Zone{{Name: "zoneA", Nodes: 1, Endpoints: 1}, {Name: "zoneA", Nodes: 2, Endpoints: 2}},
+			expectedErr: "duplicate zone name: zoneA",
+		},
+		{
+			name:        "one duplicated pair of three zones",
+			zones:       []Zone{{Name: "zoneA", Nodes: 1, Endpoints: 1}, {Name: "zoneB", Nodes: 2, Endpoints: 2}, {Name: "zoneB", Nodes: 3, Endpoints: 3}},
+			expectedErr: "duplicate zone name: zoneB",
+		},
+		{
+			name:  "unique names",
+			zones: []Zone{{Name: "zoneA", Nodes: 1, Endpoints: 1}, {Name: "zoneB", Nodes: 2, Endpoints: 2}, {Name: "zoneC", Nodes: 3, Endpoints: 3}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			region, err := CreateRegionInfo(tc.zones)
+			if tc.expectedErr != "" {
+				if err == nil || err.Error() != tc.expectedErr {
+					t.Errorf("expected error %q, got %v", tc.expectedErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error creating region: %v", err)
+			}
+			if len(region.ZoneDetails) != len(tc.zones) || region.TotalNodes != 6 || region.TotalEndpoints != 6 {
+				t.Errorf("got unexpected region %+v", region)
+			}
+		})
+	}
+}
+
+func TestCreateRegionInfoEmptyName(t *testing.T) {
+	testCases := []struct {
+		name        string
+		zones       []Zone
+		expectedErr bool
+	}{
+		{
+			name:        "one empty name with valid zones",
+			zones:       []Zone{{Name: "zoneA", Nodes: 1, Endpoints: 1}, {Name: "", Nodes: 2, Endpoints: 2}, {Name: "zoneC", Nodes: 3, Endpoints: 3}},
+			expectedErr: true,
+		},
+		{
+			name:  "all valid names",
+			zones: []Zone{{Name: "zoneA", Nodes: 1, Endpoints: 1}, {Name: "zoneB", Nodes: 2, Endpoints: 2}, {Name: "zoneC", Nodes: 3, Endpoints: 3}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			region, err := CreateRegionInfo(tc.zones)
+			if tc.expectedErr {
+				if err == nil || err.Error() != "zone name must not be empty" {
+					t.Errorf("expected empty zone name error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error creating region: %v", err)
+			}
+			if len(region.ZoneDetails) != len(tc.zones) {
+				t.Errorf("expected %d zones, got %+v", len(tc.zones), region.ZoneDetails)
+			}
+		})
+	}
+}
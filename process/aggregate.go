@@ -0,0 +1,164 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"encoding/csv"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// highInZoneTraffic is the in-zone traffic ratio above which a row counts
+// towards HighInZoneTrafficRatio
+const highInZoneTraffic = 0.9
+
+// AggregateStats summarizes the results of all rows of a processing run
+type AggregateStats struct {
+	// MeanScore, P5Score, P95Score, MaxScore and MinScore describe the
+	// distribution of scores of valid rows
+	MeanScore float64
+	P5Score   float64
+	P95Score  float64
+	MaxScore  float64
+	MinScore  float64
+	// FallbackRatio is the ratio of rows whose EndpointSliceGroups are the same
+	// as OriginalAlgorithm creates
+	FallbackRatio float64
+	// HighInZoneTrafficRatio is the ratio of rows with more than 90% in-zone
+	// traffic
+	HighInZoneTrafficRatio float64
+}
+
+// ComputeAggregateStats computes the aggregate statistics of rows. Score
+// statistics only cover valid rows, ratios are over all rows.
+func ComputeAggregateStats(rows []outputData) AggregateStats {
+	var stats AggregateStats
+	if len(rows) == 0 {
+		return stats
+	}
+	var rowScores []float64
+	fallbacks, highInZoneTraffics := 0, 0
+	for _, rowData := range rows {
+		if rowData.fallback {
+			fallbacks++
+		}
+		if rowData.result.Invalid {
+			continue
+		}
+		if rowData.result.InZoneTraffic > highInZoneTraffic {
+			highInZoneTraffics++
+		}
+		score := evaluate(rowData).Total
+		if len(rowScores) == 0 || score > stats.MaxScore {
+			stats.MaxScore = score
+		}
+		if len(rowScores) == 0 || score < stats.MinScore {
+			stats.MinScore = score
+		}
+		stats.MeanScore += score
+		rowScores = append(rowScores, score)
+	}
+	stats.FallbackRatio = float64(fallbacks) / float64(len(rows))
+	stats.HighInZoneTrafficRatio = float64(highInZoneTraffics) / float64(len(rows))
+	if len(rowScores) == 0 {
+		return stats
+	}
+	stats.MeanScore /= float64(len(rowScores))
+	stats.P5Score = percentile(rowScores, 5)
+	stats.P95Score = percentile(rowScores, 95)
+	return stats
+}
+
+// SlowestRows returns at most n rows sorted by elapsed time in descending
+// order. rows isn't modified.
+func SlowestRows(rows []outputData, n int) []outputData {
+	sorted := append([]outputData(nil), rows...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].ElapsedMs > sorted[j].ElapsedMs
+	})
+	if n < 0 {
+		n = 0
+	}
+	if n < len(sorted) {
+		sorted = sorted[:n]
+	}
+	return sorted
+}
+
+// writeAggregateStats writes stats as metric and value pairs to a csv file
+func writeAggregateStats(file string, stats AggregateStats) (err error) {
+	statsFile, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cerr := statsFile.Close()
+		if cerr != nil {
+			logger.Error("failed to close stats file", "file", file, "error", cerr)
+		}
+		if err == nil {
+			err = cerr
+		}
+	}()
+
+	logger.Info("writing aggregate stats", "file", file)
+	writer := csv.NewWriter(statsFile)
+	records := [][]string{
+		{"metric", "value"},
+		{"mean score", strconv.FormatFloat(stats.MeanScore, 'f', 4, 64)},
+		{"P5 score", strconv.FormatFloat(stats.P5Score, 'f', 4, 64)},
+		{"P95 score", strconv.FormatFloat(stats.P95Score, 'f', 4, 64)},
+		{"max score", strconv.FormatFloat(stats.MaxScore, 'f', 4, 64)},
+		{"min score", strconv.FormatFloat(stats.MinScore, 'f', 4, 64)},
+		{"fallback ratio", strconv.FormatFloat(stats.FallbackRatio, 'f', 4, 64)},
+		{"high in-zone traffic ratio", strconv.FormatFloat(stats.HighInZoneTrafficRatio, 'f', 4, 64)},
+	}
+	err = writer.WriteAll(records)
+	return err
+}
+
+// collectRows forwards every outputData from outputQueue to the returned queue
+// and appends it to rows. rows is complete once the returned queue is drained.
+func collectRows(outputQueue <-chan outputData, rows *[]outputData) <-chan outputData {
+	collectedQueue := make(chan outputData)
+	go func() {
+		defer close(collectedQueue)
+
+		for rowData := range outputQueue {
+			*rows = append(*rows, rowData)
+			collectedQueue <- rowData
+		}
+	}()
+	return collectedQueue
+}
+
+// collectMultiAlgorithmRows forwards every multiAlgorithmData from multiQueue
+// to the returned queue and appends the results of all its algorithms to rows.
+// rows is complete once the returned queue is drained.
+func collectMultiAlgorithmRows(multiQueue <-chan multiAlgorithmData, rows *[]outputData) <-chan multiAlgorithmData {
+	collectedQueue := make(chan multiAlgorithmData)
+	go func() {
+		defer close(collectedQueue)
+
+		for multiData := range multiQueue {
+			*rows = append(*rows, multiData.results...)
+			collectedQueue <- multiData
+		}
+	}()
+	return collectedQueue
+}
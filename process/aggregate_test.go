@@ -0,0 +1,137 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"math"
+	"path/filepath"
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+func TestComputeAggregateStats(t *testing.T) {
+	// without deviation and EndpointSlices, the score of row i is
+	// 0.45 * 100 * i / 20 + 0.4 * 100 = 2.25 * i + 40
+	var rows []outputData
+	for i := 1; i <= 20; i++ {
+		rows = append(rows, outputData{
+			name:     "row",
+			result:   types.SimulationResult{InZoneTraffic: float64(i) / 20},
+			fallback: i%5 == 0,
+		})
+	}
+	testCases := []struct {
+		name     string
+		rows     []outputData
+		expected AggregateStats
+	}{
+		{
+			name: "20 rows",
+			rows: rows,
+			expected: AggregateStats{
+				MeanScore:              63.625,
+				P5Score:                42.25,
+				P95Score:               82.75,
+				MaxScore:               85,
+				MinScore:               42.25,
+				FallbackRatio:          0.2,
+				HighInZoneTrafficRatio: 0.1,
+			},
+		},
+		{
+			name: "invalid rows",
+			rows: append([]outputData{{name: "invalid", result: types.SimulationResult{Invalid: true}, fallback: true}}, rows[19]),
+			expected: AggregateStats{
+				MeanScore:              85,
+				P5Score:                85,
+				P95Score:               85,
+				MaxScore:               85,
+				MinScore:               85,
+				FallbackRatio:          1,
+				HighInZoneTrafficRatio: 0.5,
+			},
+		},
+		{
+			name:     "no rows",
+			expected: AggregateStats{},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			stats := ComputeAggregateStats(tc.rows)
+			actual := []float64{stats.MeanScore, stats.P5Score, stats.P95Score, stats.MaxScore, stats.MinScore, stats.FallbackRatio, stats.HighInZoneTrafficRatio}
+			expected := []float64{tc.expected.MeanScore, tc.expected.P5Score, tc.expected.P95Score, tc.expected.MaxScore, tc.expected.MinScore, tc.expected.FallbackRatio, tc.expected.HighInZoneTrafficRatio}
+			for i := range actual {
+				if math.Abs(actual[i]-expected[i]) > 1e-9 {
+					t.Errorf("expected stats %+v, got %+v", tc.expected, stats)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestStatsOutput(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,10 10,10 10,10 10\nunbalanced,1 5,2 20,7 20\n")
+	dir := t.TempDir()
+	config := Config{InputFile: input, OutputFile: filepath.Join(dir, "output.csv"), StatsFile: filepath.Join(dir, "stats.csv"), Algorithm: "Original"}
+	if err := StartProcessingWithConfig(config); err != nil {
+		t.Fatalf("unexpected error processing: %v", err)
+	}
+	records := readOutput(t, config.StatsFile)
+	if len(records) != 8 {
+		t.Fatalf("expected 8 rows, got %d: %v", len(records), records)
+	}
+	// every row of OriginalAlgorithm only has the global EndpointSliceGroup
+	if records[6][0] != "fallback ratio" || records[6][1] != "1.0000" {
+		t.Errorf("expected fallback ratio 1.0000, got %v", records[6])
+	}
+}
+
+func TestSlowestRows(t *testing.T) {
+	rows := []outputData{
+		{name: "fast", ElapsedMs: 1},
+		{name: "slowest", ElapsedMs: 30},
+		{name: "medium", ElapsedMs: 5},
+		{name: "slow", ElapsedMs: 10},
+	}
+	testCases := []struct {
+		n        int
+		expected []string
+	}{
+		{n: 0, expected: nil},
+		{n: 2, expected: []string{"slowest", "slow"}},
+		{n: 4, expected: []string{"slowest", "slow", "medium", "fast"}},
+		{n: 10, expected: []string{"slowest", "slow", "medium", "fast"}},
+	}
+	for _, tc := range testCases {
+		slowest := SlowestRows(rows, tc.n)
+		if len(slowest) != len(tc.expected) {
+			t.Errorf("expected %d slowest rows, got %d", len(tc.expected), len(slowest))
+			continue
+		}
+		for i, rowData := range slowest {
+			if rowData.name != tc.expected[i] {
+				t.Errorf("expected row %s at %d of the %d slowest rows, got %s", tc.expected[i], i, tc.n, rowData.name)
+			}
+		}
+	}
+	if rows[0].name != "fast" {
+		t.Errorf("expected rows not to be reordered, got %s first", rows[0].name)
+	}
+}
@@ -18,14 +18,15 @@ package process
 
 import (
 	"encoding/csv"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
-	"k8s.io/klog/v2"
 )
 
 // parseInput parses an input csv file to instances of inputData and puts them
@@ -36,17 +37,18 @@ func parseInput(file string) (<-chan inputData, error) {
 		return nil, err
 	}
 
-	klog.Infof("Reading data from %v\n", file)
+	logger.Info("reading input", "file", file)
 	reader := csv.NewReader(inputFile)
 	reader.TrimLeadingSpace = true
+	// column count is checked per row by readOneRow
+	reader.FieldsPerRecord = -1
 	line, err := reader.Read()
 	if err != nil {
 		return nil, err
 	}
-	var zoneNames []string
-	for _, name := range line[1:] {
-		name = strings.TrimSpace(name)
-		zoneNames = append(zoneNames, name)
+	header, zoneNames, err := parseHeader(line)
+	if err != nil {
+		return nil, err
 	}
 	inputQueue := make(chan inputData)
 
@@ -55,13 +57,16 @@ func parseInput(file string) (<-chan inputData, error) {
 		defer func() {
 			cerr := inputFile.Close()
 			if cerr != nil {
-				klog.Errorf("close input file %s with an error %v", file, cerr)
+				logger.Error("failed to close input file", "file", file, "error", cerr)
 			}
 		}()
 
-		for data, done, rerr := readOneRow(zoneNames, reader); !done; data, done, rerr = readOneRow(zoneNames, reader) {
+		// the header is the first row of the file
+		row := 1
+		for data, done, rerr := readOneRow(header, zoneNames, reader); !done; data, done, rerr = readOneRow(header, zoneNames, reader) {
+			row++
 			if rerr != nil {
-				klog.Errorf("can't parse input data: %v, due to error: %v, skip to next row\n", data.name, err)
+				logger.Error("can't parse input data, skip to next row", "row", row, "input_name", data.name, "error", rerr)
 				continue
 			}
 			inputQueue <- data
@@ -71,8 +76,77 @@ func parseInput(file string) (<-chan inputData, error) {
 	return inputQueue, err
 }
 
-// parse one row of input file to one instance of inputData
-func readOneRow(zoneNames []string, reader *csv.Reader) (inputData, bool, error) {
+// parseHeader returns the trimmed header of an input file and its sorted zone
+// names, zones are looked up by name so their columns can be in any order. It
+// returns an error if a zone name is duplicated.
+func parseHeader(line []string) ([]string, []string, error) {
+	var header, zoneNames []string
+	seen := map[string]bool{}
+	for index, name := range line {
+		name = strings.TrimSpace(name)
+		header = append(header, name)
+		// the first column is the input name, columns without name are
+		// checked per row
+		if index == 0 || name == "" {
+			continue
+		}
+		if seen[name] {
+			return nil, nil, fmt.Errorf("duplicate zone name %s in input header", name)
+		}
+		seen[name] = true
+		zoneNames = append(zoneNames, name)
+	}
+	sort.Strings(zoneNames)
+	return header, zoneNames, nil
+}
+
+// deduplicateInput returns a queue of inputData from inputQueue without rows
+// whose input name has been seen. The first row of a name is kept, or the last
+// one if keepLast is true, which reads all rows before putting any into the
+// queue. The number of skipped rows is logged at the end.
+func deduplicateInput(inputQueue <-chan inputData, keepLast bool) <-chan inputData {
+	dedupQueue := make(chan inputData)
+	go func() {
+		defer close(dedupQueue)
+
+		duplicates := 0
+		if keepLast {
+			var rows []inputData
+			lastIndex := map[string]int{}
+			for rowData := range inputQueue {
+				if _, seen := lastIndex[rowData.name]; seen {
+					logger.Warn("duplicated input row, keep the last one", "input_name", rowData.name)
+					duplicates++
+				}
+				lastIndex[rowData.name] = len(rows)
+				rows = append(rows, rowData)
+			}
+			for index, rowData := range rows {
+				if lastIndex[rowData.name] == index {
+					dedupQueue <- rowData
+				}
+			}
+		} else {
+			seen := map[string]struct{}{}
+			for rowData := range inputQueue {
+				if _, ok := seen[rowData.name]; ok {
+					logger.Warn("duplicated input row, skip to next row", "input_name", rowData.name)
+					duplicates++
+					continue
+				}
+				seen[rowData.name] = struct{}{}
+				dedupQueue <- rowData
+			}
+		}
+		logger.Info("deduplicated input", "duplicates", duplicates)
+	}()
+	return dedupQueue
+}
+
+// parse one row of input file to one instance of inputData, with zones in the
+// order of zoneNames. Cells are matched to zones by the column names of header,
+// columns without name are only allowed to be empty.
+func readOneRow(header []string, zoneNames []string, reader *csv.Reader) (inputData, bool, error) {
 	rowCells, err := reader.Read()
 	if err == io.EOF {
 		return inputData{}, true, nil
@@ -82,8 +156,25 @@ func readOneRow(zoneNames []string, reader *csv.Reader) (inputData, bool, error)
 	}
 	var rowData inputData
 	rowData.name = rowCells[0]
-	for index, data := range rowCells[1:] {
-		nodeStr := strings.Fields(data)
+	if len(rowCells) != len(header) {
+		return rowData, false, fmt.Errorf("expected %d columns, got %d", len(header), len(rowCells))
+	}
+	cells := map[string]string{}
+	for index, name := range header[1:] {
+		cell := rowCells[index+1]
+		if name == "" {
+			if strings.TrimSpace(cell) != "" {
+				return rowData, false, fmt.Errorf("zone name of column %d must not be empty", index+2)
+			}
+			continue
+		}
+		cells[name] = cell
+	}
+	for _, zoneName := range zoneNames {
+		nodeStr := strings.Fields(cells[zoneName])
+		if len(nodeStr) < 2 {
+			return rowData, false, fmt.Errorf("zone %s expects number of nodes and endpoints, got %q", zoneName, cells[zoneName])
+		}
 		// convert string to int. number of nodes in a zone
 		numNodes, err := strconv.Atoi(nodeStr[0])
 		if err != nil {
@@ -94,11 +185,63 @@ func readOneRow(zoneNames []string, reader *csv.Reader) (inputData, bool, error)
 		if err != nil {
 			return rowData, false, err
 		}
-		rowData.zones = append(rowData.zones, types.Zone{
+		zone := types.Zone{
 			Nodes:     numNodes,
 			Endpoints: numEndpoints,
-			Name:      zoneNames[index],
-		})
+			Name:      zoneName,
+		}
+		err = parseZoneOptions(&zone, nodeStr[2:])
+		if err != nil {
+			return rowData, false, err
+		}
+		rowData.zones = append(rowData.zones, zone)
 	}
 	return rowData, false, nil
 }
+
+// parseZoneOptions parses the optional fields of a zone cell following the
+// number of nodes and endpoints
+// labels: key=value pairs separated by commas, i.e. region=us,tier=gold
+// latency: average cross-zone latency with a ms suffix, i.e. 2.5ms
+func parseZoneOptions(zone *types.Zone, options []string) error {
+	for _, option := range options {
+		if strings.HasSuffix(option, "ms") && !strings.Contains(option, "=") {
+			latency, err := strconv.ParseFloat(strings.TrimSuffix(option, "ms"), 64)
+			if err != nil {
+				return fmt.Errorf("cross-zone latency %q is not a number", option)
+			}
+			if latency < 0 {
+				return fmt.Errorf("cross-zone latency %q should not be negative", option)
+			}
+			zone.AvgCrossZoneLatencyMs = latency
+			continue
+		}
+		if !strings.Contains(option, "=") {
+			return fmt.Errorf("unknown zone option %q", option)
+		}
+		labels, err := parseLabels(option)
+		if err != nil {
+			return err
+		}
+		if zone.Labels == nil {
+			zone.Labels = map[string]string{}
+		}
+		for key, value := range labels {
+			zone.Labels[key] = value
+		}
+	}
+	return nil
+}
+
+// parseLabels parses key=value pairs separated by commas
+func parseLabels(option string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(option, ",") {
+		keyValue := strings.SplitN(pair, "=", 2)
+		if len(keyValue) != 2 || keyValue[0] == "" {
+			return nil, fmt.Errorf("label %q should be in the form of key=value", pair)
+		}
+		labels[keyValue[0]] = keyValue[1]
+	}
+	return labels, nil
+}
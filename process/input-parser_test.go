@@ -0,0 +1,225 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseInputLabels(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nrow1,\"1 5 region=us,tier=gold\",2 20 region=eu,7 20\nrow2,1 5 region,2 20,7 20\n")
+	inputQueue, err := parseInput(input)
+	if err != nil {
+		t.Fatalf("unexpected error parsing input: %v", err)
+	}
+	var rows []inputData
+	for rowData := range inputQueue {
+		rows = append(rows, rowData)
+	}
+	// the second row has a malformed label and is skipped
+	if len(rows) != 1 || rows[0].name != "row1" {
+		t.Fatalf("expected only row1 to be parsed, got %+v", rows)
+	}
+	expectedLabels := []map[string]string{
+		{"region": "us", "tier": "gold"},
+		{"region": "eu"},
+		nil,
+	}
+	for index, zone := range rows[0].zones {
+		if zone.Nodes == 0 || zone.Endpoints == 0 {
+			t.Errorf("expected nodes and endpoints of %s to be parsed, got %+v", zone.Name, zone)
+		}
+		if !reflect.DeepEqual(zone.Labels, expectedLabels[index]) {
+			t.Errorf("expected labels of %s %v, got %v", zone.Name, expectedLabels[index], zone.Labels)
+		}
+	}
+}
+
+func TestParseInputLatency(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB\nrow1,\"1 5 region=us 2.5ms\",2 20\n")
+	inputQueue, err := parseInput(input)
+	if err != nil {
+		t.Fatalf("unexpected error parsing input: %v", err)
+	}
+	var rows []inputData
+	for rowData := range inputQueue {
+		rows = append(rows, rowData)
+	}
+	if len(rows) != 1 || len(rows[0].zones) != 2 {
+		t.Fatalf("expected one row of 2 zones, got %+v", rows)
+	}
+	if zone := rows[0].zones[0]; zone.AvgCrossZoneLatencyMs != 2.5 || zone.Labels["region"] != "us" {
+		t.Errorf("expected zoneA with 2.5ms latency and region us, got %+v", zone)
+	}
+	if zone := rows[0].zones[1]; zone.AvgCrossZoneLatencyMs != 0 {
+		t.Errorf("expected zoneB without latency, got %+v", zone)
+	}
+}
+
+func TestParseInputEmptyZoneName(t *testing.T) {
+	testCases := []struct {
+		name         string
+		content      string
+		expectedRows int
+	}{
+		{name: "one empty zone name", content: "name,zoneA, ,zoneC\nrow1,1 5,2 20,7 20\n", expectedRows: 0},
+		{name: "all valid zone names", content: "name,zoneA,zoneB,zoneC\nrow1,1 5,2 20,7 20\n", expectedRows: 1},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			inputQueue, err := parseInput(writeInput(t, tc.content))
+			if err != nil {
+				t.Fatalf("unexpected error parsing input: %v", err)
+			}
+			var rows []inputData
+			for rowData := range inputQueue {
+				rows = append(rows, rowData)
+			}
+			// rows with a zone without name are skipped
+			if len(rows) != tc.expectedRows {
+				t.Errorf("expected %d rows, got %+v", tc.expectedRows, rows)
+			}
+		})
+	}
+}
+
+func TestDeduplicateInput(t *testing.T) {
+	// rows are "nodes endpoints" of zoneA, the duplicated rows differ in the
+	// number of nodes
+	input := "name,zoneA,zoneB\nrow1,1 5,2 20\nrow2,3 5,2 20\nrow1,7 5,2 20\n"
+	testCases := []struct {
+		name          string
+		config        Config
+		expectedRows  []string
+		expectedNodes []int
+	}{
+		{
+			name:          "no deduplication",
+			config:        Config{},
+			expectedRows:  []string{"row1", "row2", "row1"},
+			expectedNodes: []int{1, 3, 7},
+		},
+		{
+			name:          "keep first",
+			config:        Config{Deduplicate: true},
+			expectedRows:  []string{"row1", "row2"},
+			expectedNodes: []int{1, 3},
+		},
+		{
+			name:          "keep last",
+			config:        Config{Deduplicate: true, DeduplicateKeepLast: true},
+			expectedRows:  []string{"row2", "row1"},
+			expectedNodes: []int{3, 7},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config.InputFile = writeInput(t, input)
+			inputQueue, err := readInput(tc.config)
+			if err != nil {
+				t.Fatalf("unexpected error reading input: %v", err)
+			}
+			var rows []string
+			var nodes []int
+			for rowData := range inputQueue {
+				rows = append(rows, rowData.name)
+				nodes = append(nodes, rowData.zones[0].Nodes)
+			}
+			if !reflect.DeepEqual(rows, tc.expectedRows) || !reflect.DeepEqual(nodes, tc.expectedNodes) {
+				t.Errorf("expected rows %v with nodes %v, got %v with nodes %v", tc.expectedRows, tc.expectedNodes, rows, nodes)
+			}
+		})
+	}
+
+	config := Config{InputFile: writeInput(t, input), OutputFile: filepath.Join(t.TempDir(), "output.csv"), Algorithm: "Local", NoSummary: true, Deduplicate: true}
+	if err := StartProcessingWithConfig(config); err != nil {
+		t.Fatalf("unexpected error processing: %v", err)
+	}
+	// header and two rows
+	if records := readOutput(t, config.OutputFile); len(records) != 3 {
+		t.Errorf("expected 2 output rows, got %v", records[1:])
+	}
+}
+
+func TestParseInputColumnOrder(t *testing.T) {
+	parse := func(content string) ([]inputData, error) {
+		inputQueue, err := parseInput(writeInput(t, content))
+		if err != nil {
+			return nil, err
+		}
+		var rows []inputData
+		for rowData := range inputQueue {
+			rows = append(rows, rowData)
+		}
+		return rows, nil
+	}
+	expected, err := parse("name,zoneA,zoneB,zoneC\nrow1,1 5,2 20,7 20\n")
+	if err != nil {
+		t.Fatalf("unexpected error parsing input: %v", err)
+	}
+
+	testCases := []struct {
+		name         string
+		content      string
+		expectedRows []inputData
+		expectedErr  bool
+	}{
+		{name: "reversed zone columns", content: "name,zoneC,zoneB,zoneA\nrow1,7 20,2 20,1 5\n", expectedRows: expected},
+		{name: "extra empty column", content: "name,zoneA,,zoneB,zoneC\nrow1,1 5,,2 20,7 20\n", expectedRows: expected},
+		{name: "duplicate zone columns", content: "name,zoneA,zoneB,zoneA\nrow1,1 5,2 20,7 20\n", expectedErr: true},
+		// rows with a different number of columns are skipped
+		{name: "column count mismatch", content: "name,zoneA,zoneB,zoneC\nshort,1 5,2 20\nrow1,1 5,2 20,7 20\nlong,1 5,2 20,7 20,1 1\n", expectedRows: expected},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rows, err := parse(tc.content)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %v, got %v", tc.expectedErr, err)
+			}
+			if !reflect.DeepEqual(rows, tc.expectedRows) {
+				t.Errorf("expected rows %+v, got %+v", tc.expectedRows, rows)
+			}
+		})
+	}
+}
+
+func TestSkipMalformedRows(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nrow1,1 5,2 20,7 20\nrow2,10 10,10 10,10 10\nrow3,1 x,2 20,7 20\nrow4,1 1,1 2,1 3\nrow5,3 5,3 5,3 5\n")
+	config := Config{InputFile: input, OutputFile: filepath.Join(t.TempDir(), "output.csv"), Algorithm: "LocalShared"}
+	if err := StartProcessingWithConfig(config); err != nil {
+		t.Fatalf("unexpected error processing: %v", err)
+	}
+	var names []string
+	for _, record := range readOutput(t, config.OutputFile)[1:] {
+		if record[1] == "invalid" {
+			t.Errorf("expected row %s to be processed correctly, got %v", record[0], record)
+		}
+		names = append(names, record[0])
+	}
+	if expected := []string{"row1", "row2", "row4", "row5", "AGGREGATE"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected rows %v, got %v", expected, names)
+	}
+
+	config.Strict = true
+	// the header is the first row of the file
+	if err := StartProcessingWithConfig(config); err == nil || !strings.HasPrefix(err.Error(), "row 4, column zoneA") {
+		t.Errorf("expected strict processing to fail on row 4, got %v", err)
+	}
+}
@@ -0,0 +1,213 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+	"sigs.k8s.io/yaml"
+)
+
+// defaultEndpointsPerSlice is the max number of endpoints of an EndpointSlice
+// created by Kubernetes by default
+const defaultEndpointsPerSlice = 100
+
+// invalidNameChars matches characters not allowed in Kubernetes object names
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// endpointSlice is a discovery.k8s.io/v1 EndpointSlice with the fields the
+// simulator can derive from an EndpointSliceGroup
+type endpointSlice struct {
+	APIVersion  string     `json:"apiVersion"`
+	Kind        string     `json:"kind"`
+	Metadata    objectMeta `json:"metadata"`
+	AddressType string     `json:"addressType"`
+	Endpoints   []endpoint `json:"endpoints"`
+}
+
+// objectMeta is the metadata of an EndpointSlice
+type objectMeta struct {
+	Name      string            `json:"name"`
+	Namespace string            `json:"namespace"`
+	Labels    map[string]string `json:"labels"`
+}
+
+// endpoint is one endpoint of an EndpointSlice
+type endpoint struct {
+	Addresses []string       `json:"addresses"`
+	Zone      string         `json:"zone"`
+	Hints     *endpointHints `json:"hints,omitempty"`
+}
+
+// endpointHints are the topology hints of an endpoint
+type endpointHints struct {
+	ForZones []forZone `json:"forZones"`
+}
+
+// forZone is a zone an endpoint should be consumed by
+type forZone struct {
+	Name string `json:"name"`
+}
+
+// GenerateEndpointSliceYAML generates discovery.k8s.io/v1 EndpointSlices of a
+// service from sliceGroups as a multi-document YAML. Every EndpointSliceGroup
+// becomes one EndpointSlice, or several if it has more than 100 endpoints, and
+// the zones with a positive traffic weight become the forZones hints of its
+// endpoints. Endpoint addresses are placeholders unique per zone.
+func GenerateEndpointSliceYAML(sliceGroups map[string]types.EndpointSliceGroup, namespace, serviceName string) ([]byte, error) {
+	return generateEndpointSliceYAML(sliceGroups, namespace, serviceName, defaultEndpointsPerSlice)
+}
+
+// generateEndpointSliceYAML is GenerateEndpointSliceYAML with EndpointSlices
+// holding at most capacity endpoints
+func generateEndpointSliceYAML(sliceGroups map[string]types.EndpointSliceGroup, namespace, serviceName string, capacity int) ([]byte, error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("endpoints per slice %d should be positive", capacity)
+	}
+	var buffer bytes.Buffer
+	for index, slice := range newEndpointSlices(sliceGroups, namespace, serviceName, capacity) {
+		content, err := yaml.Marshal(slice)
+		if err != nil {
+			return nil, err
+		}
+		if index > 0 {
+			buffer.WriteString("---\n")
+		}
+		buffer.Write(content)
+	}
+	return buffer.Bytes(), nil
+}
+
+// newEndpointSlices converts sliceGroups to EndpointSlices in label order, with
+// zones of a group and its endpoints in name order
+func newEndpointSlices(sliceGroups map[string]types.EndpointSliceGroup, namespace, serviceName string, capacity int) []endpointSlice {
+	var labels []string
+	for label := range sliceGroups {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+
+	// endpoints of a zone can be spread over several groups, the address
+	// counter of every zone keeps addresses unique
+	zoneIndex := map[string]int{}
+	addressCount := map[string]int{}
+	var slices []endpointSlice
+	for _, label := range labels {
+		sliceGroup := sliceGroups[label]
+		var hints *endpointHints
+		for _, zone := range sortedKeys(sliceGroup.ZoneTrafficWeights) {
+			if sliceGroup.ZoneTrafficWeights[zone] > 0 {
+				if hints == nil {
+					hints = &endpointHints{}
+				}
+				hints.ForZones = append(hints.ForZones, forZone{Name: zone})
+			}
+		}
+		var endpoints []endpoint
+		for _, zone := range sortedKeys(sliceGroup.Composition) {
+			if _, ok := zoneIndex[zone]; !ok {
+				zoneIndex[zone] = len(zoneIndex)
+			}
+			for i := 0; i < sliceGroup.Composition[zone].Number; i++ {
+				count := addressCount[zone]
+				addressCount[zone]++
+				address := fmt.Sprintf("10.%d.%d.%d", zoneIndex[zone]%256, count/256%256, count%256)
+				endpoints = append(endpoints, endpoint{Addresses: []string{address}, Zone: zone, Hints: hints})
+			}
+		}
+		for part := 0; part == 0 || part*capacity < len(endpoints); part++ {
+			end := (part + 1) * capacity
+			if end > len(endpoints) {
+				end = len(endpoints)
+			}
+			slices = append(slices, endpointSlice{
+				APIVersion: "discovery.k8s.io/v1",
+				Kind:       "EndpointSlice",
+				Metadata: objectMeta{
+					Name:      fmt.Sprintf("%s-%s-%d", objectName(serviceName), objectName(label), part),
+					Namespace: namespace,
+					Labels:    map[string]string{"kubernetes.io/service-name": serviceName},
+				},
+				AddressType: "IPv4",
+				Endpoints:   endpoints[part*capacity : end],
+			})
+		}
+	}
+	return slices
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// objectName converts name to a valid Kubernetes object name
+func objectName(name string) string {
+	name = strings.Trim(invalidNameChars.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if name == "" {
+		return "unnamed"
+	}
+	return name
+}
+
+// writeEndpointSliceYAML writes the EndpointSlices of all rows to file, every
+// row is a service named after its input name in the default namespace
+func writeEndpointSliceYAML(file string, rows []outputData) (err error) {
+	var buffer bytes.Buffer
+	for _, rowData := range rows {
+		capacity := rowData.sliceCapacity
+		if capacity <= 0 {
+			capacity = defaultEndpointsPerSlice
+		}
+		content, err := generateEndpointSliceYAML(rowData.sliceGroups, "default", objectName(rowData.name), capacity)
+		if err != nil {
+			return err
+		}
+		if buffer.Len() > 0 && len(content) > 0 {
+			buffer.WriteString("---\n")
+		}
+		buffer.Write(content)
+	}
+
+	outputFile, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cerr := outputFile.Close()
+		if cerr != nil {
+			logger.Error("failed to close output file", "file", file, "error", cerr)
+		}
+		if err == nil {
+			err = cerr
+		}
+	}()
+	logger.Info("writing output", "file", file)
+	_, err = outputFile.Write(buffer.Bytes())
+	return err
+}
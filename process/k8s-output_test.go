@@ -0,0 +1,158 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+	"sigs.k8s.io/yaml"
+)
+
+func TestGenerateEndpointSliceYAML(t *testing.T) {
+	sliceGroups := map[string]types.EndpointSliceGroup{
+		"zoneA": {
+			Composition:        map[string]types.WeightedEndpoints{"zoneA": {Number: 2}},
+			ZoneTrafficWeights: map[string]float64{"zoneA": 1},
+		},
+		"zoneB": {
+			Composition:        map[string]types.WeightedEndpoints{"zoneA": {Number: 1}, "zoneB": {Number: 1}},
+			ZoneTrafficWeights: map[string]float64{"zoneA": 0, "zoneB": 0.5, "zoneC": 0.5},
+		},
+	}
+	content, err := GenerateEndpointSliceYAML(sliceGroups, "test", "Svc")
+	if err != nil {
+		t.Fatalf("unexpected error generating yaml: %v", err)
+	}
+	slices := parseEndpointSlices(t, content)
+	if len(slices) != 2 {
+		t.Fatalf("expected 2 EndpointSlices, got %d", len(slices))
+	}
+
+	expected := []struct {
+		name     string
+		zones    []string
+		forZones []forZone
+	}{
+		{name: "svc-zonea-0", zones: []string{"zoneA", "zoneA"}, forZones: []forZone{{Name: "zoneA"}}},
+		{name: "svc-zoneb-0", zones: []string{"zoneA", "zoneB"}, forZones: []forZone{{Name: "zoneB"}, {Name: "zoneC"}}},
+	}
+	addresses := map[string]bool{}
+	for index, slice := range slices {
+		if slice.APIVersion != "discovery.k8s.io/v1" || slice.Kind != "EndpointSlice" || slice.AddressType != "IPv4" {
+			t.Errorf("unexpected type of EndpointSlice %d: %+v", index, slice)
+		}
+		if slice.Metadata.Name != expected[index].name || slice.Metadata.Namespace != "test" || slice.Metadata.Labels["kubernetes.io/service-name"] != "Svc" {
+			t.Errorf("unexpected metadata of EndpointSlice %d: %+v", index, slice.Metadata)
+		}
+		var zones []string
+		for _, endpoint := range slice.Endpoints {
+			zones = append(zones, endpoint.Zone)
+			if endpoint.Hints == nil || !reflect.DeepEqual(endpoint.Hints.ForZones, expected[index].forZones) {
+				t.Errorf("expected hints %+v of EndpointSlice %s, got %+v", expected[index].forZones, slice.Metadata.Name, endpoint.Hints)
+			}
+			for _, address := range endpoint.Addresses {
+				if addresses[address] {
+					t.Errorf("duplicate address %s", address)
+				}
+				addresses[address] = true
+			}
+		}
+		if !reflect.DeepEqual(zones, expected[index].zones) {
+			t.Errorf("expected endpoint zones %v of EndpointSlice %s, got %v", expected[index].zones, slice.Metadata.Name, zones)
+		}
+	}
+}
+
+func TestGenerateEndpointSliceYAMLSplitting(t *testing.T) {
+	sliceGroups := map[string]types.EndpointSliceGroup{
+		"global": {
+			Composition:        map[string]types.WeightedEndpoints{"zoneA": {Number: 150}, "zoneB": {Number: 100}},
+			ZoneTrafficWeights: map[string]float64{"zoneA": 1, "zoneB": 1},
+		},
+	}
+	testCases := []struct {
+		capacity int
+		expected []int
+	}{
+		{capacity: 100, expected: []int{100, 100, 50}},
+		{capacity: 250, expected: []int{250}},
+		{capacity: 1000, expected: []int{250}},
+	}
+	for _, tc := range testCases {
+		content, err := generateEndpointSliceYAML(sliceGroups, "default", "svc", tc.capacity)
+		if err != nil {
+			t.Fatalf("unexpected error generating yaml with %d endpoints per slice: %v", tc.capacity, err)
+		}
+		var actual []int
+		for _, slice := range parseEndpointSlices(t, content) {
+			actual = append(actual, len(slice.Endpoints))
+		}
+		if !reflect.DeepEqual(actual, tc.expected) {
+			t.Errorf("expected EndpointSlice sizes %v with %d endpoints per slice, got %v", tc.expected, tc.capacity, actual)
+		}
+	}
+
+	if _, err := generateEndpointSliceYAML(sliceGroups, "default", "svc", 0); err == nil {
+		t.Errorf("expected an error with 0 endpoints per slice")
+	}
+}
+
+func TestK8sYAMLOutput(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,10 10,10 10,10 10\n")
+	config := Config{InputFile: input, OutputFile: filepath.Join(t.TempDir(), "output.yaml"), Algorithm: "Local", OutputFormat: "k8s-yaml"}
+	if err := StartProcessingWithConfig(config); err != nil {
+		t.Fatalf("unexpected error processing: %v", err)
+	}
+	content, err := os.ReadFile(config.OutputFile)
+	if err != nil {
+		t.Fatalf("unexpected error reading yaml output: %v", err)
+	}
+	slices := parseEndpointSlices(t, content)
+	// every zone of a balanced region gets its own EndpointSlice
+	if len(slices) != 3 {
+		t.Fatalf("expected 3 EndpointSlices, got %d", len(slices))
+	}
+	for _, slice := range slices {
+		if len(slice.Endpoints) != 10 || slice.Metadata.Labels["kubernetes.io/service-name"] != "balanced" {
+			t.Errorf("unexpected EndpointSlice %s with %d endpoints and labels %v", slice.Metadata.Name, len(slice.Endpoints), slice.Metadata.Labels)
+		}
+	}
+
+	config.MatrixFile = filepath.Join(t.TempDir(), "matrix.csv")
+	if err := StartProcessingWithConfig(config); err == nil {
+		t.Errorf("expected an error with a matrix file and k8s-yaml output")
+	}
+}
+
+// parseEndpointSlices parses a multi-document yaml of EndpointSlices
+func parseEndpointSlices(t *testing.T, content []byte) []endpointSlice {
+	t.Helper()
+	var slices []endpointSlice
+	for _, document := range bytes.Split(content, []byte("---\n")) {
+		var slice endpointSlice
+		if err := yaml.UnmarshalStrict(document, &slice); err != nil {
+			t.Fatalf("unexpected error parsing yaml: %v", err)
+		}
+		slices = append(slices, slice)
+	}
+	return slices
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestJSONLogging(t *testing.T) {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("unexpected error creating pipe: %v", err)
+	}
+	stderr := os.Stderr
+	os.Stderr = writer
+	defaultLogger := logger
+	SetLogger(slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+	defer func() {
+		os.Stderr = stderr
+		SetLogger(defaultLogger)
+	}()
+
+	// the second row has an invalid number of nodes
+	input := writeInput(t, "name,zoneA,zoneB\nvalid,1 5,2 20\ninvalid,-1 5,2 20\n")
+	config := Config{InputFile: input, OutputFile: filepath.Join(t.TempDir(), "output.csv"), Algorithm: "LocalShared"}
+	err = StartProcessingWithConfig(config)
+	if cerr := writer.Close(); cerr != nil {
+		t.Fatalf("unexpected error closing pipe: %v", cerr)
+	}
+	if err != nil {
+		t.Fatalf("unexpected error processing: %v", err)
+	}
+
+	entries := map[string]map[string]interface{}{}
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		var entry map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("expected valid JSON log line, got %q: %v", scanner.Text(), err)
+		}
+		if entry["level"] == nil || entry["msg"] == nil {
+			t.Errorf("expected level and msg in log line, got %v", entry)
+		}
+		if name, ok := entry["input_name"].(string); ok {
+			entries[name] = entry
+		}
+	}
+	for name, level := range map[string]string{"valid": "INFO", "invalid": "ERROR"} {
+		entry, ok := entries[name]
+		if !ok {
+			t.Errorf("expected a log line of input %s", name)
+			continue
+		}
+		if entry["level"] != level || entry["algorithm"] != "LocalShared" || entry["elapsed_ms"] == nil {
+			t.Errorf("got unexpected log line of input %s: %v", name, entry)
+		}
+	}
+	if entry := entries["invalid"]; entry != nil && entry["error"] == nil {
+		t.Errorf("expected error details in log line, got %v", entry)
+	}
+}
+
+func TestMaxDeviationWarning(t *testing.T) {
+	defaultLogger := logger
+	defer SetLogger(defaultLogger)
+
+	// the local algorithm can't balance this row, its max deviation is 6.67%
+	input := writeInput(t, "name,zoneA,zoneB\nskewed,1 2,9 30\n")
+	testCases := []struct {
+		name            string
+		threshold       float64
+		expectedWarning bool
+	}{
+		{name: "disabled", threshold: 0, expectedWarning: false},
+		{name: "exceeded", threshold: 0.06, expectedWarning: true},
+		{name: "not exceeded", threshold: 0.07, expectedWarning: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+			config := Config{InputFile: input, OutputFile: filepath.Join(t.TempDir(), "output.csv"), Algorithm: "Local", MaxDeviationThreshold: tc.threshold}
+			if err := StartProcessingWithConfig(config); err != nil {
+				t.Fatalf("unexpected error processing: %v", err)
+			}
+			warned := false
+			scanner := bufio.NewScanner(&buf)
+			for scanner.Scan() {
+				var entry map[string]interface{}
+				if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+					t.Fatalf("expected valid JSON log line, got %q: %v", scanner.Text(), err)
+				}
+				if entry["level"] == "WARN" && entry["input_name"] == "skewed" {
+					warned = true
+				}
+			}
+			if warned != tc.expectedWarning {
+				t.Errorf("expected warning %v, got %v", tc.expectedWarning, warned)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// defaultMetricsJob is the push gateway job name used when none is configured
+const defaultMetricsJob = "k8s-topology-simulator"
+
+// PrometheusReporter records simulation results of every input row as gauge
+// metrics labeled by algorithm and input name, which can be pushed to a
+// Prometheus push gateway
+type PrometheusReporter struct {
+	// algorithm name of the results reported
+	algorithm string
+	// registry all gauges are registered to
+	registry      *prometheus.Registry
+	inZoneTraffic *prometheus.GaugeVec
+	meanDeviation *prometheus.GaugeVec
+	maxDeviation  *prometheus.GaugeVec
+	score         *prometheus.GaugeVec
+}
+
+// NewPrometheusReporter creates a PrometheusReporter for results of the
+// algorithm, with gauges registered to a new registry
+func NewPrometheusReporter(algorithm string) (*PrometheusReporter, error) {
+	labels := []string{"algorithm", "input_name"}
+	reporter := &PrometheusReporter{
+		algorithm: algorithm,
+		registry:  prometheus.NewRegistry(),
+		inZoneTraffic: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "k8s_topology_sim_in_zone_traffic",
+			Help: "Ratio of traffic that stays in the same zone.",
+		}, labels),
+		meanDeviation: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "k8s_topology_sim_mean_deviation",
+			Help: "Mean traffic load deviation of all endpoints.",
+		}, labels),
+		maxDeviation: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "k8s_topology_sim_max_deviation",
+			Help: "Max traffic load deviation of all endpoints.",
+		}, labels),
+		score: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "k8s_topology_sim_score",
+			Help: "Total score of the simulation result.",
+		}, labels),
+	}
+	for _, collector := range []prometheus.Collector{reporter.inZoneTraffic, reporter.meanDeviation, reporter.maxDeviation, reporter.score} {
+		if err := reporter.registry.Register(collector); err != nil {
+			return nil, err
+		}
+	}
+	return reporter, nil
+}
+
+// Report records the metrics of one outputData, invalid results are not
+// recorded
+func (r *PrometheusReporter) Report(rowData outputData) error {
+	if rowData.result.Invalid {
+		return nil
+	}
+	inZoneTraffic, err := r.inZoneTraffic.GetMetricWithLabelValues(r.algorithm, rowData.name)
+	if err != nil {
+		return err
+	}
+	inZoneTraffic.Set(rowData.result.InZoneTraffic)
+	r.meanDeviation.WithLabelValues(r.algorithm, rowData.name).Set(rowData.result.MeanDeviation)
+	r.maxDeviation.WithLabelValues(r.algorithm, rowData.name).Set(rowData.result.MaxDeviation)
+	r.score.WithLabelValues(r.algorithm, rowData.name).Set(evaluate(rowData).Total)
+	return nil
+}
+
+// Push pushes all recorded metrics to the push gateway at url under job
+func (r *PrometheusReporter) Push(url string, job string) error {
+	if url == "" {
+		return errors.New("push gateway url should not be empty")
+	}
+	if job == "" {
+		job = defaultMetricsJob
+	}
+	return push.New(url, job).Gatherer(r.registry).Push()
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestPrometheusReporter(t *testing.T) {
+	reporter, err := NewPrometheusReporter("Local")
+	if err != nil {
+		t.Fatalf("unexpected error creating reporter: %v", err)
+	}
+	rowData := outputData{
+		name:           "row1",
+		endpoints:      100,
+		endpointSlices: 1,
+		result:         types.SimulationResult{InZoneTraffic: 0.5, MeanDeviation: 0.1, MaxDeviation: 0.2},
+	}
+	if err := reporter.Report(rowData); err != nil {
+		t.Fatalf("unexpected error reporting: %v", err)
+	}
+	if err := reporter.Report(outputData{name: "invalid", result: types.SimulationResult{Invalid: true}}); err != nil {
+		t.Fatalf("unexpected error reporting an invalid result: %v", err)
+	}
+
+	expected := map[string]float64{
+		"in zone traffic": 0.5,
+		"mean deviation":  0.1,
+		"max deviation":   0.2,
+		"score":           evaluate(rowData).Total,
+	}
+	got := map[string]float64{
+		"in zone traffic": testutil.ToFloat64(reporter.inZoneTraffic.WithLabelValues("Local", "row1")),
+		"mean deviation":  testutil.ToFloat64(reporter.meanDeviation.WithLabelValues("Local", "row1")),
+		"max deviation":   testutil.ToFloat64(reporter.maxDeviation.WithLabelValues("Local", "row1")),
+		"score":           testutil.ToFloat64(reporter.score.WithLabelValues("Local", "row1")),
+	}
+	for metric, value := range expected {
+		if got[metric] != value {
+			t.Errorf("expected %s %v, got %v", metric, value, got[metric])
+		}
+	}
+	// invalid results are not recorded
+	if count := testutil.CollectAndCount(reporter.inZoneTraffic); count != 1 {
+		t.Errorf("expected 1 in zone traffic metric, got %d", count)
+	}
+}
+
+func TestPushMetrics(t *testing.T) {
+	var mutex sync.Mutex
+	var paths, bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mutex.Lock()
+		defer mutex.Unlock()
+		paths = append(paths, r.URL.Path)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,10 10,10 10,10 10\nunbalanced,1 5,2 20,7 20\n")
+	config := Config{
+		InputFile:      input,
+		OutputFile:     filepath.Join(t.TempDir(), "output.csv"),
+		Algorithm:      "LocalShared",
+		MetricsPushURL: server.URL,
+		MetricsJob:     "test-job",
+	}
+	if err := StartProcessingWithConfig(config); err != nil {
+		t.Fatalf("unexpected error processing: %v", err)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if len(paths) != 1 || paths[0] != "/metrics/job/test-job" {
+		t.Fatalf("expected one push to the test-job, got %v", paths)
+	}
+	// metrics are pushed in the protobuf format, label values are plain text
+	for _, expected := range []string{"k8s_topology_sim_score", "balanced", "unbalanced", "LocalShared"} {
+		if !strings.Contains(bodies[0], expected) {
+			t.Errorf("expected pushed metrics to contain %s", expected)
+		}
+	}
+	if len(readOutput(t, config.OutputFile)) != 4 {
+		t.Errorf("expected output to be written while pushing metrics")
+	}
+}
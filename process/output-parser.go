@@ -18,16 +18,24 @@ package process
 
 import (
 	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
 	"math"
 	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 
-	"k8s.io/klog/v2"
+	"github.com/googleinterns/k8s-topology-simulator/modeling/algorithm"
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
 )
 
-// parseResult parses outputData to evaluation metrics and writes back to a
-// result file
-func parseResult(file string, outputQueue <-chan outputData) (err error) {
+// parseResult parses outputData to evaluation metrics and writes back to the
+// output file of config, zone traffic matrices are written to the matrix file
+// of config if it's set
+func parseResult(config Config, outputQueue <-chan outputData) (err error) {
+	file := config.OutputFile
 	outputFile, err := os.Create(file)
 	if err != nil {
 		return err
@@ -35,48 +43,468 @@ func parseResult(file string, outputQueue <-chan outputData) (err error) {
 	defer func() {
 		cerr := outputFile.Close()
 		if cerr != nil {
-			klog.Errorf("close output file %s with an error %v", file, cerr)
+			logger.Error("failed to close output file", "file", file, "error", cerr)
 		}
 		if err == nil {
 			err = cerr
 		}
 	}()
 
-	klog.Infof("Writing output to file %v\n", file)
+	logger.Info("writing output", "file", file)
 	writer := csv.NewWriter(outputFile)
 
-	title := []string{"input name", "score", "in-zone-traffic score", "deviation score", "slice score", "max deviation", "mean deviation", "SD of deviation"}
+	title := resultTitle(config)
+	// explain the filter in the header when only the worst or best rows are
+	// written
+	if config.TopN > 0 {
+		title[0] = fmt.Sprintf("input name (%d worst rows by score)", config.TopN)
+		outputQueue = filterTopN(outputQueue, config.TopN, false)
+	} else if config.TopNBest > 0 {
+		title[0] = fmt.Sprintf("input name (%d best rows by score)", config.TopNBest)
+		outputQueue = filterTopN(outputQueue, config.TopNBest, true)
+	}
 	err = writer.Write(title)
 	if err != nil {
 		return err
 	}
 
+	var matrixWriter *csv.Writer
+	if config.MatrixFile != "" {
+		var matrixFile *os.File
+		matrixFile, err = os.Create(config.MatrixFile)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			cerr := matrixFile.Close()
+			if cerr != nil {
+				logger.Error("failed to close matrix file", "file", config.MatrixFile, "error", cerr)
+			}
+			if err == nil {
+				err = cerr
+			}
+		}()
+		logger.Info("writing zone traffic matrices", "file", config.MatrixFile)
+		matrixWriter = csv.NewWriter(matrixFile)
+	}
+
+	// rows are written as they arrive, only the metrics of valid rows are kept
+	// for the summary row
+	var rowSummary summary
 	for rowData, more := <-outputQueue; more; rowData, more = <-outputQueue {
-		// use in zone traffic percentage to be in zone traffic score
-		inZoneTrafficScore := rowData.result.InZoneTraffic * 100
-		// use mean deviation to calcualte deviation score
-		deviationMaxScore := 100.0 - rowData.result.MaxDeviation*100
-		deviationMeanScore := 100.0 - rowData.result.MeanDeviation*100
-		deviationScore := 0.5*deviationMaxScore + 0.5*deviationMeanScore
-		// use number of EndpointSlices deviation to calculate sliceScore
-		numberOfOriginalSlices := math.Ceil(float64(rowData.endpoints) / endpointsPerSlice)
-		sliceScore := (numberOfOriginalSlices / float64(rowData.endpointSlices)) * 100
-		// calculate total score based on two scores above
-		totalScore := inZoneTrafficScoreWeight*inZoneTrafficScore + deviationScoreWeight*deviationScore + sliceScoreWeight*sliceScore
+		if matrixWriter != nil {
+			err = writeMatrix(matrixWriter, rowData)
+			if err != nil {
+				return err
+			}
+		}
 
-		data := []string{rowData.name}
-		if rowData.result.Invalid {
-			data = append(data, []string{"invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid"}...)
-		} else {
-			data = append(data, strconv.FormatFloat(totalScore, 'f', 4, 64))
-			data = append(data, strconv.FormatFloat(inZoneTrafficScore, 'f', 4, 64))
-			data = append(data, strconv.FormatFloat(deviationScore, 'f', 4, 64))
-			data = append(data, strconv.FormatFloat(sliceScore, 'f', 4, 64))
-			data = append(data, strconv.FormatFloat(rowData.result.MaxDeviation*100, 'f', 4, 64)+"%")
-			data = append(data, strconv.FormatFloat(rowData.result.MeanDeviation*100, 'f', 4, 64)+"%")
-			data = append(data, strconv.FormatFloat(rowData.result.DeviationSD, 'f', 4, 64))
+		if !rowData.result.Invalid {
+			rowSummary.add(rowData, evaluate(rowData))
+		}
+
+		err = writer.Write(resultRow(config, rowData))
+		if err != nil {
+			return err
+		}
+		writer.Flush()
+		err = writer.Error()
+		if err != nil {
+			return err
+		}
+	}
+	if !config.NoSummary {
+		err = writer.Write(withAlgorithm(config, rowSummary.row(), config.Algorithm))
+		if err != nil {
+			return err
+		}
+	}
+	if matrixWriter != nil {
+		matrixWriter.Flush()
+		err = matrixWriter.Error()
+		if err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	err = writer.Error()
+	return err
+}
+
+// filterTopN reads all outputData from outputQueue, and returns a queue of the n
+// rows with the lowest scores, or the highest scores if best is true. Invalid
+// rows are considered worse than any valid row.
+func filterTopN(outputQueue <-chan outputData, n int, best bool) <-chan outputData {
+	var rows []outputData
+	for rowData := range outputQueue {
+		rows = append(rows, rowData)
+	}
+	// sort by score ascending, invalid rows first
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].result.Invalid || rows[j].result.Invalid {
+			return rows[i].result.Invalid && !rows[j].result.Invalid
+		}
+		return evaluate(rows[i]).Total < evaluate(rows[j]).Total
+	})
+	if best {
+		for i, j := 0, len(rows)-1; i < j; i, j = i+1, j-1 {
+			rows[i], rows[j] = rows[j], rows[i]
+		}
+	}
+	if len(rows) > n {
+		rows = rows[:n]
+	}
+	filteredQueue := make(chan outputData, len(rows))
+	for _, rowData := range rows {
+		filteredQueue <- rowData
+	}
+	close(filteredQueue)
+	return filteredQueue
+}
+
+// summary accumulates the metrics of valid outputData for the aggregate summary
+// row
+type summary struct {
+	// totalScores is the sum of scores of all rows
+	totalScores scores
+	// maxDeviations, meanDeviations, deviationSDs and minInZoneTraffics of all
+	// rows used to calculate percentiles
+	maxDeviations     []float64
+	meanDeviations    []float64
+	deviationSDs      []float64
+	minInZoneTraffics []float64
+	// elapsedMs of all rows used to calculate the P95 elapsed time
+	elapsedMs []float64
+}
+
+// add the metrics of one valid outputData to the summary
+func (s *summary) add(rowData outputData, rowScores scores) {
+	s.totalScores.Total += rowScores.Total
+	s.totalScores.InZoneTraffic += rowScores.InZoneTraffic
+	s.totalScores.Deviation += rowScores.Deviation
+	s.totalScores.Slice += rowScores.Slice
+	s.maxDeviations = append(s.maxDeviations, rowData.result.MaxDeviation)
+	s.meanDeviations = append(s.meanDeviations, rowData.result.MeanDeviation)
+	s.deviationSDs = append(s.deviationSDs, rowData.result.DeviationSD)
+	s.minInZoneTraffics = append(s.minInZoneTraffics, minInZoneTraffic(rowData.result))
+	s.elapsedMs = append(s.elapsedMs, float64(rowData.ElapsedMs))
+}
+
+// row generates the aggregate summary row, with mean scores, P95 deviations,
+// P5 min in-zone traffic and P95 elapsed time
+func (s *summary) row() []string {
+	data := []string{"AGGREGATE"}
+	if len(s.maxDeviations) == 0 {
+		return append(data, []string{"invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid"}...)
+	}
+	rows := float64(len(s.maxDeviations))
+	data = append(data, strconv.FormatFloat(s.totalScores.Total/rows, 'f', 4, 64))
+	data = append(data, strconv.FormatFloat(s.totalScores.InZoneTraffic/rows, 'f', 4, 64))
+	data = append(data, strconv.FormatFloat(s.totalScores.Deviation/rows, 'f', 4, 64))
+	data = append(data, strconv.FormatFloat(s.totalScores.Slice/rows, 'f', 4, 64))
+	data = append(data, strconv.FormatFloat(percentile(s.maxDeviations, 95)*100, 'f', 4, 64)+"%")
+	data = append(data, strconv.FormatFloat(percentile(s.meanDeviations, 95)*100, 'f', 4, 64)+"%")
+	data = append(data, strconv.FormatFloat(percentile(s.deviationSDs, 95), 'f', 4, 64))
+	data = append(data, strconv.FormatFloat(percentile(s.minInZoneTraffics, 5)*100, 'f', 4, 64)+"%")
+	data = append(data, strconv.FormatInt(int64(math.Round(percentile(s.elapsedMs, 95))), 10))
+	return data
+}
+
+// minInZoneTraffic returns the lowest in-zone traffic ratio of all zones in
+// result, or 0 if it has no per-zone in-zone traffic
+func minInZoneTraffic(result types.SimulationResult) float64 {
+	minTraffic, found := 0.0, false
+	for _, traffic := range result.InZoneTrafficByZone {
+		if !found || traffic < minTraffic {
+			minTraffic, found = traffic, true
+		}
+	}
+	return minTraffic
+}
+
+// percentile returns the p-th percentile of non-empty values with the
+// nearest-rank method
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// scores are the evaluation metrics of one outputData
+type scores struct {
+	// Total score weighted from scores below
+	Total float64 `json:"total"`
+	// InZoneTraffic score based on the in-zone traffic percentage
+	InZoneTraffic float64 `json:"inZoneTraffic"`
+	// Deviation score based on the max and mean traffic load deviation
+	Deviation float64 `json:"deviation"`
+	// Slice score based on the number of EndpointSlices compared with the
+	// original algorithm
+	Slice float64 `json:"slice"`
+}
+
+// evaluate calculates the scores of one valid outputData, its total score is
+// weighted by the scoreWeights of rowData
+func evaluate(rowData outputData) scores {
+	weights := rowData.scoreWeights
+	if weights == (ScoreWeights{}) {
+		weights = DefaultScoreWeights()
+	}
+	return scores{
+		Total:         algorithm.CalculateScore(rowData.result, rowData.endpoints, rowData.endpointSlices, rowData.sliceCapacity, weights),
+		InZoneTraffic: algorithm.InZoneTrafficScore(rowData.result),
+		Deviation:     algorithm.DeviationScore(rowData.result),
+		Slice:         algorithm.SliceScoreWithCapacity(rowData.endpoints, rowData.endpointSlices, rowData.sliceCapacity),
+	}
+}
+
+// jsonOutput is the JSON representation of one outputData
+type jsonOutput struct {
+	// Name is the same id as input id
+	Name string `json:"name"`
+	// Endpoints associated with the input data
+	Endpoints int `json:"endpoints"`
+	// EndpointSlices associated with the input data
+	EndpointSlices int `json:"endpointSlices"`
+	// Scores of the result, omitted if the result is invalid
+	Scores *scores `json:"scores,omitempty"`
+	// Result of the simulation
+	Result types.SimulationResult `json:"result"`
+}
+
+// parseResultJSON writes all outputData with their evaluation metrics and full
+// simulation results to a JSON array
+func parseResultJSON(file string, outputArray []outputData) (err error) {
+	outputs := make([]jsonOutput, 0, len(outputArray))
+	for _, rowData := range outputArray {
+		output := jsonOutput{
+			Name:           rowData.name,
+			Endpoints:      rowData.endpoints,
+			EndpointSlices: rowData.endpointSlices,
+			Result:         finiteResult(rowData.result),
+		}
+		if !rowData.result.Invalid {
+			rowScores := evaluate(rowData)
+			output.Scores = &rowScores
+		}
+		outputs = append(outputs, output)
+	}
+	content, err := json.MarshalIndent(outputs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	outputFile, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cerr := outputFile.Close()
+		if cerr != nil {
+			logger.Error("failed to close output file", "file", file, "error", cerr)
+		}
+		if err == nil {
+			err = cerr
+		}
+	}()
+	logger.Info("writing output", "file", file)
+	_, err = outputFile.Write(content)
+	return err
+}
+
+// finiteResult returns a copy of result which can be marshaled to JSON. Traffic
+// load and mean deviation of zones without endpoints are not defined (NaN), and
+// are replaced by 0 since JSON doesn't support non-finite numbers.
+func finiteResult(result types.SimulationResult) types.SimulationResult {
+	if result.TrafficDistribution == nil {
+		return result
+	}
+	distribution := make(map[string]types.ZoneTraffic, len(result.TrafficDistribution))
+	for zone, traffic := range result.TrafficDistribution {
+		traffic.TrafficLoad = finite(traffic.TrafficLoad)
+		traffic.ZoneTrafficDetail.MeanDeviation = finite(traffic.ZoneTrafficDetail.MeanDeviation)
+		distribution[zone] = traffic
+	}
+	result.TrafficDistribution = distribution
+	return result
+}
+
+// finite replaces NaN and infinite values with 0
+func finite(value float64) float64 {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0
+	}
+	return value
+}
+
+// resultTitle returns the header of the csv output of config
+func resultTitle(config Config) []string {
+	return withAlgorithm(config, resultColumns(), "algorithm")
+}
+
+// resultRow converts rowData to a row of the csv output of config, all metrics
+// of an invalid result are "invalid"
+func resultRow(config Config, rowData outputData) []string {
+	return withAlgorithm(config, resultMetrics(rowData), rowData.result.AlgorithmName)
+}
+
+// withAlgorithm inserts algName after the input name of row, unless the
+// algorithm column is disabled in config
+func withAlgorithm(config Config, row []string, algName string) []string {
+	if config.NoAlgorithmColumn {
+		return row
+	}
+	return append([]string{row[0], algName}, row[1:]...)
+}
+
+// resultColumns returns the header of the csv output without the algorithm
+// column
+func resultColumns() []string {
+	return []string{"input name", "score", "in-zone-traffic score", "deviation score", "slice score", "max deviation", "mean deviation", "SD of deviation", "min in-zone traffic", "elapsed ms"}
+}
+
+// resultMetrics converts rowData to a row of the csv output without the
+// algorithm column
+func resultMetrics(rowData outputData) []string {
+	data := []string{rowData.name}
+	elapsed := strconv.FormatInt(rowData.ElapsedMs, 10)
+	if rowData.result.Invalid {
+		return append(data, []string{"invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", "invalid", elapsed}...)
+	}
+	rowScores := evaluate(rowData)
+	data = append(data, strconv.FormatFloat(rowScores.Total, 'f', 4, 64))
+	data = append(data, strconv.FormatFloat(rowScores.InZoneTraffic, 'f', 4, 64))
+	data = append(data, strconv.FormatFloat(rowScores.Deviation, 'f', 4, 64))
+	data = append(data, strconv.FormatFloat(rowScores.Slice, 'f', 4, 64))
+	data = append(data, strconv.FormatFloat(rowData.result.MaxDeviation*100, 'f', 4, 64)+"%")
+	data = append(data, strconv.FormatFloat(rowData.result.MeanDeviation*100, 'f', 4, 64)+"%")
+	data = append(data, strconv.FormatFloat(rowData.result.DeviationSD, 'f', 4, 64))
+	data = append(data, strconv.FormatFloat(minInZoneTraffic(rowData.result)*100, 'f', 4, 64)+"%")
+	return append(data, elapsed)
+}
+
+// writeMatrix writes the zone-to-zone traffic matrix of one outputData as a
+// section of the matrix file. The section begins with a header of zone names,
+// followed by one row per source zone with the ratio of traffic sent to every
+// zone.
+func writeMatrix(writer *csv.Writer, rowData outputData) error {
+	if rowData.result.Invalid {
+		return writer.Write([]string{rowData.name, "invalid"})
+	}
+	var zoneNames []string
+	for zone := range rowData.result.ZoneTrafficMatrix {
+		zoneNames = append(zoneNames, zone)
+	}
+	sort.Strings(zoneNames)
+
+	header := append([]string{rowData.name, "from/to"}, zoneNames...)
+	err := writer.Write(header)
+	if err != nil {
+		return err
+	}
+	for _, source := range zoneNames {
+		data := []string{rowData.name, source}
+		for _, destination := range zoneNames {
+			data = append(data, strconv.FormatFloat(rowData.result.ZoneTrafficMatrix[source][destination], 'f', 4, 64))
+		}
+		err = writer.Write(data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseComparison writes ranked algorithm results of every input row to a
+// result file
+func parseComparison(file string, comparisonQueue <-chan comparisonData) (err error) {
+	outputFile, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cerr := outputFile.Close()
+		if cerr != nil {
+			logger.Error("failed to close output file", "file", file, "error", cerr)
+		}
+		if err == nil {
+			err = cerr
+		}
+	}()
+
+	logger.Info("writing comparison", "file", file)
+	writer := csv.NewWriter(outputFile)
+
+	title := []string{"input name", "rank", "algorithm", "score", "in-zone traffic", "max deviation", "mean deviation"}
+	err = writer.Write(title)
+	if err != nil {
+		return err
+	}
+
+	for rowData, more := <-comparisonQueue; more; rowData, more = <-comparisonQueue {
+		for rank, result := range rowData.results {
+			data := []string{rowData.name, strconv.Itoa(rank + 1), result.AlgorithmName}
+			if result.SimulationResult.Invalid {
+				data = append(data, []string{"invalid", "invalid", "invalid", "invalid"}...)
+			} else {
+				data = append(data, strconv.FormatFloat(result.Score, 'f', 4, 64))
+				data = append(data, strconv.FormatFloat(result.SimulationResult.InZoneTraffic*100, 'f', 4, 64)+"%")
+				data = append(data, strconv.FormatFloat(result.SimulationResult.MaxDeviation*100, 'f', 4, 64)+"%")
+				data = append(data, strconv.FormatFloat(result.SimulationResult.MeanDeviation*100, 'f', 4, 64)+"%")
+			}
+			err = writer.Write(data)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	err = writer.Error()
+	return err
+}
+
+// parseMultiAlgorithmResult writes the score of every algorithm of algNames on
+// every input row to a result file, one score_<algName> column per algorithm
+func parseMultiAlgorithmResult(file string, algNames []string, multiQueue <-chan multiAlgorithmData) (err error) {
+	outputFile, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cerr := outputFile.Close()
+		if cerr != nil {
+			logger.Error("failed to close output file", "file", file, "error", cerr)
+		}
+		if err == nil {
+			err = cerr
 		}
+	}()
+
+	logger.Info("writing output", "file", file)
+	writer := csv.NewWriter(outputFile)
+
+	title := []string{"input name"}
+	for _, name := range algNames {
+		title = append(title, "score_"+name)
+	}
+	err = writer.Write(title)
+	if err != nil {
+		return err
+	}
 
+	for rowData, more := <-multiQueue; more; rowData, more = <-multiQueue {
+		data := []string{rowData.name}
+		for _, result := range rowData.results {
+			if result.result.Invalid {
+				data = append(data, "invalid")
+			} else {
+				data = append(data, strconv.FormatFloat(evaluate(result).Total, 'f', 4, 64))
+			}
+		}
 		err = writer.Write(data)
 		if err != nil {
 			return err
@@ -86,3 +514,139 @@ func parseResult(file string, outputQueue <-chan outputData) (err error) {
 	err = writer.Error()
 	return err
 }
+
+// OutputDiff compares the scores of one input row in two output files
+type OutputDiff struct {
+	// RowName is the input name of the row
+	RowName string
+	// ScoreA is the score in the first file, NaN if the row is missing or
+	// invalid
+	ScoreA float64
+	// ScoreB is the score in the second file, NaN if the row is missing or
+	// invalid
+	ScoreB float64
+	// Delta is ScoreB - ScoreA
+	Delta float64
+	// Winner is "A" or "B" for the file with the higher score, or "tie". It's
+	// empty if either score is NaN.
+	Winner string
+}
+
+// DiffOutputs compares the scores of rows with the same input name in two csv
+// output files. Rows of fileA come first in their order, followed by rows only
+// in fileB.
+func DiffOutputs(fileA, fileB string) ([]OutputDiff, error) {
+	namesA, scoresA, err := readScores(fileA)
+	if err != nil {
+		return nil, err
+	}
+	namesB, scoresB, err := readScores(fileB)
+	if err != nil {
+		return nil, err
+	}
+	var diffs []OutputDiff
+	for _, name := range namesA {
+		scoreB, ok := scoresB[name]
+		if !ok {
+			scoreB = math.NaN()
+		}
+		diffs = append(diffs, newOutputDiff(name, scoresA[name], scoreB))
+	}
+	for _, name := range namesB {
+		if _, ok := scoresA[name]; !ok {
+			diffs = append(diffs, newOutputDiff(name, math.NaN(), scoresB[name]))
+		}
+	}
+	return diffs, nil
+}
+
+// newOutputDiff compares two scores of a row
+func newOutputDiff(name string, scoreA, scoreB float64) OutputDiff {
+	diff := OutputDiff{RowName: name, ScoreA: scoreA, ScoreB: scoreB, Delta: scoreB - scoreA}
+	switch {
+	case math.IsNaN(diff.Delta):
+	case diff.Delta > 0:
+		diff.Winner = "B"
+	case diff.Delta < 0:
+		diff.Winner = "A"
+	default:
+		diff.Winner = "tie"
+	}
+	return diff
+}
+
+// readScores reads input names in order and their scores from a csv output
+// file. Invalid scores are NaN, the aggregate summary row is skipped.
+func readScores(file string) ([]string, map[string]float64, error) {
+	outputFile, err := os.Open(filepath.Clean(file))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		cerr := outputFile.Close()
+		if cerr != nil {
+			logger.Error("failed to close output file", "file", file, "error", cerr)
+		}
+	}()
+	records, err := csv.NewReader(outputFile).ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("output file %s has no header", file)
+	}
+	// the first column is the input name, its header may explain a filter
+	scoreColumn := -1
+	for index, title := range records[0] {
+		if title == "score" {
+			scoreColumn = index
+		}
+	}
+	if scoreColumn < 1 {
+		return nil, nil, fmt.Errorf("output file %s has no score column", file)
+	}
+	var names []string
+	scores := map[string]float64{}
+	for _, record := range records[1:] {
+		name := record[0]
+		if name == "AGGREGATE" {
+			continue
+		}
+		if _, ok := scores[name]; ok {
+			return nil, nil, fmt.Errorf("duplicated input name %s in output file %s", name, file)
+		}
+		score := math.NaN()
+		if record[scoreColumn] != "invalid" {
+			score, err = strconv.ParseFloat(record[scoreColumn], 64)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid score of %s in output file %s: %v", name, file, err)
+			}
+		}
+		names = append(names, name)
+		scores[name] = score
+	}
+	return names, scores, nil
+}
+
+// WriteDiff writes diffs as csv to writer
+func WriteDiff(writer io.Writer, diffs []OutputDiff) error {
+	csvWriter := csv.NewWriter(writer)
+	err := csvWriter.Write([]string{"input name", "score A", "score B", "delta", "winner"})
+	if err != nil {
+		return err
+	}
+	for _, diff := range diffs {
+		err = csvWriter.Write([]string{
+			diff.RowName,
+			strconv.FormatFloat(diff.ScoreA, 'f', 4, 64),
+			strconv.FormatFloat(diff.ScoreB, 'f', 4, 64),
+			strconv.FormatFloat(diff.Delta, 'f', 4, 64),
+			diff.Winner,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
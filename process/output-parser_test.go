@@ -0,0 +1,534 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/algorithm"
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// writeInput writes csv input content to a file in a temporary directory and
+// returns the path of the file
+func writeInput(t *testing.T, content string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "input.csv")
+	if err := os.WriteFile(file, []byte(content), 0600); err != nil {
+		t.Fatalf("unexpected error writing input file: %v", err)
+	}
+	return file
+}
+
+// readOutput reads all records of a csv output file
+func readOutput(t *testing.T, file string) [][]string {
+	t.Helper()
+	outputFile, err := os.Open(filepath.Clean(file))
+	if err != nil {
+		t.Fatalf("unexpected error opening output file: %v", err)
+	}
+	defer func() {
+		if err := outputFile.Close(); err != nil {
+			t.Errorf("unexpected error closing output file: %v", err)
+		}
+	}()
+	reader := csv.NewReader(outputFile)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("unexpected error reading output file: %v", err)
+	}
+	return records
+}
+
+func TestMatrixOutput(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,10 10,10 10,10 10\nunbalanced,1 5,2 20,7 20\n")
+	dir := t.TempDir()
+	config := Config{
+		InputFile:  input,
+		OutputFile: filepath.Join(dir, "output.csv"),
+		Algorithm:  "LocalShared",
+		MatrixFile: filepath.Join(dir, "matrix.csv"),
+	}
+	if err := StartProcessingWithConfig(config); err != nil {
+		t.Fatalf("unexpected error processing: %v", err)
+	}
+
+	records := readOutput(t, config.MatrixFile)
+	// every input row has a section of one header and one row per zone
+	if len(records) != 2*4 {
+		t.Fatalf("expected 8 rows in the matrix file, got %d: %v", len(records), records)
+	}
+	for index, record := range records {
+		if len(record) != 5 {
+			t.Errorf("expected 5 columns in row %d, got %v", index, record)
+		}
+	}
+	expectedHeader := []string{"balanced", "from/to", "zoneA", "zoneB", "zoneC"}
+	for index, cell := range expectedHeader {
+		if records[0][index] != cell {
+			t.Errorf("expected header %v, got %v", expectedHeader, records[0])
+			break
+		}
+	}
+	if records[1][1] != "zoneA" || records[1][2] != "0.3333" || records[1][3] != "0.0000" {
+		t.Errorf("expected all traffic of zoneA to stay in zoneA for balanced input, got %v", records[1])
+	}
+	if records[4][0] != "unbalanced" || records[4][1] != "from/to" {
+		t.Errorf("expected the second section to begin at row 4, got %v", records[4])
+	}
+}
+
+func TestJSONOutput(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nunbalanced,1 5,2 20,7 20\nno endpoints,30 100,30 100,30 0\n")
+	dir := t.TempDir()
+	csvConfig := Config{InputFile: input, OutputFile: filepath.Join(dir, "output.csv"), Algorithm: "LocalShared", NoSummary: true}
+	if err := StartProcessingWithConfig(csvConfig); err != nil {
+		t.Fatalf("unexpected error processing csv output: %v", err)
+	}
+	jsonConfig := Config{InputFile: input, OutputFile: filepath.Join(dir, "output.json"), Algorithm: "LocalShared"}
+	if err := StartProcessingWithConfig(jsonConfig); err != nil {
+		t.Fatalf("unexpected error processing json output: %v", err)
+	}
+
+	content, err := os.ReadFile(jsonConfig.OutputFile)
+	if err != nil {
+		t.Fatalf("unexpected error reading json output: %v", err)
+	}
+	var outputs []jsonOutput
+	if err := json.Unmarshal(content, &outputs); err != nil {
+		t.Fatalf("unexpected error parsing json output: %v", err)
+	}
+	records := readOutput(t, csvConfig.OutputFile)
+	if len(outputs) != len(records)-1 {
+		t.Fatalf("expected %d json outputs, got %d", len(records)-1, len(outputs))
+	}
+	for index, output := range outputs {
+		record := records[index+1]
+		if output.Name != record[0] {
+			t.Errorf("expected output %d to be %s, got %s", index, record[0], output.Name)
+		}
+		if output.Scores == nil {
+			t.Errorf("expected scores of %s", output.Name)
+			continue
+		}
+		// scores follow the input name and the algorithm
+		for column, score := range []float64{output.Scores.Total, output.Scores.InZoneTraffic, output.Scores.Deviation, output.Scores.Slice} {
+			if formatted := strconv.FormatFloat(score, 'f', 4, 64); formatted != record[column+2] {
+				t.Errorf("expected %s in column %d of %s, got %s", record[column+2], column+2, output.Name, formatted)
+			}
+		}
+		if len(output.Result.TrafficDistribution) != 3 || len(output.Result.ZoneTrafficMatrix) != 3 {
+			t.Errorf("expected traffic distribution and matrix of 3 zones, got %+v", output.Result)
+		}
+	}
+	if _, err := json.Marshal(outputs); err != nil {
+		t.Errorf("unexpected error marshaling parsed outputs: %v", err)
+	}
+
+	badConfig := Config{InputFile: input, OutputFile: filepath.Join(dir, "output.txt"), OutputFormat: "xml"}
+	if err := StartProcessingWithConfig(badConfig); err == nil {
+		t.Errorf("expected an error with an unknown output format")
+	}
+}
+
+func TestSummaryOutput(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,10 10,10 10,10 10\nunbalanced,1 5,2 20,7 20\n")
+	testCases := []struct {
+		name         string
+		noSummary    bool
+		expectedRows int
+	}{
+		{name: "with summary", noSummary: false, expectedRows: 4},
+		{name: "without summary", noSummary: true, expectedRows: 3},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := Config{InputFile: input, OutputFile: filepath.Join(t.TempDir(), "output.csv"), Algorithm: "LocalShared", NoSummary: tc.noSummary}
+			if err := StartProcessingWithConfig(config); err != nil {
+				t.Fatalf("unexpected error processing: %v", err)
+			}
+			records := readOutput(t, config.OutputFile)
+			if len(records) != tc.expectedRows {
+				t.Fatalf("expected %d rows, got %d: %v", tc.expectedRows, len(records), records)
+			}
+			last := records[len(records)-1]
+			if tc.noSummary {
+				if last[0] == "AGGREGATE" {
+					t.Errorf("expected no summary row, got %v", last)
+				}
+				return
+			}
+			if last[0] != "AGGREGATE" || len(last) != len(records[0]) {
+				t.Errorf("expected a summary row at the end, got %v", last)
+			}
+		})
+	}
+}
+
+func TestMinInZoneTrafficOutput(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,10 10,10 10,10 10\nglobal,1 1,1 0,1 0\n")
+	config := Config{InputFile: input, OutputFile: filepath.Join(t.TempDir(), "output.csv"), Algorithm: "LocalShared"}
+	if err := StartProcessingWithConfig(config); err != nil {
+		t.Fatalf("unexpected error processing: %v", err)
+	}
+	records := readOutput(t, config.OutputFile)
+	column := len(records[0]) - 2
+	if records[0][column] != "min in-zone traffic" {
+		t.Fatalf("expected min in-zone traffic before the elapsed time column, got %v", records[0])
+	}
+	// every zone of the balanced row keeps all its traffic, zones without
+	// endpoints of the global row keep none
+	expected := map[string]string{"balanced": "100.0000%", "global": "0.0000%", "AGGREGATE": "0.0000%"}
+	for _, record := range records[1:] {
+		if record[column] != expected[record[0]] {
+			t.Errorf("expected min in-zone traffic %s of %s, got %s", expected[record[0]], record[0], record[column])
+		}
+	}
+}
+
+func TestElapsedOutput(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,10 10,10 10,10 10\ninvalid,1 0,1 0,1 0\n")
+	config := Config{InputFile: input, OutputFile: filepath.Join(t.TempDir(), "output.csv"), Algorithm: "LocalShared"}
+	if err := StartProcessingWithConfig(config); err != nil {
+		t.Fatalf("unexpected error processing: %v", err)
+	}
+	records := readOutput(t, config.OutputFile)
+	column := len(records[0]) - 1
+	if records[0][column] != "elapsed ms" {
+		t.Fatalf("expected elapsed ms as the last column, got %v", records[0])
+	}
+	for _, record := range records[1:] {
+		if len(record) != len(records[0]) {
+			t.Errorf("expected %d columns, got %v", len(records[0]), record)
+			continue
+		}
+		if elapsed, err := strconv.ParseInt(record[column], 10, 64); err != nil || elapsed < 0 {
+			t.Errorf("expected a non-negative integer elapsed time of %s, got %s", record[0], record[column])
+		}
+	}
+
+	model, err := newModel(Config{}, algorithm.NewAlgorithm("Local"))
+	if err != nil {
+		t.Fatalf("unexpected error creating model: %v", err)
+	}
+	rowData := inputData{name: "balanced", zones: []types.Zone{{Name: "zoneA", Nodes: 10, Endpoints: 10}, {Name: "zoneB", Nodes: 10, Endpoints: 10}}}
+	oData, err := runSimulation(model, "Local", rowData, 0, time.Nanosecond)
+	if err != nil {
+		t.Fatalf("unexpected error simulating: %v", err)
+	}
+	if oData.ElapsedMs < 0 {
+		t.Errorf("expected a non-negative elapsed time, got %v", oData.ElapsedMs)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	values := []float64{5, 1, 4, 2, 3, 10, 9, 8, 7, 6}
+	testCases := []struct {
+		p        float64
+		expected float64
+	}{
+		{p: 0, expected: 1},
+		{p: 50, expected: 5},
+		{p: 95, expected: 10},
+		{p: 100, expected: 10},
+	}
+	for _, tc := range testCases {
+		if got := percentile(values, tc.p); got != tc.expected {
+			t.Errorf("expected P%v to be %v, got %v", tc.p, tc.expected, got)
+		}
+	}
+	if values[0] != 5 {
+		t.Errorf("expected percentile not to sort values in place, got %v", values)
+	}
+}
+
+func TestTopNOutput(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\n"+
+		"balanced,10 10,10 10,10 10\n"+
+		"unbalanced,1 5,2 20,7 20\n"+
+		"one empty zone,30 100,30 100,30 0\n"+
+		"two empty zones,30 100,30 0,30 0\n"+
+		"skewed,1 1,1 1,10 30\n")
+	dir := t.TempDir()
+	// the summary row is suppressed to count data rows only
+	allConfig := Config{InputFile: input, OutputFile: filepath.Join(dir, "all.csv"), Algorithm: "LocalShared", NoSummary: true}
+	if err := StartProcessingWithConfig(allConfig); err != nil {
+		t.Fatalf("unexpected error processing: %v", err)
+	}
+	var allScores []float64
+	for _, record := range readOutput(t, allConfig.OutputFile)[1:] {
+		score, err := strconv.ParseFloat(record[2], 64)
+		if err != nil {
+			t.Fatalf("unexpected score in row %v: %v", record, err)
+		}
+		allScores = append(allScores, score)
+	}
+	sort.Float64s(allScores)
+
+	testCases := []struct {
+		name           string
+		topN           int
+		topNBest       int
+		expectedScores []float64
+	}{
+		{name: "worst rows", topN: 2, expectedScores: allScores[:2]},
+		{name: "best rows", topNBest: 2, expectedScores: []float64{allScores[4], allScores[3]}},
+		{name: "more rows than input", topN: 10, expectedScores: allScores},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := allConfig
+			config.OutputFile = filepath.Join(t.TempDir(), "output.csv")
+			config.TopN = tc.topN
+			config.TopNBest = tc.topNBest
+			if err := StartProcessingWithConfig(config); err != nil {
+				t.Fatalf("unexpected error processing: %v", err)
+			}
+			records := readOutput(t, config.OutputFile)
+			if len(records) != len(tc.expectedScores)+1 {
+				t.Fatalf("expected %d rows, got %d: %v", len(tc.expectedScores)+1, len(records), records)
+			}
+			if !strings.Contains(records[0][0], "rows by score") {
+				t.Errorf("expected the header to explain the filter, got %v", records[0])
+			}
+			for index, record := range records[1:] {
+				if expected := strconv.FormatFloat(tc.expectedScores[index], 'f', 4, 64); record[2] != expected {
+					t.Errorf("expected score %s in row %d, got %v", expected, index+1, record)
+				}
+			}
+		})
+	}
+
+	config := allConfig
+	config.TopN, config.TopNBest = 2, 2
+	if err := StartProcessingWithConfig(config); err == nil {
+		t.Errorf("expected an error with both top-n and top-n-best set")
+	}
+}
+
+func TestDiffOutputs(t *testing.T) {
+	header := "input name,score,in-zone-traffic score,deviation score,slice score,max deviation,mean deviation,SD of deviation\n"
+	fileA := writeInput(t, header+
+		"row1,70.0000,1,1,1,1%,1%,0\n"+
+		"row2,80.0000,1,1,1,1%,1%,0\n"+
+		"row3,60.0000,1,1,1,1%,1%,0\n"+
+		"onlyA,50.0000,1,1,1,1%,1%,0\n"+
+		"AGGREGATE,65.0000,1,1,1,1%,1%,0\n")
+	fileB := writeInput(t, header+
+		"row3,60.0000,1,1,1,1%,1%,0\n"+
+		"row2,75.5000,1,1,1,1%,1%,0\n"+
+		"row1,72.0000,1,1,1,1%,1%,0\n"+
+		"onlyB,40.0000,1,1,1,1%,1%,0\n")
+	diffs, err := DiffOutputs(fileA, fileB)
+	if err != nil {
+		t.Fatalf("unexpected error diffing outputs: %v", err)
+	}
+	expected := []OutputDiff{
+		{RowName: "row1", ScoreA: 70, ScoreB: 72, Delta: 2, Winner: "B"},
+		{RowName: "row2", ScoreA: 80, ScoreB: 75.5, Delta: -4.5, Winner: "A"},
+		{RowName: "row3", ScoreA: 60, ScoreB: 60, Delta: 0, Winner: "tie"},
+		{RowName: "onlyA", ScoreA: 50, ScoreB: math.NaN(), Delta: math.NaN()},
+		{RowName: "onlyB", ScoreA: math.NaN(), ScoreB: 40, Delta: math.NaN()},
+	}
+	if len(diffs) != len(expected) {
+		t.Fatalf("expected %d diffs, got %+v", len(expected), diffs)
+	}
+	equal := func(a, b float64) bool {
+		return a == b || (math.IsNaN(a) && math.IsNaN(b))
+	}
+	for index, diff := range diffs {
+		want := expected[index]
+		if diff.RowName != want.RowName || diff.Winner != want.Winner || !equal(diff.ScoreA, want.ScoreA) || !equal(diff.ScoreB, want.ScoreB) || !equal(diff.Delta, want.Delta) {
+			t.Errorf("expected diff %+v, got %+v", want, diff)
+		}
+	}
+
+	var builder strings.Builder
+	if err := WriteDiff(&builder, diffs); err != nil {
+		t.Fatalf("unexpected error writing diffs: %v", err)
+	}
+	if lines := strings.Split(strings.TrimSpace(builder.String()), "\n"); len(lines) != 6 || lines[1] != "row1,70.0000,72.0000,2.0000,B" {
+		t.Errorf("got unexpected diff table %q", builder.String())
+	}
+
+	if _, err := DiffOutputs(fileA, writeInput(t, "input name,rank\nrow1,1\n")); err == nil {
+		t.Errorf("expected an error diffing a file without score column")
+	}
+}
+
+func TestStreamingOutput(t *testing.T) {
+	const rows = 10000
+	outputQueue := make(chan outputData)
+	go func() {
+		defer close(outputQueue)
+		for i := 0; i < rows; i++ {
+			outputQueue <- outputData{
+				name:           "row" + strconv.Itoa(i),
+				endpoints:      100,
+				endpointSlices: 1,
+				result:         types.SimulationResult{InZoneTraffic: 0.5, MaxDeviation: 0.1, MeanDeviation: 0.05, Invalid: i%100 == 0},
+			}
+		}
+	}()
+	output := filepath.Join(t.TempDir(), "output.csv")
+	if err := parseResult(Config{OutputFile: output}, outputQueue); err != nil {
+		t.Fatalf("unexpected error writing output: %v", err)
+	}
+	records := readOutput(t, output)
+	// header, one line per row and the summary row
+	if len(records) != rows+2 {
+		t.Fatalf("expected %d lines, got %d", rows+2, len(records))
+	}
+	if records[rows][0] != "row9999" || records[rows+1][0] != "AGGREGATE" {
+		t.Errorf("expected the last row followed by the summary row, got %v and %v", records[rows], records[rows+1])
+	}
+}
+
+func TestMultiAlgorithmRun(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,10 10,10 10,10 10\nunbalanced,1 5,2 20,7 20\ninvalid,-1 5,2 20,7 20\n")
+	testCases := []struct {
+		name     string
+		algNames []string
+	}{
+		{name: "one algorithm", algNames: []string{"Local"}},
+		{name: "three algorithms", algNames: []string{"Local", "LocalShared", "Original"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "output.csv")
+			if err := MultiAlgorithmRun(input, output, tc.algNames); err != nil {
+				t.Fatalf("unexpected error processing: %v", err)
+			}
+			records := readOutput(t, output)
+			if len(records) != 4 {
+				t.Fatalf("expected 4 rows, got %d: %v", len(records), records)
+			}
+			for i, name := range tc.algNames {
+				if records[0][i+1] != "score_"+name {
+					t.Errorf("expected column %d to be score_%s, got %s", i+1, name, records[0][i+1])
+				}
+			}
+			for _, record := range records {
+				if len(record) != len(tc.algNames)+1 {
+					t.Errorf("expected %d columns, got %d: %v", len(tc.algNames)+1, len(record), record)
+				}
+			}
+			if records[3][1] != "invalid" {
+				t.Errorf("expected invalid score of invalid row, got %s", records[3][1])
+			}
+		})
+	}
+
+	if err := MultiAlgorithmRun(input, filepath.Join(t.TempDir(), "output.csv"), nil); err == nil {
+		t.Error("expected error running no algorithm")
+	}
+}
+
+func TestMultiAlgorithmRunConfig(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,10 10,10 10,10 10\nunbalanced,1 5,2 20,7 20\n")
+	config := Config{InputFile: input, OutputFile: filepath.Join(t.TempDir(), "output.csv"), Threshold: 0.2, EndpointsPerSlice: 10, FailedZone: "zoneC"}
+	if err := MultiAlgorithmRunWithConfig(config, []string{"Local", "Original"}); err != nil {
+		t.Fatalf("unexpected error processing: %v", err)
+	}
+	records := readOutput(t, config.OutputFile)
+	// every algorithm scores like a single algorithm run with the same config,
+	// Original has no deviation threshold and keeps its default
+	for i, algName := range []string{"Local", "Original"} {
+		single := config
+		single.Algorithm = algName
+		single.OutputFile = filepath.Join(t.TempDir(), "single.csv")
+		if algName == "Original" {
+			single.Threshold = 0
+		}
+		if err := StartProcessingWithConfig(single); err != nil {
+			t.Fatalf("unexpected error processing %s: %v", algName, err)
+		}
+		expected := readOutput(t, single.OutputFile)
+		for row := 1; row < 3; row++ {
+			if records[row][i+1] != expected[row][2] {
+				t.Errorf("expected %s score %s of %s, got %s", algName, expected[row][2], records[row][0], records[row][i+1])
+			}
+		}
+	}
+
+	config.EndpointsPerSlice = -1
+	if err := MultiAlgorithmRunWithConfig(config, []string{"Local"}); err == nil {
+		t.Error("expected error running with negative endpoints per slice")
+	}
+}
+
+func TestAlgorithmColumn(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,10 10,10 10,10 10\nunbalanced,1 5,2 20,7 20\n")
+	dir := t.TempDir()
+	testCases := []struct {
+		name              string
+		noAlgorithmColumn bool
+		expectedHeader    []string
+	}{
+		{name: "algorithm column", expectedHeader: []string{"input name", "algorithm", "score"}},
+		{name: "no algorithm column", noAlgorithmColumn: true, expectedHeader: []string{"input name", "score"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := Config{InputFile: input, OutputFile: filepath.Join(dir, "output.csv"), Algorithm: "Local", NoAlgorithmColumn: tc.noAlgorithmColumn}
+			if err := StartProcessingWithConfig(config); err != nil {
+				t.Fatalf("unexpected error processing: %v", err)
+			}
+			records := readOutput(t, config.OutputFile)
+			if len(records) != 4 {
+				t.Fatalf("expected 4 rows, got %d: %v", len(records), records)
+			}
+			if !reflect.DeepEqual(records[0][:len(tc.expectedHeader)], tc.expectedHeader) {
+				t.Errorf("expected header to begin with %v, got %v", tc.expectedHeader, records[0])
+			}
+			for _, record := range records[1:] {
+				if len(record) != len(records[0]) {
+					t.Errorf("expected %d columns, got %v", len(records[0]), record)
+				}
+				if !tc.noAlgorithmColumn && record[1] != "Local" {
+					t.Errorf("expected algorithm Local of %s, got %s", record[0], record[1])
+				}
+			}
+		})
+	}
+}
+
+func TestResultNames(t *testing.T) {
+	model, err := newModel(Config{}, algorithm.NewAlgorithm("Local"))
+	if err != nil {
+		t.Fatalf("unexpected error creating model: %v", err)
+	}
+	rowData := inputData{name: "balanced", zones: []types.Zone{{Name: "zoneA", Nodes: 10, Endpoints: 10}, {Name: "zoneB", Nodes: 10, Endpoints: 10}}}
+	oData, err := runSimulation(model, "Local", rowData, 0, 0)
+	if err != nil {
+		t.Fatalf("unexpected error simulating: %v", err)
+	}
+	if oData.result.AlgorithmName != "Local" || oData.result.RegionName != "balanced" {
+		t.Errorf("expected result of Local on balanced, got algorithm %q and region %q", oData.result.AlgorithmName, oData.result.RegionName)
+	}
+}
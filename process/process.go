@@ -17,36 +17,482 @@ limitations under the License.
 package process
 
 import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
 	"github.com/googleinterns/k8s-topology-simulator/modeling"
 	"github.com/googleinterns/k8s-topology-simulator/modeling/algorithm"
 	"github.com/googleinterns/k8s-topology-simulator/modeling/simulator"
 	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
-	"k8s.io/klog/v2"
 )
 
-const endpointsPerSlice = 100
-const inZoneTrafficScoreWeight, deviationScoreWeight, sliceScoreWeight = 0.45, 0.4, 0.15
+const csvFormat, jsonFormat, k8sYAMLFormat = "csv", "json", "k8s-yaml"
+
+// scoreWeightsSumTolerance is how far the sum of ScoreWeights may be from 1
+const scoreWeightsSumTolerance = 0.001
+
+// ScoreWeights are the weights of the component scores in the total score of
+// a row
+type ScoreWeights = algorithm.ScoreWeights
+
+// Config contains the settings of one processing run
+type Config struct {
+	// InputFile is the csv file zone inputs are read from
+	InputFile string
+	// OutputFile is the csv file evaluation results are written to
+	OutputFile string
+	// Algorithm is the name of the routing algorithm to evaluate
+	Algorithm string
+	// FailedZone, if not empty, simulates traffic with this zone offline
+	FailedZone string
+	// MatrixFile, if not empty, is the csv file zone-to-zone traffic matrices
+	// are written to
+	MatrixFile string
+	// OutputFormat of the output file, either "csv", "json" or "k8s-yaml" for
+	// the EndpointSlices of every row. If empty, the format is detected from
+	// the extension of OutputFile.
+	OutputFormat string
+	// NoSummary suppresses the aggregate summary row at the end of the csv
+	// output
+	NoSummary bool
+	// TopN, if positive, writes only the TopN rows with the lowest scores to
+	// the csv output
+	TopN int
+	// TopNBest, if positive, writes only the TopNBest rows with the highest
+	// scores to the csv output
+	TopNBest int
+	// MetricsPushURL, if not empty, is the Prometheus push gateway metrics of
+	// every row are pushed to
+	MetricsPushURL string
+	// MetricsJob is the push gateway job name of the metrics
+	MetricsJob string
+	// Deduplicate skips input rows with an input name that has been seen
+	Deduplicate bool
+	// DeduplicateKeepLast deduplicates input rows keeping the last row of an
+	// input name instead of the first
+	DeduplicateKeepLast bool
+	// StatsFile, if not empty, is the csv file aggregate statistics of all
+	// rows are written to
+	StatsFile string
+	// HTMLReportFile, if not empty, is the html file a report of all rows is
+	// written to
+	HTMLReportFile string
+	// Threshold, if positive, replaces the deviation threshold of Algorithm
+	Threshold float64
+	// AutoTuneThreshold replaces the deviation threshold of Algorithm with the
+	// one scoring best on all rows of InputFile
+	AutoTuneThreshold bool
+	// MaxDeviationThreshold, if positive, logs a warning for every row whose
+	// max deviation of traffic load exceeds it
+	MaxDeviationThreshold float64
+	// EndpointsPerSlice, if positive, is the number of max endpoints per
+	// EndpointSlice, 100 by default
+	EndpointsPerSlice int
+	// Strict fails processing on the first invalid input row instead of
+	// skipping invalid rows
+	Strict bool
+	// SlowThresholdMs, if positive, logs a warning for every row taking more
+	// milliseconds to simulate
+	SlowThresholdMs int64
+	// NoAlgorithmColumn leaves out the algorithm column after the input name
+	// of the csv output, for tools expecting the columns of earlier versions
+	NoAlgorithmColumn bool
+	// ScoreWeights of the total score, DefaultScoreWeights if not set
+	ScoreWeights ScoreWeights
+	// DumpSlicesFile, if not empty, is the file the EndpointSliceGroups of
+	// every row are written to, one JSON object per line
+	DumpSlicesFile string
+	// ReplaySlicesFile, if not empty, is a file written with DumpSlicesFile,
+	// the EndpointSliceGroups of a row are read from it and simulated instead
+	// of running Algorithm. Rows missing from it are skipped.
+	ReplaySlicesFile string
+	// ComparisonReportFile, if not empty, is the markdown file a table of the
+	// results of every algorithm on every row is written to by
+	// MultiAlgorithmRunWithConfig
+	ComparisonReportFile string
+}
+
+// DefaultScoreWeights returns the weights of the total score used if Config
+// doesn't set any
+func DefaultScoreWeights() ScoreWeights {
+	return algorithm.DefaultScoreWeights
+}
+
+// ValidateScoreWeights returns an error if any weight of w is negative or the
+// weights don't sum up to 1
+func ValidateScoreWeights(w ScoreWeights) error {
+	if w.InZoneTraffic < 0 || w.Deviation < 0 || w.SliceCount < 0 {
+		return fmt.Errorf("score weights %+v should not be negative", w)
+	}
+	if sum := w.InZoneTraffic + w.Deviation + w.SliceCount; math.Abs(sum-1) > scoreWeightsSumTolerance {
+		return fmt.Errorf("score weights %+v should sum up to 1, got %v", w, sum)
+	}
+	return nil
+}
+
+// scoreWeights returns the ScoreWeights of config, DefaultScoreWeights if they
+// are not set
+func scoreWeights(config Config) ScoreWeights {
+	if config.ScoreWeights == (ScoreWeights{}) {
+		return DefaultScoreWeights()
+	}
+	return config.ScoreWeights
+}
 
 // StartProcessing starts parsing input file, running simulation and
 // generating output file
 func StartProcessing(inputFile string, outputFile string, alg string) error {
+	return StartProcessingWithConfig(Config{InputFile: inputFile, OutputFile: outputFile, Algorithm: alg})
+}
+
+// StartProcessingWithConfig starts parsing input file, running simulation and
+// generating output file with the provided config
+func StartProcessingWithConfig(config Config) error {
+	format, err := outputFormat(config)
+	if err != nil {
+		return err
+	}
+	if !algorithm.IsKnownAlgorithm(config.Algorithm) {
+		return fmt.Errorf("unknown algorithm %q, should be one of %v", config.Algorithm, algorithm.ListAlgorithms())
+	}
+	if config.TopN > 0 && config.TopNBest > 0 {
+		return errors.New("top-n and top-n-best can't be set at the same time")
+	}
+	if config.TopN < 0 || config.TopNBest < 0 {
+		return fmt.Errorf("top-n %d and top-n-best %d should not be negative", config.TopN, config.TopNBest)
+	}
+	if config.EndpointsPerSlice < 0 {
+		return fmt.Errorf("endpoints per slice %d should not be negative", config.EndpointsPerSlice)
+	}
+	if err := ValidateScoreWeights(scoreWeights(config)); err != nil {
+		return err
+	}
+	if format == jsonFormat && config.MatrixFile != "" {
+		return errors.New("matrix file is not supported with json output, zone traffic matrices are included in the json output")
+	}
+	if format == k8sYAMLFormat && config.MatrixFile != "" {
+		return errors.New("matrix file is not supported with k8s-yaml output")
+	}
+	err = validateBeforeProcessing(config.InputFile, config.Strict)
+	if err != nil {
+		return err
+	}
 
 	// initialize a goroutine to read row data from input file and put the
 	// converted row data into a queue
-	inputQueue, err := parseInput(inputFile)
+	inputQueue, err := readInput(config)
 	if err != nil {
 		return err
 	}
 
 	// initialize a goroutine to process row data from inputQueue and put the
 	// processed data into another queue to handle results
-	outputQueue, err := startSimulation(alg, inputQueue)
+	outputQueue, err := startSimulation(config, inputQueue)
 	if err != nil {
 		return err
 	}
 
+	var reporter *PrometheusReporter
+	if config.MetricsPushURL != "" {
+		reporter, err = NewPrometheusReporter(config.Algorithm)
+		if err != nil {
+			return err
+		}
+		outputQueue = reportMetrics(reporter, outputQueue)
+	}
+	var rows []outputData
+	if needsRows(config) {
+		outputQueue = collectRows(outputQueue, &rows)
+	}
+
 	// parse results from outputQueue and write to output file
-	return parseResult(outputFile, outputQueue)
+	err = writeOutput(config, format, outputQueue)
+	if err != nil {
+		return err
+	}
+	err = writeReports(config, rows)
+	if err != nil {
+		return err
+	}
+	if reporter == nil {
+		return nil
+	}
+	return reporter.Push(config.MetricsPushURL, config.MetricsJob)
+}
+
+// needsRows returns true if config has any output over all rows
+func needsRows(config Config) bool {
+	return config.StatsFile != "" || config.HTMLReportFile != "" || config.DumpSlicesFile != ""
+}
+
+// writeReports writes the slice groups dump, the aggregate statistics and the
+// html report of all rows if they are set in config
+func writeReports(config Config, rows []outputData) error {
+	if config.DumpSlicesFile != "" {
+		err := writeSliceGroupsDump(config.DumpSlicesFile, rows)
+		if err != nil {
+			return err
+		}
+	}
+	if config.StatsFile == "" && config.HTMLReportFile == "" {
+		return nil
+	}
+	stats := ComputeAggregateStats(rows)
+	if config.StatsFile != "" {
+		err := writeAggregateStats(config.StatsFile, stats)
+		if err != nil {
+			return err
+		}
+	}
+	if config.HTMLReportFile != "" {
+		return GenerateHTMLReport(config.HTMLReportFile, rows, stats)
+	}
+	return nil
+}
+
+// readInput parses the input file and deduplicates the rows if configured
+func readInput(config Config) (<-chan inputData, error) {
+	inputQueue, err := parseInput(config.InputFile)
+	if err != nil {
+		return nil, err
+	}
+	if config.Deduplicate || config.DeduplicateKeepLast {
+		inputQueue = deduplicateInput(inputQueue, config.DeduplicateKeepLast)
+	}
+	return inputQueue, nil
+}
+
+// writeOutput writes results from outputQueue to the output file in format
+func writeOutput(config Config, format string, outputQueue <-chan outputData) error {
+	if format == jsonFormat {
+		var outputArray []outputData
+		for rowData := range outputQueue {
+			outputArray = append(outputArray, rowData)
+		}
+		return parseResultJSON(config.OutputFile, outputArray)
+	}
+	if format == k8sYAMLFormat {
+		var outputArray []outputData
+		for rowData := range outputQueue {
+			outputArray = append(outputArray, rowData)
+		}
+		return writeEndpointSliceYAML(config.OutputFile, outputArray)
+	}
+	return parseResult(config, outputQueue)
+}
+
+// reportMetrics records metrics of every outputData from outputQueue with
+// reporter, and forwards them to the returned queue
+func reportMetrics(reporter *PrometheusReporter, outputQueue <-chan outputData) <-chan outputData {
+	reportedQueue := make(chan outputData)
+	go func() {
+		defer close(reportedQueue)
+
+		for rowData := range outputQueue {
+			if err := reporter.Report(rowData); err != nil {
+				logger.Error("error reporting metrics", "input_name", rowData.name, "error", err)
+			}
+			reportedQueue <- rowData
+		}
+	}()
+	return reportedQueue
+}
+
+// outputFormat returns the output format of config, detecting it from the
+// extension of the output file if it's not set
+func outputFormat(config Config) (string, error) {
+	switch config.OutputFormat {
+	case csvFormat, jsonFormat, k8sYAMLFormat:
+		return config.OutputFormat, nil
+	case "":
+		if strings.EqualFold(filepath.Ext(config.OutputFile), ".json") {
+			return jsonFormat, nil
+		}
+		return csvFormat, nil
+	}
+	return "", fmt.Errorf("unknown output format %q, should be %q, %q or %q", config.OutputFormat, csvFormat, jsonFormat, k8sYAMLFormat)
+}
+
+// StartComparison starts parsing input file, running every algorithm listed by
+// algorithm.ListAlgorithms on each row and writing the ranked results to the
+// output file
+func StartComparison(config Config) error {
+	err := validateBeforeProcessing(config.InputFile, config.Strict)
+	if err != nil {
+		return err
+	}
+	inputQueue, err := readInput(config)
+	if err != nil {
+		return err
+	}
+	comparisonQueue, err := startComparison(config, inputQueue)
+	if err != nil {
+		return err
+	}
+	return parseComparison(config.OutputFile, comparisonQueue)
+}
+
+// MultiAlgorithmRun starts parsing input file, running every algorithm of
+// algNames on each row and writing one score column per algorithm to the
+// output file
+func MultiAlgorithmRun(inputFile, outputFile string, algNames []string) error {
+	return MultiAlgorithmRunWithConfig(Config{InputFile: inputFile, OutputFile: outputFile}, algNames)
+}
+
+// MultiAlgorithmRunWithConfig starts parsing input file, running every
+// algorithm of algNames on each row and writing one score column per algorithm
+// to the output file with the provided config. The aggregate statistics and the
+// html report of config cover the results of all algorithms.
+func MultiAlgorithmRunWithConfig(config Config, algNames []string) error {
+	if len(algNames) == 0 {
+		return errors.New("no algorithm to run")
+	}
+	if config.ReplaySlicesFile != "" {
+		return errors.New("replay slices is not supported with multiple algorithms")
+	}
+	for _, algName := range algNames {
+		if !algorithm.IsKnownAlgorithm(algName) {
+			return fmt.Errorf("unknown algorithm %q, should be one of %v", algName, algorithm.ListAlgorithms())
+		}
+	}
+	if config.EndpointsPerSlice < 0 {
+		return fmt.Errorf("endpoints per slice %d should not be negative", config.EndpointsPerSlice)
+	}
+	err := ValidateScoreWeights(scoreWeights(config))
+	if err != nil {
+		return err
+	}
+	err = validateBeforeProcessing(config.InputFile, config.Strict)
+	if err != nil {
+		return err
+	}
+	inputQueue, err := readInput(config)
+	if err != nil {
+		return err
+	}
+	multiQueue, err := startMultiAlgorithmRun(config, algNames, inputQueue)
+	if err != nil {
+		return err
+	}
+	var rows []outputData
+	if needsRows(config) || config.ComparisonReportFile != "" {
+		multiQueue = collectMultiAlgorithmRows(multiQueue, &rows)
+	}
+	err = parseMultiAlgorithmResult(config.OutputFile, algNames, multiQueue)
+	if err != nil {
+		return err
+	}
+	if config.ComparisonReportFile != "" {
+		err = writeComparisonReport(config.ComparisonReportFile, rows)
+		if err != nil {
+			return err
+		}
+	}
+	return writeReports(config, rows)
+}
+
+// BatchProcess processes every input file in order with the algorithm alg and
+// writes all rows to one csv output file. The first column of the output is
+// the input file of a row, since rows of different input files may share the
+// same input name.
+func BatchProcess(inputFiles []string, outputFile string, alg string) error {
+	return BatchProcessWithConfig(Config{OutputFile: outputFile, Algorithm: alg}, inputFiles)
+}
+
+// BatchProcessWithConfig processes every input file in order with the
+// provided config and writes all rows to the csv output file of config, the
+// input file of config is ignored. The merged output has no summary row.
+func BatchProcessWithConfig(config Config, inputFiles []string) (err error) {
+	if len(inputFiles) == 0 {
+		return errors.New("no input file to process")
+	}
+	if !algorithm.IsKnownAlgorithm(config.Algorithm) {
+		return fmt.Errorf("unknown algorithm %q, should be one of %v", config.Algorithm, algorithm.ListAlgorithms())
+	}
+	if config.EndpointsPerSlice < 0 {
+		return fmt.Errorf("endpoints per slice %d should not be negative", config.EndpointsPerSlice)
+	}
+	if err := ValidateScoreWeights(scoreWeights(config)); err != nil {
+		return err
+	}
+	file := config.OutputFile
+	outputFile, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cerr := outputFile.Close()
+		if cerr != nil {
+			logger.Error("failed to close output file", "file", file, "error", cerr)
+		}
+		if err == nil {
+			err = cerr
+		}
+	}()
+
+	logger.Info("writing output", "file", file)
+	writer := csv.NewWriter(outputFile)
+	err = writer.Write(append([]string{"source_file"}, resultTitle(config)...))
+	if err != nil {
+		return err
+	}
+	for index, inputFile := range inputFiles {
+		logger.Info("processing input file", "file", inputFile, "progress", fmt.Sprintf("%d/%d", index+1, len(inputFiles)))
+		config.InputFile = inputFile
+		err = validateBeforeProcessing(inputFile, config.Strict)
+		if err != nil {
+			return err
+		}
+		var inputQueue <-chan inputData
+		inputQueue, err = readInput(config)
+		if err != nil {
+			return err
+		}
+		var outputQueue <-chan outputData
+		outputQueue, err = startSimulation(config, inputQueue)
+		if err != nil {
+			return err
+		}
+		rows := 0
+		for rowData := range outputQueue {
+			err = writer.Write(append([]string{inputFile}, resultRow(config, rowData)...))
+			if err != nil {
+				return err
+			}
+			rows++
+		}
+		writer.Flush()
+		err = writer.Error()
+		if err != nil {
+			return err
+		}
+		logger.Info("input file processed", "file", inputFile, "rows", rows)
+	}
+	return nil
+}
+
+// WatchMode runs StartProcessing, and re-runs it whenever the input file is
+// written until it's interrupted by ctrl-C
+func WatchMode(inputFile, outputFile, algName string) error {
+	return WatchModeWithConfig(Config{InputFile: inputFile, OutputFile: outputFile, Algorithm: algName})
+}
+
+// WatchModeWithConfig runs StartProcessingWithConfig, and re-runs it whenever
+// the input file of config is written until it's interrupted by ctrl-C
+func WatchModeWithConfig(config Config) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	return watch(ctx, config.InputFile, watchPollInterval, watchDebounce, os.Stderr, func() error {
+		return StartProcessingWithConfig(config)
+	})
 }
 
 // every row of the input file will be parsed to one instance of inputData
@@ -65,21 +511,122 @@ type outputData struct {
 	endpoints int
 	// number of EndpointSlices associated with the input data
 	endpointSlices int
+	// number of max endpoints per EndpointSlice, 100 if it's not set
+	sliceCapacity int
+	// EndpointSliceGroups the result is simulated with
+	sliceGroups map[string]types.EndpointSliceGroup
 	// simulation result of that piece of input data
 	result types.SimulationResult
+	// name of the algorithm the result is simulated with
+	algorithm string
+	// fallback is true if the EndpointSliceGroups are the same as
+	// OriginalAlgorithm creates
+	fallback bool
+	// ElapsedMs is the time of updating the region and simulating in
+	// milliseconds
+	ElapsedMs int64
+	// weights of the total score, DefaultScoreWeights if not set
+	scoreWeights ScoreWeights
+}
+
+// every instance of inputData will be mapped to one instance of comparisonData
+// in comparison mode
+type comparisonData struct {
+	// same id as input id
+	name string
+	// results of all algorithms sorted by score
+	results []modeling.AlgorithmResult
+}
+
+// every instance of inputData will be mapped to one instance of
+// multiAlgorithmData in multi-algorithm mode
+type multiAlgorithmData struct {
+	// same id as input id
+	name string
+	// results of every algorithm in the order of the algorithm names
+	results []outputData
+}
+
+// newSimulator creates the traffic simulator described by config
+func newSimulator(config Config) simulator.TrafficSimulator {
+	// currently do calculation based on probability rather than real
+	// simulation.
+	var sim simulator.TrafficSimulator = simulator.TheoreticalSimulator{}
+	if config.FailedZone != "" {
+		sim = simulator.ZoneFailureSimulator{FailedZone: config.FailedZone, Inner: sim}
+	}
+	return sim
+}
+
+// newModel creates a model of alg with the simulator and the EndpointSlice
+// capacity of config
+func newModel(config Config, alg algorithm.RoutingAlgorithm) (*modeling.Model, error) {
+	opts := []modeling.ModelOption{modeling.WithAlgorithm(alg), modeling.WithSimulator(newSimulator(config))}
+	if config.EndpointsPerSlice > 0 {
+		opts = append(opts, modeling.WithSliceCapacity(config.EndpointsPerSlice))
+	}
+	return modeling.NewModelWithOptions(opts...)
+}
+
+// newAlgorithm creates the algorithm of config with its deviation threshold
+// replaced if it's set or tuned
+func newAlgorithm(config Config) (algorithm.RoutingAlgorithm, error) {
+	threshold := config.Threshold
+	if config.AutoTuneThreshold {
+		tuned, err := tuneThreshold(config)
+		if err != nil {
+			return nil, err
+		}
+		threshold = tuned
+	}
+	if threshold == 0 {
+		return algorithm.NewAlgorithm(config.Algorithm), nil
+	}
+	return algorithm.NewAlgorithmWithThreshold(config.Algorithm, threshold)
+}
+
+// tuneThreshold returns the deviation threshold of the algorithm of config
+// with the highest average score over all rows of the input file. Slice scores
+// are left out since they depend on the number of EndpointSlices.
+func tuneThreshold(config Config) (float64, error) {
+	inputQueue, err := readInput(config)
+	if err != nil {
+		return 0, err
+	}
+	var regions []types.RegionInfo
+	for rowData := range inputQueue {
+		region, rerr := types.CreateRegionInfo(rowData.zones)
+		if rerr != nil {
+			logger.Warn("skip row for threshold tuning", "input_name", rowData.name, "error", rerr)
+			continue
+		}
+		regions = append(regions, region)
+	}
+	return algorithm.AutoTuneThreshold(config.Algorithm, regions, func(result types.SimulationResult) float64 {
+		return algorithm.CalculateScore(result, 0, 0, 0, scoreWeights(config))
+	})
 }
 
 // startSimulation processes simulation on input data, produces instances of
 // outputData structure and puts them in a queue(channel)
-func startSimulation(algName string, inputQueue <-chan inputData) (<-chan outputData, error) {
+func startSimulation(config Config, inputQueue <-chan inputData) (<-chan outputData, error) {
 	// create algorithm based on the algorithm name
-	alg := algorithm.NewAlgorithm(algName)
-	// create simulation model, currently do calculation based on probability
-	// rather than real simulation.
-	model, err := modeling.NewModel(alg, simulator.TheoreticalSimulator{})
+	alg, err := newAlgorithm(config)
+	if err != nil {
+		return nil, err
+	}
+	// create simulation model
+	model, err := newModel(config, alg)
 	if err != nil {
 		return nil, err
 	}
+	var replays map[string]replayedSliceGroups
+	if config.ReplaySlicesFile != "" {
+		replays, err = readSliceGroupsDump(config.ReplaySlicesFile)
+		if err != nil {
+			return nil, err
+		}
+	}
 	outputQueue := make(chan outputData)
 	// Some simplifications here result in this code not being threadsafe.
 	// Do not use more than one goroutine to process this queue.
@@ -87,8 +634,24 @@ func startSimulation(algName string, inputQueue <-chan inputData) (<-chan output
 		defer close(outputQueue)
 
 		for rowData, more := <-inputQueue; more; rowData, more = <-inputQueue {
-			oData, rerr := runSimulation(model, rowData)
+			algName := config.Algorithm
+			if replays != nil {
+				replay, ok := replays[rowData.name]
+				if !ok {
+					logger.Error("no slice groups to replay", "input_name", rowData.name, "file", config.ReplaySlicesFile)
+					continue
+				}
+				if replay.algorithm != "" {
+					algName = replay.algorithm
+				}
+				if uerr := model.UpdateAlgorithm(replay); uerr != nil {
+					logger.Error("error replaying slice groups", "input_name", rowData.name, "error", uerr)
+					continue
+				}
+			}
+			oData, rerr := runSimulation(model, algName, rowData, config.MaxDeviationThreshold, time.Duration(config.SlowThresholdMs)*time.Millisecond)
 			if rerr == nil {
+				oData.scoreWeights = scoreWeights(config)
 				outputQueue <- oData
 			}
 		}
@@ -98,20 +661,143 @@ func startSimulation(algName string, inputQueue <-chan inputData) (<-chan output
 }
 
 // helper function helps to generate one piece of outputData from one piece of
-// inputData
-func runSimulation(model *modeling.Model, rowData inputData) (outputData, error) {
+// inputData, algName is only used for logging. A warning is logged if
+// maxDeviationThreshold is positive and the result isn't balanced within it,
+// or if slowThreshold is positive and the row takes longer to simulate.
+func runSimulation(model *modeling.Model, algName string, rowData inputData, maxDeviationThreshold float64, slowThreshold time.Duration) (outputData, error) {
+	start := time.Now()
 	err := model.UpdateRegion(rowData.zones)
 	if err != nil {
-		klog.Errorf("error updating region for input : %s, %v", rowData.name, err)
+		logger.Error("error updating region", "algorithm", algName, "input_name", rowData.name, "elapsed_ms", time.Since(start).Milliseconds(), "error", err)
 		return outputData{}, err
 	}
 	simRes, err := model.StartSimulation()
 	if err != nil {
-		klog.Errorf("error starting simulation for input : %s, %v", rowData.name, err)
+		logger.Error("error starting simulation", "algorithm", algName, "input_name", rowData.name, "elapsed_ms", time.Since(start).Milliseconds(), "error", err)
 		return outputData{}, err
 	}
+	elapsed := time.Since(start)
+	logger.Info("simulation finished", "algorithm", algName, "input_name", rowData.name, "elapsed_ms", elapsed.Milliseconds())
+	if slowThreshold > 0 && elapsed > slowThreshold {
+		logger.Warn("simulation exceeds slow threshold", "algorithm", algName, "input_name", rowData.name, "elapsed_ms", elapsed.Milliseconds(), "threshold_ms", slowThreshold.Milliseconds())
+	}
+	simRes.AlgorithmName = algName
+	simRes.RegionName = rowData.name
+	if maxDeviationThreshold > 0 && !simRes.IsBalanced(maxDeviationThreshold) {
+		logger.Warn("max deviation exceeds threshold", "algorithm", algName, "input_name", rowData.name, "max_deviation", simRes.MaxDeviation, "threshold", maxDeviationThreshold)
+	}
+	sliceGroups := model.GetSliceGroups()
 	return outputData{name: rowData.name,
 		endpoints:      model.GetNumberOfEndpoints(),
 		endpointSlices: model.GetNumberOfEndpointSlices(),
-		result:         simRes}, nil
+		sliceCapacity:  model.GetSliceCapacity(),
+		sliceGroups:    sliceGroups,
+		result:         simRes,
+		algorithm:      algName,
+		fallback:       isFallback(sliceGroups),
+		ElapsedMs:      elapsed.Milliseconds()}, nil
+}
+
+// isFallback returns true if slices only has the global EndpointSliceGroup
+// created by OriginalAlgorithm
+func isFallback(slices map[string]types.EndpointSliceGroup) bool {
+	_, ok := slices["global"]
+	return ok && len(slices) == 1
+}
+
+// startComparison compares all algorithms on input data, produces instances of
+// comparisonData and puts them in a queue(channel)
+func startComparison(config Config, inputQueue <-chan inputData) (<-chan comparisonData, error) {
+	algNames := algorithm.ListAlgorithms()
+	var algs []algorithm.RoutingAlgorithm
+	for _, name := range algNames {
+		algs = append(algs, algorithm.NewAlgorithm(name))
+	}
+	// the model algorithm is only used to initialize the region, every listed
+	// algorithm runs on a scratch copy of it
+	model, err := newModel(config, algorithm.OriginalAlgorithm{})
+	if err != nil {
+		return nil, err
+	}
+	comparisonQueue := make(chan comparisonData)
+	go func() {
+		defer close(comparisonQueue)
+
+		for rowData, more := <-inputQueue; more; rowData, more = <-inputQueue {
+			start := time.Now()
+			if err := model.UpdateRegion(rowData.zones); err != nil {
+				logger.Error("error updating region", "input_name", rowData.name, "elapsed_ms", time.Since(start).Milliseconds(), "error", err)
+				continue
+			}
+			results, err := model.CompareAlgorithms(algNames, algs)
+			if err != nil {
+				logger.Error("error comparing algorithms", "input_name", rowData.name, "elapsed_ms", time.Since(start).Milliseconds(), "error", err)
+				continue
+			}
+			logger.Info("comparison finished", "input_name", rowData.name, "elapsed_ms", time.Since(start).Milliseconds())
+			comparisonQueue <- comparisonData{name: rowData.name, results: results}
+		}
+	}()
+
+	return comparisonQueue, nil
+}
+
+// newAlgorithms creates the algorithms of algNames with the deviation threshold
+// of config like newAlgorithm, algorithms without a deviation threshold keep
+// their defaults
+func newAlgorithms(config Config, algNames []string) ([]algorithm.RoutingAlgorithm, error) {
+	var algs []algorithm.RoutingAlgorithm
+	for _, name := range algNames {
+		algConfig := config
+		algConfig.Algorithm = name
+		if _, ok := algorithm.AlgorithmParamSpec[strings.TrimSuffix(name, "Algorithm")]["threshold"]; !ok {
+			algConfig.Threshold = 0
+			algConfig.AutoTuneThreshold = false
+		}
+		alg, err := newAlgorithm(algConfig)
+		if err != nil {
+			return nil, err
+		}
+		algs = append(algs, alg)
+	}
+	return algs, nil
+}
+
+// startMultiAlgorithmRun runs every algorithm of algNames on input data with
+// the same model created from config, produces instances of multiAlgorithmData
+// scored with the weights of config and puts them in a queue(channel)
+func startMultiAlgorithmRun(config Config, algNames []string, inputQueue <-chan inputData) (<-chan multiAlgorithmData, error) {
+	algs, err := newAlgorithms(config, algNames)
+	if err != nil {
+		return nil, err
+	}
+	model, err := newModel(config, algs[0])
+	if err != nil {
+		return nil, err
+	}
+	weights := scoreWeights(config)
+	slowThreshold := time.Duration(config.SlowThresholdMs) * time.Millisecond
+	multiQueue := make(chan multiAlgorithmData)
+	go func() {
+		defer close(multiQueue)
+
+		for rowData, more := <-inputQueue; more; rowData, more = <-inputQueue {
+			multiData := multiAlgorithmData{name: rowData.name}
+			for i, alg := range algs {
+				oData := outputData{name: rowData.name, result: types.SimulationResult{Invalid: true}, algorithm: algNames[i], scoreWeights: weights}
+				if uerr := model.UpdateAlgorithm(alg); uerr == nil {
+					// errors are logged by runSimulation, the result of the
+					// algorithm is written as invalid
+					if simData, rerr := runSimulation(model, algNames[i], rowData, config.MaxDeviationThreshold, slowThreshold); rerr == nil {
+						oData = simData
+						oData.scoreWeights = weights
+					}
+				}
+				multiData.results = append(multiData.results, oData)
+			}
+			multiQueue <- multiData
+		}
+	}()
+
+	return multiQueue, nil
 }
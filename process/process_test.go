@@ -0,0 +1,192 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestThreshold(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,10 10,10 10,10 10\nunbalanced,1 10,2 2,8 3\n")
+	testCases := []struct {
+		name        string
+		config      Config
+		expectedErr bool
+	}{
+		{name: "threshold", config: Config{Algorithm: "Local", Threshold: 1}},
+		{name: "auto-tune threshold", config: Config{Algorithm: "LocalShared", AutoTuneThreshold: true}},
+		{name: "algorithm without threshold", config: Config{Algorithm: "Original", Threshold: 1}, expectedErr: true},
+		{name: "auto-tune algorithm without threshold", config: Config{Algorithm: "Original", AutoTuneThreshold: true}, expectedErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.config.InputFile = input
+			tc.config.OutputFile = filepath.Join(t.TempDir(), "output.csv")
+			err := StartProcessingWithConfig(tc.config)
+			if (err != nil) != tc.expectedErr {
+				t.Fatalf("expected error: %v, got %v", tc.expectedErr, err)
+			}
+			if err != nil {
+				return
+			}
+			// header, 2 rows and the summary row
+			if records := readOutput(t, tc.config.OutputFile); len(records) != 4 {
+				t.Errorf("expected 4 rows, got %d: %v", len(records), records)
+			}
+		})
+	}
+}
+
+func TestEndpointsPerSlice(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,100 100,100 100,100 100\nunbalanced,1 100,2 200,7 200\n")
+	endpointSlices := map[int][]int{}
+	for _, capacity := range []int{0, 100, 50} {
+		config := Config{InputFile: input, OutputFile: filepath.Join(t.TempDir(), "output.json"), Algorithm: "Original", EndpointsPerSlice: capacity}
+		if err := StartProcessingWithConfig(config); err != nil {
+			t.Fatalf("unexpected error processing with %d endpoints per slice: %v", capacity, err)
+		}
+		content, err := os.ReadFile(config.OutputFile)
+		if err != nil {
+			t.Fatalf("unexpected error reading json output: %v", err)
+		}
+		var outputs []jsonOutput
+		if err := json.Unmarshal(content, &outputs); err != nil {
+			t.Fatalf("unexpected error parsing json output: %v", err)
+		}
+		for _, output := range outputs {
+			endpointSlices[capacity] = append(endpointSlices[capacity], output.EndpointSlices)
+			// the original algorithm is the baseline of the slice score
+			if output.Scores == nil || output.Scores.Slice != 100 {
+				t.Errorf("expected slice score 100 of %s with %d endpoints per slice, got %+v", output.Name, capacity, output.Scores)
+			}
+		}
+	}
+	for index, slices := range endpointSlices[100] {
+		if endpointSlices[0][index] != slices {
+			t.Errorf("expected %d EndpointSlices by default, got %d", slices, endpointSlices[0][index])
+		}
+		if endpointSlices[50][index] != 2*slices {
+			t.Errorf("expected halving endpoints per slice to double %d EndpointSlices, got %d", slices, endpointSlices[50][index])
+		}
+	}
+
+	config := Config{InputFile: input, OutputFile: filepath.Join(t.TempDir(), "output.csv"), Algorithm: "Original", EndpointsPerSlice: -1}
+	if err := StartProcessingWithConfig(config); err == nil {
+		t.Errorf("expected an error with negative endpoints per slice")
+	}
+}
+
+func TestStartProcessing(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,10 10,10 10,10 10\nunbalanced,1 5,2 20,7 20\nsmall,1 1,1 1,1 2\n")
+	output := filepath.Join(t.TempDir(), "output.csv")
+	if err := StartProcessing(input, output, "LocalShared"); err != nil {
+		t.Fatalf("unexpected error processing: %v", err)
+	}
+	records := readOutput(t, output)
+	// header, 3 rows and the summary row
+	if len(records) != 5 {
+		t.Fatalf("expected 5 rows, got %d: %v", len(records), records)
+	}
+	for _, record := range records[1:4] {
+		if _, err := strconv.ParseFloat(record[2], 64); err != nil {
+			t.Errorf("expected a numeric score of %s, got %q", record[0], record[2])
+		}
+	}
+
+	if err := StartProcessing(input, filepath.Join(t.TempDir(), "output.csv"), "Unknown"); err == nil {
+		t.Errorf("expected an error processing with an unknown algorithm")
+	}
+}
+
+func TestBatchProcess(t *testing.T) {
+	inputA := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,10 10,10 10,10 10\nunbalanced,1 5,2 20,7 20\nsmall,1 1,1 1,1 2\n")
+	inputB := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,20 20,20 20,20 20\nskewed,1 30,1 1,1 1\nlarge,100 100,50 200,30 90\n")
+	output := filepath.Join(t.TempDir(), "output.csv")
+	if err := BatchProcess([]string{inputA, inputB}, output, "LocalShared"); err != nil {
+		t.Fatalf("unexpected error processing: %v", err)
+	}
+	records := readOutput(t, output)
+	// header and 3 rows of each input file
+	if len(records) != 7 {
+		t.Fatalf("expected 7 rows, got %d: %v", len(records), records)
+	}
+	if records[0][0] != "source_file" || records[0][1] != "input name" {
+		t.Errorf("expected the source file column before the input name, got %v", records[0])
+	}
+	expected := [][]string{{inputA, "balanced"}, {inputA, "unbalanced"}, {inputA, "small"}, {inputB, "balanced"}, {inputB, "skewed"}, {inputB, "large"}}
+	for index, record := range records[1:] {
+		if record[0] != expected[index][0] || record[1] != expected[index][1] {
+			t.Errorf("expected row %d of %s from %s, got %v", index+1, expected[index][1], expected[index][0], record)
+		}
+	}
+
+	if err := BatchProcess(nil, output, "LocalShared"); err == nil {
+		t.Errorf("expected an error processing no input file")
+	}
+	if err := BatchProcess([]string{inputA, filepath.Join(t.TempDir(), "missing.csv")}, output, "LocalShared"); err == nil {
+		t.Errorf("expected an error processing a missing input file")
+	}
+}
+
+func TestValidateScoreWeights(t *testing.T) {
+	testCases := []struct {
+		name      string
+		weights   ScoreWeights
+		expectErr bool
+	}{
+		{name: "default weights", weights: DefaultScoreWeights()},
+		{name: "only in-zone traffic", weights: ScoreWeights{InZoneTraffic: 1}},
+		{name: "sum within tolerance", weights: ScoreWeights{InZoneTraffic: 0.3335, Deviation: 0.3335, SliceCount: 0.3335}},
+		{name: "sum below 1", weights: ScoreWeights{InZoneTraffic: 0.4, Deviation: 0.4, SliceCount: 0.1}, expectErr: true},
+		{name: "sum above 1", weights: ScoreWeights{InZoneTraffic: 0.5, Deviation: 0.5, SliceCount: 0.1}, expectErr: true},
+		{name: "negative weight", weights: ScoreWeights{InZoneTraffic: 1.2, Deviation: -0.2}, expectErr: true},
+		{name: "zero weights", weights: ScoreWeights{}, expectErr: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := ValidateScoreWeights(tc.weights); (err != nil) != tc.expectErr {
+				t.Errorf("expected error %v validating %+v, got %v", tc.expectErr, tc.weights, err)
+			}
+		})
+	}
+}
+
+func TestScoreWeights(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,10 10,10 10,10 10\nunbalanced,1 5,2 20,7 20\n")
+	config := Config{InputFile: input, OutputFile: filepath.Join(t.TempDir(), "output.csv"), Algorithm: "LocalShared", NoSummary: true, ScoreWeights: ScoreWeights{InZoneTraffic: 1}}
+	if err := StartProcessingWithConfig(config); err != nil {
+		t.Fatalf("unexpected error processing: %v", err)
+	}
+	// the total score is the in-zone traffic score alone
+	for _, record := range readOutput(t, config.OutputFile)[1:] {
+		if record[2] != record[3] {
+			t.Errorf("expected score %s of %s to equal its in-zone traffic score %s", record[2], record[0], record[3])
+		}
+	}
+
+	config.ScoreWeights = ScoreWeights{InZoneTraffic: 0.5, Deviation: 0.4, SliceCount: 0.2}
+	if err := StartProcessingWithConfig(config); err == nil {
+		t.Errorf("expected an error processing with score weights summing up to 1.1")
+	}
+	if err := MultiAlgorithmRunWithConfig(config, []string{"Local"}); err == nil {
+		t.Errorf("expected an error running algorithms with score weights summing up to 1.1")
+	}
+}
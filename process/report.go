@@ -0,0 +1,280 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"html/template"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/algorithm"
+)
+
+// max deviations below lowDeviation are colored green in the heat map, below
+// highDeviation yellow and red otherwise
+const lowDeviation, highDeviation = 0.1, 0.25
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Topology Simulation Report</title>
+<style>
+table { border-collapse: collapse; margin-bottom: 24px; }
+th, td { border: 1px solid #999; padding: 4px 8px; text-align: left; }
+.green { background-color: #c8e6c9; }
+.yellow { background-color: #fff9c4; }
+.red { background-color: #ffcdd2; }
+</style>
+</head>
+<body>
+<h1>Topology Simulation Report</h1>
+<h2>Summary Statistics</h2>
+<table>
+<tr><th>Metric</th><th>Value</th></tr>
+{{range .Summary}}<tr><td>{{.Name}}</td><td>{{.Value}}</td></tr>
+{{end}}</table>
+{{if .Algorithms}}<h2>Algorithm Score Comparison</h2>
+<table>
+<tr><th>Input Name</th>{{range .Algorithms}}<th>{{.}}</th>{{end}}</tr>
+{{range .AlgorithmScores}}<tr><td>{{.Name}}</td>{{range .Scores}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}<h2>Max Deviation Heat Map</h2>
+<table>
+<tr><th>Input Name</th><th>Algorithm</th><th>Max Deviation</th></tr>
+{{range .Deviations}}<tr><td>{{.Name}}</td><td>{{.Algorithm}}</td><td class="{{.Color}}">{{.Value}}</td></tr>
+{{end}}</table>
+{{with .Worst}}<h2>Zone Traffic Distribution of the Worst Row</h2>
+<p>{{.Name}} ({{.Algorithm}}) with score {{.Score}}</p>
+<table>
+<tr><th>Zone</th><th>Incoming Traffic</th><th>Traffic Load</th><th>Mean Deviation</th></tr>
+{{range .Zones}}<tr><td>{{.Name}}</td><td>{{.Incoming}}</td><td>{{.TrafficLoad}}</td><td>{{.MeanDeviation}}</td></tr>
+{{end}}</table>
+{{end}}</body>
+</html>
+`))
+
+// reportData is the content rendered by reportTemplate
+type reportData struct {
+	// Summary statistics of all rows
+	Summary []reportMetric
+	// Algorithms of the rows, only set if rows are from more than one
+	// algorithm
+	Algorithms []string
+	// AlgorithmScores of every input row, one score per algorithm
+	AlgorithmScores []reportScores
+	// Deviations of every row for the heat map
+	Deviations []reportDeviation
+	// Worst valid row by score, nil if there is no valid row
+	Worst *reportWorstRow
+}
+
+// reportMetric is one row of the summary statistics table
+type reportMetric struct {
+	Name  string
+	Value string
+}
+
+// reportScores are the scores of all algorithms on one input row
+type reportScores struct {
+	Name   string
+	Scores []string
+}
+
+// reportDeviation is one cell of the max deviation heat map
+type reportDeviation struct {
+	Name      string
+	Algorithm string
+	Value     string
+	// Color is the css class of the cell, green, yellow or red
+	Color string
+}
+
+// reportWorstRow is the zone traffic distribution of the worst-scoring row
+type reportWorstRow struct {
+	Name      string
+	Algorithm string
+	Score     string
+	Zones     []reportZone
+}
+
+// reportZone is the traffic of one zone of the worst-scoring row
+type reportZone struct {
+	Name          string
+	Incoming      string
+	TrafficLoad   string
+	MeanDeviation string
+}
+
+// GenerateHTMLReport writes an HTML report of rows and their aggregate
+// statistics to outputFile. The report has a summary statistics table, a score
+// comparison table if rows are from more than one algorithm, a heat map of max
+// deviations and the zone traffic distribution of the worst-scoring row.
+func GenerateHTMLReport(outputFile string, rows []outputData, aggStats AggregateStats) (err error) {
+	reportFile, err := os.Create(outputFile)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cerr := reportFile.Close()
+		if cerr != nil {
+			logger.Error("failed to close report file", "file", outputFile, "error", cerr)
+		}
+		if err == nil {
+			err = cerr
+		}
+	}()
+
+	logger.Info("writing html report", "file", outputFile)
+	return reportTemplate.Execute(reportFile, newReportData(rows, aggStats))
+}
+
+// newReportData converts rows and aggStats to the content of the report
+func newReportData(rows []outputData, aggStats AggregateStats) reportData {
+	data := reportData{
+		Summary: []reportMetric{
+			{"mean score", formatFloat(aggStats.MeanScore)},
+			{"P5 score", formatFloat(aggStats.P5Score)},
+			{"P95 score", formatFloat(aggStats.P95Score)},
+			{"max score", formatFloat(aggStats.MaxScore)},
+			{"min score", formatFloat(aggStats.MinScore)},
+			{"fallback ratio", formatFloat(aggStats.FallbackRatio)},
+			{"high in-zone traffic ratio", formatFloat(aggStats.HighInZoneTrafficRatio)},
+		},
+	}
+
+	var worst outputData
+	worstScore, found := 0.0, false
+	for _, rowData := range rows {
+		deviation := reportDeviation{Name: rowData.name, Algorithm: rowData.algorithm, Value: "invalid", Color: "red"}
+		if !rowData.result.Invalid {
+			deviation.Value = formatPercent(rowData.result.MaxDeviation)
+			deviation.Color = deviationColor(rowData.result.MaxDeviation)
+			score := evaluate(rowData).Total
+			if !found || score < worstScore {
+				worst, worstScore, found = rowData, score, true
+			}
+		}
+		data.Deviations = append(data.Deviations, deviation)
+	}
+	if found {
+		data.Worst = newReportWorstRow(worst, worstScore)
+	}
+
+	data.Algorithms, data.AlgorithmScores = algorithmScores(rows)
+	if len(data.Algorithms) < 2 {
+		data.Algorithms, data.AlgorithmScores = nil, nil
+	}
+	return data
+}
+
+// algorithmScores groups the scores of rows by input name, one score per
+// algorithm. Algorithms and input names are in the order they first appear.
+func algorithmScores(rows []outputData) ([]string, []reportScores) {
+	var algNames, names []string
+	seenAlgs := map[string]bool{}
+	scores := map[string]map[string]string{}
+	for _, rowData := range rows {
+		if !seenAlgs[rowData.algorithm] {
+			seenAlgs[rowData.algorithm] = true
+			algNames = append(algNames, rowData.algorithm)
+		}
+		if _, ok := scores[rowData.name]; !ok {
+			scores[rowData.name] = map[string]string{}
+			names = append(names, rowData.name)
+		}
+		score := "invalid"
+		if !rowData.result.Invalid {
+			score = formatFloat(evaluate(rowData).Total)
+		}
+		scores[rowData.name][rowData.algorithm] = score
+	}
+	var allScores []reportScores
+	for _, name := range names {
+		rowScores := reportScores{Name: name}
+		for _, algName := range algNames {
+			score, ok := scores[name][algName]
+			if !ok {
+				score = "missing"
+			}
+			rowScores.Scores = append(rowScores.Scores, score)
+		}
+		allScores = append(allScores, rowScores)
+	}
+	return algNames, allScores
+}
+
+// newReportWorstRow returns the zone traffic distribution of a valid row with
+// zones sorted by name
+func newReportWorstRow(rowData outputData, score float64) *reportWorstRow {
+	worst := &reportWorstRow{Name: rowData.name, Algorithm: rowData.algorithm, Score: formatFloat(score)}
+	for name, traffic := range rowData.result.TrafficDistribution {
+		worst.Zones = append(worst.Zones, reportZone{
+			Name:          name,
+			Incoming:      formatPercent(traffic.Incoming),
+			TrafficLoad:   formatFloat(finite(traffic.TrafficLoad)),
+			MeanDeviation: formatPercent(finite(traffic.ZoneTrafficDetail.MeanDeviation)),
+		})
+	}
+	sort.Slice(worst.Zones, func(i, j int) bool {
+		return worst.Zones[i].Name < worst.Zones[j].Name
+	})
+	return worst
+}
+
+// writeComparisonReport writes a markdown table comparing the algorithms of
+// rows on every input name to file
+func writeComparisonReport(file string, rows []outputData) error {
+	var scenarios []algorithm.ScenarioResult
+	index := map[string]int{}
+	for _, rowData := range rows {
+		if _, ok := index[rowData.name]; !ok {
+			index[rowData.name] = len(scenarios)
+			scenarios = append(scenarios, algorithm.ScenarioResult{ScenarioName: rowData.name})
+		}
+		result := algorithm.AlgorithmResult{Name: rowData.algorithm, Result: rowData.result}
+		if !rowData.result.Invalid {
+			result.Score = evaluate(rowData).Total
+		}
+		scenario := &scenarios[index[rowData.name]]
+		scenario.AlgorithmResults = append(scenario.AlgorithmResults, result)
+	}
+	logger.Info("writing comparison report", "file", file)
+	return os.WriteFile(file, []byte(algorithm.GenerateComparisonMarkdown(scenarios)), 0644)
+}
+
+// deviationColor returns the heat map color of a max deviation
+func deviationColor(deviation float64) string {
+	switch {
+	case deviation < lowDeviation:
+		return "green"
+	case deviation < highDeviation:
+		return "yellow"
+	}
+	return "red"
+}
+
+// formatFloat formats value with 4 decimal places like the csv output
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', 4, 64)
+}
+
+// formatPercent formats a ratio as a percentage like the csv output
+func formatPercent(value float64) string {
+	return strconv.FormatFloat(value*100, 'f', 4, 64) + "%"
+}
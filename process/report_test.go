@@ -0,0 +1,117 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHTMLReport(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,10 10,10 10,10 10\nunbalanced,1 5,2 20,7 20\n")
+	headers := []string{"<h2>Summary Statistics</h2>", "<th>Metric</th>", "<h2>Max Deviation Heat Map</h2>", "<th>Max Deviation</th>", "<h2>Zone Traffic Distribution of the Worst Row</h2>", "<th>Traffic Load</th>"}
+	comparisonHeader := "<h2>Algorithm Score Comparison</h2>"
+	testCases := []struct {
+		name       string
+		algNames   []string
+		comparison bool
+	}{
+		{name: "one algorithm", algNames: []string{"LocalShared"}},
+		{name: "multiple algorithms", algNames: []string{"Local", "Original"}, comparison: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			config := Config{InputFile: input, OutputFile: filepath.Join(dir, "output.csv"), HTMLReportFile: filepath.Join(dir, "report.html"), Algorithm: tc.algNames[0]}
+			var err error
+			if len(tc.algNames) > 1 {
+				err = MultiAlgorithmRunWithConfig(config, tc.algNames)
+			} else {
+				err = StartProcessingWithConfig(config)
+			}
+			if err != nil {
+				t.Fatalf("unexpected error processing: %v", err)
+			}
+			content, err := os.ReadFile(config.HTMLReportFile)
+			if err != nil {
+				t.Fatalf("unexpected error reading report: %v", err)
+			}
+			report := string(content)
+			for _, header := range headers {
+				if !strings.Contains(report, header) {
+					t.Errorf("expected report to contain %s", header)
+				}
+			}
+			if strings.Contains(report, comparisonHeader) != tc.comparison {
+				t.Errorf("expected report to contain %s: %v", comparisonHeader, tc.comparison)
+			}
+			for _, name := range tc.algNames {
+				if tc.comparison && !strings.Contains(report, "<th>"+name+"</th>") {
+					t.Errorf("expected report to contain a column of %s", name)
+				}
+			}
+		})
+	}
+}
+
+func TestComparisonReport(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,10 10,10 10,10 10\nunbalanced,1 5,2 20,7 20\n")
+	dir := t.TempDir()
+	algNames := []string{"Local", "Original", "LocalShared"}
+	config := Config{InputFile: input, OutputFile: filepath.Join(dir, "output.csv"), ComparisonReportFile: filepath.Join(dir, "comparison.md")}
+	if err := MultiAlgorithmRunWithConfig(config, algNames); err != nil {
+		t.Fatalf("unexpected error processing: %v", err)
+	}
+	content, err := os.ReadFile(config.ComparisonReportFile)
+	if err != nil {
+		t.Fatalf("unexpected error reading comparison report: %v", err)
+	}
+	report := string(content)
+	if !strings.HasPrefix(report, "| Scenario |") {
+		t.Errorf("expected comparison report to start with the header, got %q", report)
+	}
+	// header and separator lines, one line per algorithm of each input row
+	if lines := strings.Count(report, "\n"); lines != 2+2*len(algNames) {
+		t.Errorf("expected %d lines in comparison report, got %d", 2+2*len(algNames), lines)
+	}
+	for _, name := range algNames {
+		if !strings.Contains(report, "| unbalanced | "+name+" |") {
+			t.Errorf("expected comparison report to contain %s on unbalanced", name)
+		}
+	}
+}
+
+func TestDeviationColor(t *testing.T) {
+	testCases := []struct {
+		deviation float64
+		expected  string
+	}{
+		{deviation: 0, expected: "green"},
+		{deviation: 0.05, expected: "green"},
+		{deviation: 0.1, expected: "yellow"},
+		{deviation: 0.2, expected: "yellow"},
+		{deviation: 0.25, expected: "red"},
+		{deviation: 1, expected: "red"},
+	}
+	for _, tc := range testCases {
+		if actual := deviationColor(tc.deviation); actual != tc.expected {
+			t.Errorf("expected color %s of deviation %v, got %s", tc.expected, tc.deviation, actual)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling"
+	"github.com/googleinterns/k8s-topology-simulator/modeling/algorithm"
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// simulateRequest is the JSON body of a POST /simulate request
+type simulateRequest struct {
+	// Zones of the region to simulate
+	Zones []types.Zone `json:"zones"`
+	// Algorithm name, the default algorithm of the server is used if empty
+	Algorithm string `json:"algorithm"`
+}
+
+// errorResponse is the JSON body of a failed request
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// ServeHTTP starts an HTTP server on addr which simulates zones of POST
+// /simulate requests and returns the simulation results as JSON. Requests
+// without an algorithm are simulated with algName. /healthz returns the status
+// of the server.
+func ServeHTTP(addr string, algName string) error {
+	logger.Info("serving simulations", "addr", addr, "algorithm", algName)
+	return http.ListenAndServe(addr, newServerHandler(algName))
+}
+
+// newServerHandler returns the handler of ServeHTTP
+func newServerHandler(algName string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("/simulate", func(w http.ResponseWriter, r *http.Request) {
+		handleSimulate(w, r, algName)
+	})
+	return mux
+}
+
+// handleSimulate simulates the zones of a request with the requested
+// algorithm, or algName if it's not set
+func handleSimulate(w http.ResponseWriter, r *http.Request, algName string) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "only POST is allowed"})
+		return
+	}
+	var request simulateRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&request); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid request body: %v", err)})
+		return
+	}
+	if request.Algorithm == "" {
+		request.Algorithm = algName
+	}
+	if !algorithm.IsKnownAlgorithm(request.Algorithm) {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("unknown algorithm %q", request.Algorithm)})
+		return
+	}
+
+	// every request has its own model since models are not threadsafe
+	model, err := modeling.NewModelWithOptions(modeling.WithAlgorithm(algorithm.NewAlgorithm(request.Algorithm)))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+		return
+	}
+	if err := model.UpdateRegion(request.Zones); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: fmt.Sprintf("invalid zones: %v", err)})
+		return
+	}
+	result, err := model.StartSimulation()
+	if err != nil {
+		logger.Error("error starting simulation", "algorithm", request.Algorithm, "error", err)
+		writeJSON(w, http.StatusInternalServerError, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, finiteResult(result))
+}
+
+// writeJSON writes value as the JSON body of a response with status
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		logger.Error("failed to write response", "error", err)
+	}
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+func TestServeHTTP(t *testing.T) {
+	server := httptest.NewServer(newServerHandler("LocalShared"))
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/healthz")
+	if err != nil {
+		t.Fatalf("unexpected error getting health: %v", err)
+	}
+	var health map[string]string
+	if err := json.NewDecoder(response.Body).Decode(&health); err != nil {
+		t.Fatalf("unexpected error decoding health: %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK || health["status"] != "ok" {
+		t.Errorf("expected status ok, got %d %v", response.StatusCode, health)
+	}
+
+	zones := `[{"name": "zoneA", "nodes": 1, "endpoints": 5}, {"name": "zoneB", "nodes": 2, "endpoints": 20}, {"name": "zoneC", "nodes": 7, "endpoints": 20}]`
+	testCases := []struct {
+		name           string
+		method         string
+		body           string
+		expectedStatus int
+	}{
+		{name: "default algorithm", method: http.MethodPost, body: `{"zones": ` + zones + `}`, expectedStatus: http.StatusOK},
+		{name: "requested algorithm", method: http.MethodPost, body: `{"zones": ` + zones + `, "algorithm": "OriginalAlgorithm"}`, expectedStatus: http.StatusOK},
+		{name: "invalid zones", method: http.MethodPost, body: `{"zones": [{"name": "zoneA", "nodes": -1, "endpoints": 5}]}`, expectedStatus: http.StatusBadRequest},
+		{name: "no zones", method: http.MethodPost, body: `{"zones": []}`, expectedStatus: http.StatusBadRequest},
+		{name: "unknown algorithm", method: http.MethodPost, body: `{"zones": ` + zones + `, "algorithm": "Unknown"}`, expectedStatus: http.StatusBadRequest},
+		{name: "malformed body", method: http.MethodPost, body: `{"zones":`, expectedStatus: http.StatusBadRequest},
+		{name: "wrong method", method: http.MethodGet, expectedStatus: http.StatusMethodNotAllowed},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			request, err := http.NewRequest(tc.method, server.URL+"/simulate", strings.NewReader(tc.body))
+			if err != nil {
+				t.Fatalf("unexpected error creating request: %v", err)
+			}
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatalf("unexpected error simulating: %v", err)
+			}
+			defer response.Body.Close()
+			if response.StatusCode != tc.expectedStatus {
+				t.Fatalf("expected status %d, got %d", tc.expectedStatus, response.StatusCode)
+			}
+			if tc.expectedStatus != http.StatusOK {
+				var body errorResponse
+				if err := json.NewDecoder(response.Body).Decode(&body); err != nil || body.Error == "" {
+					t.Errorf("expected a JSON error body, got %+v, %v", body, err)
+				}
+				return
+			}
+			var result types.SimulationResult
+			if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+				t.Fatalf("unexpected error decoding result: %v", err)
+			}
+			if result.Invalid || result.InZoneTraffic <= 0 || len(result.TrafficDistribution) != 3 {
+				t.Errorf("expected a valid result of 3 zones, got %+v", result)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// sliceGroupsRecord is one line of a slice groups dump, the EndpointSliceGroups
+// of one row serialized by types.SerializeSliceGroups
+type sliceGroupsRecord struct {
+	Name        string          `json:"name"`
+	Algorithm   string          `json:"algorithm"`
+	SliceGroups json.RawMessage `json:"sliceGroups"`
+}
+
+// writeSliceGroupsDump writes the EndpointSliceGroups of all rows to file, one
+// JSON object per line
+func writeSliceGroupsDump(file string, rows []outputData) (err error) {
+	dumpFile, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		cerr := dumpFile.Close()
+		if cerr != nil {
+			logger.Error("failed to close slice groups dump", "file", file, "error", cerr)
+		}
+		if err == nil {
+			err = cerr
+		}
+	}()
+
+	logger.Info("writing slice groups dump", "file", file)
+	writer := bufio.NewWriter(dumpFile)
+	encoder := json.NewEncoder(writer)
+	for _, rowData := range rows {
+		sliceGroups, err := types.SerializeSliceGroups(rowData.sliceGroups)
+		if err != nil {
+			return fmt.Errorf("failed to serialize slice groups of %s: %v", rowData.name, err)
+		}
+		err = encoder.Encode(sliceGroupsRecord{Name: rowData.name, Algorithm: rowData.algorithm, SliceGroups: sliceGroups})
+		if err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// readSliceGroupsDump reads EndpointSliceGroups written by
+// writeSliceGroupsDump by input name. A row dumped more than once, e.g. by
+// several algorithms, keeps its last record.
+func readSliceGroupsDump(file string) (map[string]replayedSliceGroups, error) {
+	dumpFile, err := os.Open(filepath.Clean(file))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		cerr := dumpFile.Close()
+		if cerr != nil {
+			logger.Error("failed to close slice groups dump", "file", file, "error", cerr)
+		}
+	}()
+
+	records := map[string]replayedSliceGroups{}
+	scanner := bufio.NewScanner(dumpFile)
+	// a row with many zones can exceed the default max line length
+	scanner.Buffer(nil, 64*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var record sliceGroupsRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("line %d of %s: %v", line, file, err)
+		}
+		sliceGroups, err := types.DeserializeSliceGroups(record.SliceGroups)
+		if err != nil {
+			return nil, fmt.Errorf("line %d of %s: %v", line, file, err)
+		}
+		records[record.Name] = replayedSliceGroups{algorithm: record.Algorithm, sliceGroups: sliceGroups}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// replayedSliceGroups is a routing algorithm creating the EndpointSliceGroups
+// read from a slice groups dump regardless of the region, so they are
+// simulated without running the algorithm that created them
+type replayedSliceGroups struct {
+	// name of the algorithm that created sliceGroups
+	algorithm   string
+	sliceGroups map[string]types.EndpointSliceGroup
+}
+
+// CreateSliceGroups returns a deep copy of the replayed EndpointSliceGroups
+func (r replayedSliceGroups) CreateSliceGroups(types.RegionInfo) (map[string]types.EndpointSliceGroup, error) {
+	sliceGroups := make(map[string]types.EndpointSliceGroup, len(r.sliceGroups))
+	for label, sliceGroup := range r.sliceGroups {
+		sliceGroups[label] = sliceGroup.Clone()
+	}
+	return sliceGroups, nil
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDumpAndReplaySlices(t *testing.T) {
+	rows := "balanced,10 10,10 10,10 10\nunbalanced,1 5,2 20,7 20\n"
+	dir := t.TempDir()
+	dumpFile := filepath.Join(dir, "dump.jsonl")
+	dumpConfig := Config{InputFile: writeInput(t, "name,zoneA,zoneB,zoneC\n"+rows), OutputFile: filepath.Join(dir, "dumped.csv"), Algorithm: "LocalShared", NoSummary: true, DumpSlicesFile: dumpFile}
+	if err := StartProcessingWithConfig(dumpConfig); err != nil {
+		t.Fatalf("unexpected error processing with dump: %v", err)
+	}
+	content, err := os.ReadFile(dumpFile)
+	if err != nil {
+		t.Fatalf("unexpected error reading dump: %v", err)
+	}
+	if lines := strings.Count(string(content), "\n"); lines != 2 {
+		t.Errorf("expected 2 lines in dump, got %d", lines)
+	}
+
+	// replaying with OriginalAlgorithm reproduces the results of LocalShared,
+	// the row missing from the dump is skipped
+	replayConfig := Config{InputFile: writeInput(t, "name,zoneA,zoneB,zoneC\n"+rows+"missing,1 1,1 1,1 1\n"), OutputFile: filepath.Join(dir, "replayed.csv"), Algorithm: "Original", NoSummary: true, ReplaySlicesFile: dumpFile}
+	if err := StartProcessingWithConfig(replayConfig); err != nil {
+		t.Fatalf("unexpected error processing with replay: %v", err)
+	}
+	dumped, replayed := readOutput(t, dumpConfig.OutputFile), readOutput(t, replayConfig.OutputFile)
+	if len(dumped) != len(replayed) {
+		t.Fatalf("expected %d replayed records, got %d", len(dumped), len(replayed))
+	}
+	for i := range dumped {
+		// the last column is the elapsed time
+		expected, actual := dumped[i][:len(dumped[i])-1], replayed[i][:len(replayed[i])-1]
+		if !reflect.DeepEqual(expected, actual) {
+			t.Errorf("expected replayed record %v, got %v", expected, actual)
+		}
+	}
+}
+
+func TestReadSliceGroupsDump(t *testing.T) {
+	testCases := []struct {
+		name     string
+		content  string
+		expected map[string]string
+		err      bool
+	}{
+		{
+			name:     "last record of a row",
+			content:  `{"name":"row","algorithm":"Local","sliceGroups":{}}` + "\n\n" + `{"name":"row","algorithm":"Original","sliceGroups":{"global":{"Label":"global"}}}` + "\n",
+			expected: map[string]string{"row": "Original"},
+		},
+		{
+			name:    "malformed record",
+			content: "{\n",
+			err:     true,
+		},
+		{
+			name:    "invalid slice groups",
+			content: `{"name":"row","sliceGroups":{"global":{"Label":"global","ZoneTrafficWeights":{"ZoneA":-1}}}}` + "\n",
+			err:     true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "dump.jsonl")
+			if err := os.WriteFile(file, []byte(tc.content), 0600); err != nil {
+				t.Fatalf("unexpected error writing dump: %v", err)
+			}
+			records, err := readSliceGroupsDump(file)
+			if (err != nil) != tc.err {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+			for name, algName := range tc.expected {
+				if records[name].algorithm != algName {
+					t.Errorf("expected %s to be replayed from %s, got %+v", name, algName, records[name])
+				}
+			}
+		})
+	}
+	if _, err := readSliceGroupsDump(filepath.Join(t.TempDir(), "missing.jsonl")); err == nil {
+		t.Errorf("expected error reading a missing dump")
+	}
+}
+
+func TestReplaySlicesMultipleAlgorithms(t *testing.T) {
+	config := Config{InputFile: writeInput(t, "name,zoneA\nrow,1 1\n"), OutputFile: filepath.Join(t.TempDir(), "output.csv"), ReplaySlicesFile: "dump.jsonl"}
+	if err := MultiAlgorithmRunWithConfig(config, []string{"Local", "Original"}); err == nil {
+		t.Errorf("expected error replaying slices with multiple algorithms")
+	}
+}
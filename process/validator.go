@@ -0,0 +1,185 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/googleinterns/k8s-topology-simulator/modeling/types"
+)
+
+// minZones is the minimum number of zones of a valid input file
+const minZones = 2
+
+// ValidationError describes a problem found in an input file
+type ValidationError struct {
+	// Row of the problem in the file, the header is row 1
+	Row int
+	// Column of the problem, the header name of the column if there is one.
+	// Empty if the problem is about the whole row.
+	Column string
+	// Message describing the problem
+	Message string
+	// fatal problems are in the header and prevent the file from being parsed,
+	// problems of other rows only make the row skipped or simulated as is
+	fatal bool
+}
+
+// Error implements the error interface
+func (e ValidationError) Error() string {
+	if e.Column == "" {
+		return fmt.Sprintf("row %d: %s", e.Row, e.Message)
+	}
+	return fmt.Sprintf("row %d, column %s: %s", e.Row, e.Column, e.Message)
+}
+
+// ValidateInput reads the whole input file and checks every row without running
+// any simulation. It returns the number of data rows and every problem found,
+// err is only returned if the file can't be read.
+func ValidateInput(inputFile string) (rowCount int, validationErrors []ValidationError, err error) {
+	file, err := os.Open(filepath.Join("", filepath.Clean(inputFile)))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer func() {
+		cerr := file.Close()
+		if cerr != nil {
+			logger.Error("failed to close input file", "file", inputFile, "error", cerr)
+		}
+	}()
+
+	reader := csv.NewReader(file)
+	reader.TrimLeadingSpace = true
+	// column count is checked per row below
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err == io.EOF {
+		return 0, []ValidationError{{Row: 1, Message: "missing header", fatal: true}}, nil
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// columns without zone name are allowed like the parser does, as long as
+	// their cells are empty
+	zoneNames := map[string]bool{}
+	for _, name := range header[1:] {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if zoneNames[name] {
+			validationErrors = append(validationErrors, ValidationError{Row: 1, Column: name, Message: "duplicated zone name", fatal: true})
+		}
+		zoneNames[name] = true
+	}
+	if len(zoneNames) < minZones {
+		validationErrors = append(validationErrors, ValidationError{Row: 1, Message: fmt.Sprintf("expected at least %d zones, got %d", minZones, len(zoneNames)), fatal: true})
+	}
+
+	for {
+		var rowCells []string
+		rowCells, err = reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rowCount, validationErrors, err
+		}
+		rowCount++
+		// the header is the first row of the file
+		row := rowCount + 1
+		// the parser skips rows with a different number of columns
+		if len(rowCells) != len(header) {
+			validationErrors = append(validationErrors, ValidationError{Row: row, Message: fmt.Sprintf("expected %d columns, got %d", len(header), len(rowCells))})
+			continue
+		}
+		if strings.TrimSpace(rowCells[0]) == "" {
+			validationErrors = append(validationErrors, ValidationError{Row: row, Column: columnName(header, 0), Message: "empty input name"})
+		}
+		for index, cell := range rowCells[1:] {
+			if strings.TrimSpace(header[index+1]) == "" {
+				if strings.TrimSpace(cell) != "" {
+					validationErrors = append(validationErrors, ValidationError{Row: row, Column: columnName(header, index+1), Message: fmt.Sprintf("%q should be empty in a column without zone name", cell)})
+				}
+				continue
+			}
+			if message := validateZoneCell(cell); message != "" {
+				validationErrors = append(validationErrors, ValidationError{Row: row, Column: columnName(header, index+1), Message: message})
+			}
+		}
+	}
+	return rowCount, validationErrors, nil
+}
+
+// columnName returns the header name of a column, or its position if it has no
+// name
+func columnName(header []string, index int) string {
+	if index < len(header) && strings.TrimSpace(header[index]) != "" {
+		return strings.TrimSpace(header[index])
+	}
+	return fmt.Sprintf("#%d", index+1)
+}
+
+// validateZoneCell checks one "nodes endpoints [options]" cell, returns a
+// description of the problem or an empty string if the cell is valid
+func validateZoneCell(cell string) string {
+	fields := strings.Fields(cell)
+	if len(fields) < 2 {
+		return fmt.Sprintf("%q should contain number of nodes and endpoints", cell)
+	}
+	for index, kind := range []string{"nodes", "endpoints"} {
+		number, err := strconv.Atoi(fields[index])
+		if err != nil {
+			return fmt.Sprintf("number of %s %q is not an integer", kind, fields[index])
+		}
+		if number < 0 {
+			return fmt.Sprintf("number of %s %d should not be negative", kind, number)
+		}
+	}
+	if err := parseZoneOptions(&types.Zone{}, fields[2:]); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// validateBeforeProcessing validates the input file, logs every non-fatal
+// problem as a warning and returns the first fatal problem. If strict is true,
+// every problem is fatal.
+func validateBeforeProcessing(inputFile string, strict bool) error {
+	_, validationErrors, err := ValidateInput(inputFile)
+	if err != nil {
+		return err
+	}
+	var fatalErr error
+	for _, validationError := range validationErrors {
+		if validationError.fatal || strict {
+			if fatalErr == nil {
+				fatalErr = validationError
+			}
+			continue
+		}
+		logger.Warn("invalid input", "row", validationError.Row, "column", validationError.Column, "error", validationError.Message)
+	}
+	return fatalErr
+}
@@ -0,0 +1,223 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateInput(t *testing.T) {
+	testCases := []struct {
+		name           string
+		input          string
+		expectedRows   int
+		expectedErrors []ValidationError
+	}{
+		{
+			name:         "valid input",
+			input:        "name,zoneA,zoneB,zoneC\nrow1,1 5,2 20,7 20\nrow2,30 100,30 0,30 0\n",
+			expectedRows: 2,
+		},
+		{
+			name:           "empty file",
+			input:          "",
+			expectedRows:   0,
+			expectedErrors: []ValidationError{{Row: 1, Message: "missing header", fatal: true}},
+		},
+		{
+			name:           "one zone column",
+			input:          "name,zoneA\nrow1,1 5\n",
+			expectedRows:   1,
+			expectedErrors: []ValidationError{{Row: 1, Message: "expected at least 2 zones", fatal: true}},
+		},
+		{
+			name:         "invalid zone names",
+			input:        "name,zoneA,,zoneA\nrow1,1 5,2 20,7 20\n",
+			expectedRows: 1,
+			expectedErrors: []ValidationError{
+				{Row: 1, Column: "zoneA", Message: "duplicated zone name", fatal: true},
+				{Row: 1, Message: "expected at least 2 zones, got 1", fatal: true},
+				{Row: 2, Column: "#3", Message: "\"2 20\" should be empty in a column without zone name"},
+			},
+		},
+		{
+			name:         "trailing column without zone name",
+			input:        "name,zoneA,zoneB,\nrow1,1 5,2 20,\nrow2,1 5,2 20,3 30\n",
+			expectedRows: 2,
+			expectedErrors: []ValidationError{
+				{Row: 3, Column: "#4", Message: "\"3 30\" should be empty in a column without zone name"},
+			},
+		},
+		{
+			name:           "empty input name",
+			input:          "name,zoneA,zoneB\n,1 5,2 20\n",
+			expectedRows:   1,
+			expectedErrors: []ValidationError{{Row: 2, Column: "name", Message: "empty input name"}},
+		},
+		{
+			name:         "negative numbers",
+			input:        "name,zoneA,zoneB\nrow1,-1 5,2 20\nrow2,1 5,2 -20\n",
+			expectedRows: 2,
+			expectedErrors: []ValidationError{
+				{Row: 2, Column: "zoneA", Message: "number of nodes -1 should not be negative"},
+				{Row: 3, Column: "zoneB", Message: "number of endpoints -20 should not be negative"},
+			},
+		},
+		{
+			name:         "malformed cells",
+			input:        "name,zoneA,zoneB\nrow1,1,2 20\nrow2,a 5,2 20\n",
+			expectedRows: 2,
+			expectedErrors: []ValidationError{
+				{Row: 2, Column: "zoneA", Message: "\"1\" should contain number of nodes and endpoints"},
+				{Row: 3, Column: "zoneA", Message: "number of nodes \"a\" is not an integer"},
+			},
+		},
+		{
+			name:         "zone labels",
+			input:        "name,zoneA,zoneB\nrow1,\"1 5 region=us,tier=gold\",2 20 region=eu\nrow2,1 5 region,2 20 =eu\n",
+			expectedRows: 2,
+			expectedErrors: []ValidationError{
+				{Row: 3, Column: "zoneA", Message: "unknown zone option \"region\""},
+				{Row: 3, Column: "zoneB", Message: "label \"=eu\" should be in the form of key=value"},
+			},
+		},
+		{
+			name:         "cross-zone latency",
+			input:        "name,zoneA,zoneB\nrow1,1 5 2.5ms,2 20 4ms\nrow2,1 5 -1ms,2 20 fastms\n",
+			expectedRows: 2,
+			expectedErrors: []ValidationError{
+				{Row: 3, Column: "zoneA", Message: "cross-zone latency \"-1ms\" should not be negative"},
+				{Row: 3, Column: "zoneB", Message: "cross-zone latency \"fastms\" is not a number"},
+			},
+		},
+		{
+			name:           "inconsistent columns",
+			input:          "name,zoneA,zoneB,zoneC\nrow1,1 5,2 20\nrow2,1 5,2 20,7 20\n",
+			expectedRows:   2,
+			expectedErrors: []ValidationError{{Row: 2, Message: "expected 4 columns, got 3"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rows, validationErrors, err := ValidateInput(writeInput(t, tc.input))
+			if err != nil {
+				t.Fatalf("unexpected error validating input: %v", err)
+			}
+			if rows != tc.expectedRows {
+				t.Errorf("expected %d rows, got %d", tc.expectedRows, rows)
+			}
+			if len(validationErrors) != len(tc.expectedErrors) {
+				t.Fatalf("expected %d validation errors, got %v", len(tc.expectedErrors), validationErrors)
+			}
+			for index, expected := range tc.expectedErrors {
+				got := validationErrors[index]
+				if got.Row != expected.Row || got.Column != expected.Column || got.fatal != expected.fatal || !strings.HasPrefix(got.Message, expected.Message) {
+					t.Errorf("expected validation error %+v, got %+v", expected, got)
+				}
+			}
+		})
+	}
+
+	if _, _, err := ValidateInput("non-existent.csv"); err == nil {
+		t.Errorf("expected an error validating a non-existent file")
+	}
+}
+
+func TestValidationError(t *testing.T) {
+	if got := (ValidationError{Row: 2, Column: "zoneA", Message: "bad cell"}).Error(); got != "row 2, column zoneA: bad cell" {
+		t.Errorf("got unexpected error string %q", got)
+	}
+	if got := (ValidationError{Row: 1, Message: "missing header"}).Error(); got != "row 1: missing header" {
+		t.Errorf("got unexpected error string %q", got)
+	}
+}
+
+func TestValidateBeforeProcessing(t *testing.T) {
+	testCases := []struct {
+		name          string
+		input         string
+		strict        bool
+		expectedError string
+		// expectedRows are the input names in the output if there is no error
+		expectedRows []string
+	}{
+		{
+			name:         "warnings only",
+			input:        "name,zoneA,zoneB\nrow1,-1 5,2 20\nrow2,1 5,2 20\n",
+			expectedRows: []string{"row2"},
+		},
+		{
+			name:         "trailing column without zone name",
+			input:        "name,zoneA,zoneB,\nrow1,1 5,2 20,\nrow2,1 5,2 20,\n",
+			expectedRows: []string{"row1", "row2"},
+		},
+		{
+			// the row with missing columns is skipped
+			name:         "row with missing columns",
+			input:        "name,zoneA,zoneB,zoneC\nrow1,1 5,2 20\nrow2,1 5,2 20,7 20\n",
+			expectedRows: []string{"row2"},
+		},
+		{
+			name:          "row with missing columns in strict mode",
+			input:         "name,zoneA,zoneB,zoneC\nrow1,1 5,2 20\nrow2,1 5,2 20,7 20\n",
+			strict:        true,
+			expectedError: "row 2: expected 4 columns, got 3",
+		},
+		{
+			// the parser skips the row with a malformed cell
+			name:         "cell without endpoints",
+			input:        "name,zoneA,zoneB\nrow1,1 5,2 20\nrow2,3,2 20\nrow3,1 5,2 20\n",
+			expectedRows: []string{"row1", "row3"},
+		},
+		{
+			name:          "cell without endpoints in strict mode",
+			input:         "name,zoneA,zoneB\nrow1,1 5,2 20\nrow2,3,2 20\nrow3,1 5,2 20\n",
+			strict:        true,
+			expectedError: "row 3, column zoneA",
+		},
+		{
+			name:          "fatal header error",
+			input:         "name,zoneA\nrow1,1 5\n",
+			expectedError: "row 1: expected at least 2 zones",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := Config{InputFile: writeInput(t, tc.input), OutputFile: filepath.Join(t.TempDir(), "output.csv"), Algorithm: "LocalShared", Strict: tc.strict, NoSummary: true}
+			err := StartProcessingWithConfig(config)
+			if tc.expectedError == "" {
+				if err != nil {
+					t.Fatalf("unexpected error processing: %v", err)
+				}
+				var names []string
+				for _, record := range readOutput(t, config.OutputFile)[1:] {
+					names = append(names, record[0])
+				}
+				if !reflect.DeepEqual(names, tc.expectedRows) {
+					t.Errorf("expected rows %v in the output, got %v", tc.expectedRows, names)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tc.expectedError) {
+				t.Errorf("expected error starting with %q, got %v", tc.expectedError, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// the input file is polled every watchPollInterval in watch mode, and a run
+// starts once it hasn't changed for watchDebounce
+const watchPollInterval, watchDebounce = 100 * time.Millisecond, 500 * time.Millisecond
+
+// fileVersion identifies the content of a file by its modification time and
+// size
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+// statVersion returns the current version of file, a missing file has the zero
+// version
+func statVersion(file string) fileVersion {
+	info, err := os.Stat(file)
+	if err != nil {
+		return fileVersion{}
+	}
+	return fileVersion{modTime: info.ModTime(), size: info.Size()}
+}
+
+// watch calls run once, and again every time file changes and then stays
+// unchanged for debounce, until ctx is done. file is polled every interval. A
+// timestamp and the run count are written to out after every run, errors of
+// run are logged without stopping the watch.
+func watch(ctx context.Context, file string, interval, debounce time.Duration, out io.Writer, run func() error) error {
+	runs := 0
+	rerun := func() {
+		runs++
+		if err := run(); err != nil {
+			logger.Error("error processing in watch mode", "file", file, "run", runs, "error", err)
+		}
+		fmt.Fprintf(out, "%s run %d finished, watching %s\n", time.Now().Format(time.RFC3339), runs, file)
+	}
+
+	version := statVersion(file)
+	rerun()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	// changedAt is when file was last seen changing, zero if there is no
+	// pending run
+	var changedAt time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			if current := statVersion(file); current != version {
+				version = current
+				changedAt = now
+				continue
+			}
+			if !changedAt.IsZero() && now.Sub(changedAt) >= debounce {
+				changedAt = time.Time{}
+				rerun()
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2020 Google LLC
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    https://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package process
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWatch(t *testing.T) {
+	input := writeInput(t, "name,zoneA,zoneB,zoneC\nbalanced,10 10,10 10,10 10\n")
+	config := Config{InputFile: input, OutputFile: filepath.Join(t.TempDir(), "output.csv"), Algorithm: "LocalShared"}
+
+	// modTimes are the distinct modification times of the output file seen
+	// after every run
+	var mu sync.Mutex
+	var modTimes []time.Time
+	run := func() error {
+		err := StartProcessingWithConfig(config)
+		info, serr := os.Stat(config.OutputFile)
+		if serr != nil {
+			t.Errorf("unexpected error reading output file: %v", serr)
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if len(modTimes) == 0 || !info.ModTime().Equal(modTimes[len(modTimes)-1]) {
+			modTimes = append(modTimes, info.ModTime())
+		}
+		return err
+	}
+	waitForRuns := func(expected int) {
+		t.Helper()
+		for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			mu.Lock()
+			runs := len(modTimes)
+			mu.Unlock()
+			if runs >= expected {
+				return
+			}
+		}
+		t.Fatalf("expected output file to be written %d times", expected)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var out bytes.Buffer
+	done := make(chan error)
+	go func() {
+		done <- watch(ctx, input, 10*time.Millisecond, 50*time.Millisecond, &out, run)
+	}()
+	waitForRuns(1)
+	for i := 0; i < 2; i++ {
+		// rapid saves are debounced into one run
+		for _, content := range []string{"name,zoneA,zoneB,zoneC\n", "name,zoneA,zoneB,zoneC\nunbalanced,1 5,2 20,7 20\n"} {
+			if err := os.WriteFile(input, []byte(content), 0600); err != nil {
+				t.Fatalf("unexpected error writing input file: %v", err)
+			}
+		}
+		waitForRuns(i + 2)
+	}
+	// wait for another possible run before stopping the watch
+	time.Sleep(200 * time.Millisecond)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error watching: %v", err)
+	}
+
+	if len(modTimes) != 3 {
+		t.Errorf("expected the initial run and 2 re-runs, got %d runs", len(modTimes))
+	}
+	if runs := strings.Count(out.String(), "finished"); runs != 3 {
+		t.Errorf("expected 3 runs written to out, got %d: %s", runs, out.String())
+	}
+	if !strings.Contains(out.String(), "run 3 finished") {
+		t.Errorf("expected run count in out, got %s", out.String())
+	}
+}